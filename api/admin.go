@@ -0,0 +1,31 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// AdminToken guards the admin endpoints. Admin endpoints are disabled while it is empty.
+var AdminToken string
+
+// RequireAdmin only lets requests carrying the admin token through, either as
+// a bearer token or in the X-Admin-Token header
+func RequireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if AdminToken == "" {
+			http.Error(w, `{"error": "Admin endpoints are disabled"}`, http.StatusForbidden)
+			return
+		}
+
+		token := r.Header.Get("X-Admin-Token")
+		if bearer, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); found {
+			token = bearer
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(AdminToken)) != 1 {
+			http.Error(w, `{"error": "Unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	handler := RequireAdmin(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	serve := func(header, value string) int {
+		req, _ := http.NewRequest("POST", "/", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		resp := httptest.NewRecorder()
+		handler(resp, req)
+		return resp.Code
+	}
+
+	// Edge case: Admin endpoints are disabled without a configured token
+	AdminToken = ""
+	if code := serve("X-Admin-Token", ""); code != http.StatusForbidden {
+		t.Errorf("Expected status code 403 when disabled, got %d", code)
+	}
+
+	AdminToken = "secret"
+	defer func() { AdminToken = "" }()
+
+	// Normal case: Token accepted in either header
+	if code := serve("X-Admin-Token", "secret"); code != http.StatusNoContent {
+		t.Errorf("Expected status code 204 with X-Admin-Token, got %d", code)
+	}
+	if code := serve("Authorization", "Bearer secret"); code != http.StatusNoContent {
+		t.Errorf("Expected status code 204 with bearer token, got %d", code)
+	}
+
+	// Edge case: Missing or wrong token
+	if code := serve("", ""); code != http.StatusUnauthorized {
+		t.Errorf("Expected status code 401 without token, got %d", code)
+	}
+	if code := serve("X-Admin-Token", "wrong"); code != http.StatusUnauthorized {
+		t.Errorf("Expected status code 401 with wrong token, got %d", code)
+	}
+}
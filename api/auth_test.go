@@ -0,0 +1,162 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backend-intern-assignment/models"
+	"backend-intern-assignment/storage"
+	"backend-intern-assignment/utils"
+)
+
+func TestRequireAPIKey(t *testing.T) {
+	handler := RequireAPIKey(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(requestTenant(r)))
+	})
+	serve := func(header, value string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		resp := httptest.NewRecorder()
+		handler(resp, req)
+		return resp
+	}
+
+	// Normal case: Without configured keys requests pass without a tenant
+	APIKeys = nil
+	if resp := serve("", ""); resp.Code != http.StatusOK || resp.Body.String() != "" {
+		t.Errorf("Expected status code 200 without a tenant, got %d %q", resp.Code, resp.Body.String())
+	}
+
+	APIKeys = map[string]string{"k-acme": "acme", "k-globex": "globex"}
+	defer func() { APIKeys = nil }()
+
+	// Normal case: Key accepted in either header and mapped to its tenant
+	if resp := serve(APIKeyHeader, "k-acme"); resp.Code != http.StatusOK || resp.Body.String() != "acme" {
+		t.Errorf("Expected tenant acme with X-API-Key, got %d %q", resp.Code, resp.Body.String())
+	}
+	if resp := serve("Authorization", "Bearer k-globex"); resp.Code != http.StatusOK || resp.Body.String() != "globex" {
+		t.Errorf("Expected tenant globex with bearer token, got %d %q", resp.Code, resp.Body.String())
+	}
+
+	// Edge case: Missing, empty or unknown keys
+	if resp := serve("", ""); resp.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status code 401 without key, got %d", resp.Code)
+	}
+	if resp := serve("Authorization", "Bearer "); resp.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status code 401 with empty key, got %d", resp.Code)
+	}
+	if resp := serve(APIKeyHeader, "k-initech"); resp.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status code 401 with unknown key, got %d", resp.Code)
+	}
+}
+
+func TestTenantIsolation(t *testing.T) {
+	router := NewRouter()
+	models.InitTestStoreMaster()
+	APIKeys = map[string]string{"k-acme": "acme", "k-globex": "globex"}
+	defer func() { APIKeys = nil }()
+
+	serve := func(method, url, key string, body []byte) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, url, bytes.NewReader(body))
+		req.Header.Set(APIKeyHeader, key)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	jobID := models.CreateTenantJob("acme", models.JobRequest{
+		Count:  1,
+		Visits: []models.Visit{{StoreID: "RP00001", VisitTime: "2036-02-01T09:00:00Z", ImageURLs: []string{"acme.jpg"}}},
+	})
+	models.StoreImageResult(jobID, models.ImageResult{
+		StoreID: "RP00001", VisitTime: "2036-02-01T09:00:00Z", ImageURL: "acme.jpg", Metrics: utils.Metrics{"perimeter": 80},
+	})
+	period := "from=2036-02-01T00:00:00Z&to=2036-03-01T00:00:00Z"
+
+	// Normal case: Submitted jobs belong to the submitting tenant
+	t.Run("SubmitTagsTenant", func(t *testing.T) {
+		payload, _ := json.Marshal(models.JobRequest{Count: 0, Visits: []models.Visit{}})
+		resp := serve("POST", "/api/submit/", "k-globex", payload)
+		if resp.Code != http.StatusCreated {
+			t.Fatalf("Expected status code 201, got %d", resp.Code)
+		}
+		var body map[string]interface{}
+		json.Unmarshal(resp.Body.Bytes(), &body)
+		jobID, _ := body["job_id"].(string)
+		job, _ := models.SnapshotJob(jobID)
+		if job.Tenant != "globex" {
+			t.Errorf("Expected tenant globex, got %q", job.Tenant)
+		}
+	})
+
+	// Normal case: The owner sees its job
+	t.Run("OwnerSeesJob", func(t *testing.T) {
+		if resp := serve("GET", "/api/status?jobid="+jobID, "k-acme", nil); resp.Code != http.StatusOK {
+			t.Errorf("Expected status code 200 for the owner, got %d", resp.Code)
+		}
+		resp := serve("GET", "/api/jobs/"+jobID+"/results", "k-acme", nil)
+		if resp.Code != http.StatusOK || !strings.Contains(resp.Body.String(), "acme.jpg") {
+			t.Errorf("Expected the owner's results, got %d %q", resp.Code, resp.Body.String())
+		}
+	})
+
+	// Edge case: Other tenants cannot tell the job exists
+	t.Run("OtherTenantIsDenied", func(t *testing.T) {
+		if resp := serve("GET", "/api/status?jobid="+jobID, "k-globex", nil); resp.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code 400 as for unknown jobs, got %d", resp.Code)
+		}
+		if resp := serve("GET", "/api/jobs/"+jobID+"/results", "k-globex", nil); resp.Code != http.StatusNotFound {
+			t.Errorf("Expected status code 404 as for unknown jobs, got %d", resp.Code)
+		}
+	})
+
+	// Edge case: Bulk exports and reports only include the tenant's jobs
+	t.Run("ListsAreScoped", func(t *testing.T) {
+		resp := serve("GET", "/api/exports/results?format=ndjson&"+period, "k-globex", nil)
+		if strings.Contains(resp.Body.String(), "acme.jpg") {
+			t.Errorf("Expected another tenant's results to be left out, got %q", resp.Body.String())
+		}
+		resp = serve("GET", "/api/exports/results?format=ndjson&"+period, "k-acme", nil)
+		if !strings.Contains(resp.Body.String(), "acme.jpg") {
+			t.Errorf("Expected the tenant's results, got %q", resp.Body.String())
+		}
+
+		resp = serve("GET", "/api/reports/stores?"+period, "k-globex", nil)
+		if strings.Contains(resp.Body.String(), "RP00001") {
+			t.Errorf("Expected an empty report for another tenant, got %s", resp.Body.String())
+		}
+		resp = serve("GET", "/api/reports/stores?"+period, "k-acme", nil)
+		if !strings.Contains(resp.Body.String(), "RP00001") {
+			t.Errorf("Expected the tenant's visit in its report, got %s", resp.Body.String())
+		}
+	})
+
+	// Edge case: Stored images are only served to their tenant
+	t.Run("ImagesAreScoped", func(t *testing.T) {
+		store, _ := storage.NewLocalStore(t.TempDir())
+		storage.Default = store
+		defer func() { storage.Default = nil }()
+		key := storage.TenantKey("acme", "abc_thumb.jpg")
+		store.Put(key, strings.NewReader("thumbnail-bytes"))
+
+		if resp := serve("GET", "/api/images/"+key, "k-acme", nil); resp.Code != http.StatusOK {
+			t.Errorf("Expected status code 200 for the owner, got %d", resp.Code)
+		}
+		if resp := serve("GET", "/api/images/"+key, "k-globex", nil); resp.Code != http.StatusNotFound {
+			t.Errorf("Expected status code 404 for another tenant, got %d", resp.Code)
+		}
+	})
+
+	// Edge case: Job endpoints require a key
+	t.Run("Unauthenticated", func(t *testing.T) {
+		if resp := serve("GET", "/api/status?jobid="+jobID, "", nil); resp.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status code 401, got %d", resp.Code)
+		}
+	})
+}
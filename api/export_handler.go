@@ -0,0 +1,102 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"backend-intern-assignment/export"
+	"backend-intern-assignment/models"
+
+	"github.com/gorilla/mux"
+)
+
+// ExportJobResults streams one row per image of a job as CSV, NDJSON or
+// Parquet. Jobs of other tenants are not found.
+func ExportJobResults(w http.ResponseWriter, r *http.Request) {
+	jobID := mux.Vars(r)["id"]
+	if !models.IsJobID(jobID) {
+		http.Error(w, `{"error": "Invalid job ID"}`, http.StatusBadRequest)
+		return
+	}
+	job, err := models.SnapshotJob(jobID)
+	if err != nil || job.Tenant != requestTenant(r) {
+		http.Error(w, `{"error": "Job not found"}`, http.StatusNotFound)
+		return
+	}
+
+	format := exportFormat(r)
+	writer, ok := startExport(w, r, format, "job-"+jobID+"-results")
+	if !ok {
+		return
+	}
+	for _, row := range models.JobResultRows(job) {
+		if err := writer.Write(row); err != nil {
+			requestLogger(r).Error("Failed to export results", "job_id", jobID, "error", err)
+			return
+		}
+	}
+	if err := writer.Close(); err != nil {
+		requestLogger(r).Error("Failed to export results", "job_id", jobID, "error", err)
+	}
+}
+
+// ExportResults streams the results of the tenant's visits in a time range, one job at a time
+func ExportResults(w http.ResponseWriter, r *http.Request) {
+	period, err := reportPeriod(r)
+	if err != nil {
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+
+	format := exportFormat(r)
+	filename := fmt.Sprintf("results-%s-%s", period.From.Format("20060102T150405Z"), period.To.Format("20060102T150405Z"))
+	writer, ok := startExport(w, r, format, filename)
+	if !ok {
+		return
+	}
+	for _, jobID := range models.JobIDs(requestTenant(r)) {
+		job, err := models.SnapshotJob(jobID)
+		if err != nil {
+			continue
+		}
+		for _, row := range models.JobResultRows(job) {
+			if !row.InPeriod(period) {
+				continue
+			}
+			if err := writer.Write(row); err != nil {
+				requestLogger(r).Error("Failed to export results", "error", err)
+				return
+			}
+		}
+	}
+	if err := writer.Close(); err != nil {
+		requestLogger(r).Error("Failed to export results", "error", err)
+	}
+}
+
+// exportFormat reads the "format" query parameter, defaulting to CSV
+func exportFormat(r *http.Request) string {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format
+	}
+	return export.FormatCSV
+}
+
+// startExport writes the response headers and returns a writer for the rows.
+// It responds with 400 and returns false for unknown formats.
+func startExport(w http.ResponseWriter, r *http.Request, format, filename string) (export.Writer, bool) {
+	contentType := export.ContentType(format)
+	if contentType == "" {
+		http.Error(w, `{"error": "Unknown format"}`, http.StatusBadRequest)
+		return nil, false
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, filename, format))
+
+	writer, err := export.NewWriter(format, w)
+	if err != nil {
+		requestLogger(r).Error("Failed to start export", "format", format, "error", err)
+		return nil, false
+	}
+	return writer, true
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backend-intern-assignment/models"
+	"backend-intern-assignment/utils"
+)
+
+func TestExportJobResults(t *testing.T) {
+	router := NewRouter()
+	models.InitTestStoreMaster()
+
+	jobID := models.CreateJob(models.JobRequest{
+		Count:  1,
+		Visits: []models.Visit{{StoreID: "RP00001", VisitTime: "2035-06-01T09:00:00Z", ImageURLs: []string{"a.jpg"}}},
+	})
+	models.StoreImageResult(jobID, models.ImageResult{
+		StoreID: "RP00001", VisitTime: "2035-06-01T09:00:00Z", ImageURL: "a.jpg", Metrics: utils.Metrics{"perimeter": 600},
+	})
+
+	// Normal case: CSV is the default format
+	t.Run("CSV", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/jobs/"+jobID+"/results", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusOK {
+			t.Fatalf("Expected status code 200, got %d", resp.Code)
+		}
+		if resp.Header().Get("Content-Type") != "text/csv" {
+			t.Errorf("Expected text/csv, got %q", resp.Header().Get("Content-Type"))
+		}
+		want := jobID + ",RP00001,B P STORE,7100015,2035-06-01T09:00:00Z,a.jpg,600,processed,"
+		if !strings.Contains(resp.Body.String(), want) {
+			t.Errorf("Expected row %q, got %q", want, resp.Body.String())
+		}
+	})
+
+	// Normal case: NDJSON and Parquet are selected with the format parameter
+	t.Run("OtherFormats", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/jobs/"+jobID+"/results?format=ndjson", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		if !strings.Contains(resp.Body.String(), `"store_name":"B P STORE"`) {
+			t.Errorf("Expected NDJSON row, got %q", resp.Body.String())
+		}
+
+		req, _ = http.NewRequest("GET", "/api/jobs/"+jobID+"/results?format=parquet", nil)
+		resp = httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		if !bytes.HasPrefix(resp.Body.Bytes(), []byte("PAR1")) {
+			t.Errorf("Expected Parquet file, got %q", resp.Body.Bytes())
+		}
+	})
+
+	// Edge case: Unknown jobs and formats are rejected
+	t.Run("Invalid", func(t *testing.T) {
+		cases := map[string]int{
+			"/api/jobs/018f3c2a-7b41-7c3e-9a52-4d6e8f0a1b2c/results": http.StatusNotFound,
+			"/api/jobs/abc/results":                                  http.StatusBadRequest,
+			"/api/jobs/999999/results":                               http.StatusBadRequest,
+			"/api/jobs/" + jobID + "/results?format=xlsx":            http.StatusBadRequest,
+		}
+		for url, code := range cases {
+			req, _ := http.NewRequest("GET", url, nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+			if resp.Code != code {
+				t.Errorf("Expected status code %d for %s, got %d", code, url, resp.Code)
+			}
+		}
+	})
+}
+
+func TestExportResults(t *testing.T) {
+	router := NewRouter()
+	models.InitTestStoreMaster()
+
+	models.CreateJob(models.JobRequest{
+		Count: 2,
+		Visits: []models.Visit{
+			{StoreID: "RP00001", VisitTime: "2036-02-01T09:00:00Z", ImageURLs: []string{"in.jpg"}},
+			{StoreID: "RP00002", VisitTime: "2036-03-01T09:00:00Z", ImageURLs: []string{"out.jpg"}},
+		},
+	})
+
+	// Normal case: Only visits in the range are exported
+	t.Run("DateRange", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/exports/results?from=2036-02-01&to=2036-03-01&format=ndjson", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusOK {
+			t.Fatalf("Expected status code 200, got %d", resp.Code)
+		}
+		body := resp.Body.String()
+		if !strings.Contains(body, "in.jpg") || strings.Contains(body, "out.jpg") {
+			t.Errorf("Expected only in.jpg, got %q", body)
+		}
+	})
+
+	// Edge case: Invalid range is rejected
+	t.Run("InvalidRange", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/exports/results?from=2036-03-01&to=2036-02-01", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code 400, got %d", resp.Code)
+		}
+	})
+}
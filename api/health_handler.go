@@ -0,0 +1,139 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+
+	"backend-intern-assignment/models"
+	"backend-intern-assignment/storage"
+	"backend-intern-assignment/worker"
+)
+
+// Build information, set at link time, e.g.
+//
+//	go build -ldflags "-X backend-intern-assignment/api.Commit=$(git rev-parse HEAD)"
+//
+// Commit and BuildTime fall back to the VCS information embedded by go build.
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// BuildInfo describes the running binary
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildTime string `json:"build_time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+// ReadBuildInfo returns the linked build information, completed from the
+// binary's embedded VCS settings
+func ReadBuildInfo() BuildInfo {
+	info := BuildInfo{Version: Version, Commit: Commit, BuildTime: BuildTime, GoVersion: runtime.Version()}
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		case "vcs.time":
+			if info.BuildTime == "" {
+				info.BuildTime = setting.Value
+			}
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
+
+// Readiness check statuses
+const (
+	CheckOK   = "ok"
+	CheckFail = "fail"
+)
+
+// Check is the outcome of one readiness check
+type Check struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// GetHealth reports that the process is alive
+func GetHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": CheckOK})
+}
+
+// GetReadiness reports whether the server can take jobs: the store master is
+// loaded, the image store is reachable and the worker pool is accepting jobs
+// and not saturated. It responds 503 when any check fails.
+func GetReadiness(w http.ResponseWriter, r *http.Request) {
+	checks := map[string]Check{
+		"store_master": checkStoreMaster(),
+		"storage":      checkStorage(),
+		"worker_pool":  checkWorkerPool(),
+	}
+
+	status, code := CheckOK, http.StatusOK
+	for _, check := range checks {
+		if check.Status != CheckOK {
+			status, code = CheckFail, http.StatusServiceUnavailable
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": status,
+		"checks": checks,
+	})
+}
+
+// GetVersion returns the build information of the running binary
+func GetVersion(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ReadBuildInfo())
+}
+
+func checkStoreMaster() Check {
+	if models.StoreMasterStatus().VersionID == "" {
+		return Check{Status: CheckFail, Error: "store master not loaded"}
+	}
+	return Check{Status: CheckOK}
+}
+
+// checkStorage passes when no image store is configured
+func checkStorage() Check {
+	checker, ok := storage.Default.(storage.Checker)
+	if !ok {
+		return Check{Status: CheckOK}
+	}
+	if err := checker.Check(); err != nil {
+		return Check{Status: CheckFail, Error: err.Error()}
+	}
+	return Check{Status: CheckOK}
+}
+
+// checkWorkerPool fails while the pool drains or its queue is full. Without a
+// running pool jobs are processed in their own goroutines.
+func checkWorkerPool() Check {
+	if worker.Draining() {
+		return Check{Status: CheckFail, Error: "worker pool is shutting down"}
+	}
+	depth, capacity := worker.QueueStats()
+	if capacity > 0 && depth >= capacity {
+		return Check{Status: CheckFail, Error: fmt.Sprintf("job queue is full (%d jobs)", depth)}
+	}
+	return Check{Status: CheckOK}
+}
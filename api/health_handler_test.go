@@ -0,0 +1,169 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"backend-intern-assignment/models"
+	"backend-intern-assignment/storage"
+	"backend-intern-assignment/worker"
+)
+
+type readinessResponse struct {
+	Status string           `json:"status"`
+	Checks map[string]Check `json:"checks"`
+}
+
+func getReadiness(t *testing.T) (int, readinessResponse) {
+	t.Helper()
+	req, _ := http.NewRequest("GET", "/readyz", nil)
+	resp := httptest.NewRecorder()
+	NewRouter().ServeHTTP(resp, req)
+
+	var body readinessResponse
+	if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Expected a JSON body, got %s", resp.Body.String())
+	}
+	return resp.Code, body
+}
+
+func TestGetHealth(t *testing.T) {
+	router := NewRouter()
+
+	// Normal case: Liveness always succeeds
+	t.Run("Alive", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/healthz", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusOK {
+			t.Errorf("Expected status code 200, got %d", resp.Code)
+		}
+		if resp.Body.String() != "{\"status\":\"ok\"}\n" {
+			t.Errorf("Unexpected body: %s", resp.Body.String())
+		}
+	})
+}
+
+// restartPool replaces the worker pool, stopping the current one first so its
+// workers do not outlive it
+func restartPool(size, queueSize int) {
+	worker.Shutdown(context.Background())
+	worker.StartPool(size, queueSize)
+}
+
+func TestGetReadiness(t *testing.T) {
+	models.InitTestStoreMaster()
+	restartPool(1, 10)
+
+	// Normal case: Loaded store master, reachable storage and an idle pool
+	t.Run("Ready", func(t *testing.T) {
+		store, err := storage.NewLocalStore(t.TempDir())
+		if err != nil {
+			t.Fatalf("Expected no error creating store, got %v", err)
+		}
+		storage.Default = store
+		defer func() { storage.Default = nil }()
+
+		code, body := getReadiness(t)
+		if code != http.StatusOK {
+			t.Errorf("Expected status code 200, got %d", code)
+		}
+		if body.Status != CheckOK {
+			t.Errorf("Expected status ok, got %s", body.Status)
+		}
+		for _, name := range []string{"store_master", "storage", "worker_pool"} {
+			if body.Checks[name].Status != CheckOK {
+				t.Errorf("Expected check %s to pass, got %+v", name, body.Checks[name])
+			}
+		}
+	})
+
+	// Edge case: Unreachable storage fails readiness
+	t.Run("StorageUnreachable", func(t *testing.T) {
+		storage.Default = &storage.LocalStore{Root: filepath.Join(t.TempDir(), "missing")}
+		defer func() { storage.Default = nil }()
+
+		code, body := getReadiness(t)
+		if code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status code 503, got %d", code)
+		}
+		if body.Checks["storage"].Status != CheckFail || body.Checks["storage"].Error == "" {
+			t.Errorf("Expected a failed storage check with an error, got %+v", body.Checks["storage"])
+		}
+		if body.Checks["store_master"].Status != CheckOK {
+			t.Errorf("Expected the store master check to pass, got %+v", body.Checks["store_master"])
+		}
+	})
+
+	// Edge case: A full job queue fails readiness
+	t.Run("QueueFull", func(t *testing.T) {
+		restartPool(0, 1)
+		defer restartPool(1, 10)
+		jobID := models.CreateJob(models.JobRequest{Count: 0})
+		worker.Enqueue(jobID)
+
+		code, body := getReadiness(t)
+		if code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status code 503, got %d", code)
+		}
+		if body.Checks["worker_pool"].Status != CheckFail {
+			t.Errorf("Expected a failed worker pool check, got %+v", body.Checks["worker_pool"])
+		}
+	})
+
+	// Edge case: A draining pool fails readiness
+	t.Run("Draining", func(t *testing.T) {
+		worker.Shutdown(context.Background())
+		defer worker.StartPool(1, 10)
+
+		code, body := getReadiness(t)
+		if code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status code 503, got %d", code)
+		}
+		if body.Checks["worker_pool"].Error != "worker pool is shutting down" {
+			t.Errorf("Expected the shutdown to be reported, got %+v", body.Checks["worker_pool"])
+		}
+	})
+}
+
+func TestGetVersion(t *testing.T) {
+	router := NewRouter()
+
+	// Normal case: Linked build information is reported
+	t.Run("LinkedInfo", func(t *testing.T) {
+		defer func(version, commit, buildTime string) {
+			Version, Commit, BuildTime = version, commit, buildTime
+		}(Version, Commit, BuildTime)
+		Version, Commit, BuildTime = "1.2.3", "abc123", "2024-01-02T03:04:05Z"
+
+		req, _ := http.NewRequest("GET", "/version", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusOK {
+			t.Errorf("Expected status code 200, got %d", resp.Code)
+		}
+		var info BuildInfo
+		json.Unmarshal(resp.Body.Bytes(), &info)
+		if info.Version != "1.2.3" || info.Commit != "abc123" || info.BuildTime != "2024-01-02T03:04:05Z" {
+			t.Errorf("Expected the linked build information, got %+v", info)
+		}
+		if info.GoVersion != runtime.Version() {
+			t.Errorf("Expected Go version %s, got %s", runtime.Version(), info.GoVersion)
+		}
+	})
+
+	// Edge case: Without linked values the version defaults to dev
+	t.Run("Defaults", func(t *testing.T) {
+		info := ReadBuildInfo()
+		if info.Version != "dev" {
+			t.Errorf("Expected version dev, got %s", info.Version)
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"backend-intern-assignment/storage"
+
+	"github.com/gorilla/mux"
+)
+
+// GetImage serves a stored original image or thumbnail by its key. Images of
+// other tenants are answered as unknown.
+func GetImage(w http.ResponseWriter, r *http.Request) {
+	if storage.Default == nil {
+		http.Error(w, `{"error": "Image storage is disabled"}`, http.StatusNotFound)
+		return
+	}
+
+	key := mux.Vars(r)["key"]
+	if !storage.KeyOwnedBy(key, requestTenant(r)) {
+		http.Error(w, `{"error": "Image not found"}`, http.StatusNotFound)
+		return
+	}
+	body, contentType, err := storage.Default.Get(key)
+	if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+		http.Error(w, `{"error": "Image not found"}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	defer body.Close()
+
+	// Keys are content-addressed, so the bytes behind a key never change, but
+	// images belong to a tenant and must not be kept by shared caches
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+	io.Copy(w, body)
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backend-intern-assignment/storage"
+)
+
+func TestGetImage(t *testing.T) {
+	router := NewRouter()
+
+	store, err := storage.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Expected no error creating store, got %v", err)
+	}
+	store.Put("abc_thumb.jpg", strings.NewReader("thumbnail-bytes"))
+	storage.Default = store
+	defer func() { storage.Default = nil }()
+
+	// Normal case: Stored image is served with its content type
+	t.Run("ExistingImage", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/images/abc_thumb.jpg", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusOK {
+			t.Errorf("Expected status code 200, got %d", resp.Code)
+		}
+		if resp.Header().Get("Content-Type") != "image/jpeg" {
+			t.Errorf("Expected content type image/jpeg, got %s", resp.Header().Get("Content-Type"))
+		}
+		if resp.Body.String() != "thumbnail-bytes" {
+			t.Errorf("Unexpected body: %s", resp.Body.String())
+		}
+	})
+
+	// Edge case: Unknown key
+	t.Run("MissingImage", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/images/missing.jpg", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusNotFound {
+			t.Errorf("Expected status code 404 for missing image, got %d", resp.Code)
+		}
+	})
+
+	// Edge case: Storage disabled
+	t.Run("StorageDisabled", func(t *testing.T) {
+		storage.Default = nil
+		defer func() { storage.Default = store }()
+
+		req, _ := http.NewRequest("GET", "/api/images/abc_thumb.jpg", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusNotFound {
+			t.Errorf("Expected status code 404 when storage is disabled, got %d", resp.Code)
+		}
+	})
+}
@@ -5,10 +5,18 @@ import (
 	"net/http"
 	"strconv"
 
+	"backend-intern-assignment/logging"
 	"backend-intern-assignment/models"
+	"backend-intern-assignment/tracing"
+	"backend-intern-assignment/utils"
 	"backend-intern-assignment/worker"
 )
 
+// LegacyJobIDs makes /api/status accept the numeric job IDs used before job
+// IDs became UUIDs, and responses include them. Numeric IDs are sequential and
+// easy to enumerate, so they are off unless clients still need them.
+var LegacyJobIDs = false
+
 // SubmitJob handles job submission
 func SubmitJob(w http.ResponseWriter, r *http.Request) {
 	var jobRequest models.JobRequest
@@ -17,38 +25,92 @@ func SubmitJob(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error": "Invalid request"}`, http.StatusBadRequest)
 		return
 	}
+	if err := utils.ValidateAnalyzers(jobRequest.Analyzers); err != nil {
+		http.Error(w, `{"error": "Unknown analyzer"}`, http.StatusBadRequest)
+		return
+	}
+
+	if worker.Draining() {
+		http.Error(w, `{"error": "Server is shutting down"}`, http.StatusServiceUnavailable)
+		return
+	}
+	if !checkConcurrentJobs(w, r) || !takeImageQuota(w, r, jobRequest) {
+		return
+	}
+
+	ctx, span := tracing.Start(r.Context(), "SubmitJob")
+	defer span.End()
 
-	jobID := models.CreateJob(jobRequest)
-	go worker.ProcessJob(jobID)
+	jobID := models.CreateTenantJob(requestTenant(r), jobRequest)
+	span.SetAttributes(tracing.String("job.id", jobID), tracing.Int("job.visits", len(jobRequest.Visits)))
+	models.SetJobRequestID(jobID, logging.RequestID(ctx))
+	models.SetJobTraceParent(jobID, tracing.FormatTraceParent(span.SpanContext()))
+	if err := worker.EnqueueContext(ctx, jobID); err != nil {
+		span.RecordError(err)
+		ImageQuota.Return(requestTenant(r), requestImages(jobRequest))
+		models.InterruptJob(jobID, "server shut down before the job started")
+		http.Error(w, `{"error": "Server is shutting down"}`, http.StatusServiceUnavailable)
+		return
+	}
+	requestLogger(r).Info("Job submitted", "job_id", jobID, "visits", len(jobRequest.Visits))
 
 	w.WriteHeader(http.StatusCreated)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]int{"job_id": jobID})
+	response := map[string]interface{}{"job_id": jobID}
+	if LegacyJobIDs {
+		job, _ := models.SnapshotJob(jobID)
+		response["legacy_job_id"] = job.LegacyID
+	}
+	json.NewEncoder(w).Encode(response)
 }
 
-// GetJobStatus retrieves the status of a job
+// GetJobStatus retrieves the status of a job. Jobs of other tenants are
+// reported like unknown jobs.
 func GetJobStatus(w http.ResponseWriter, r *http.Request) {
-	jobIDParam := r.URL.Query().Get("jobid")
-	jobID, err := strconv.Atoi(jobIDParam)
-	if err != nil {
+	jobID, ok := statusJobID(r.URL.Query().Get("jobid"))
+	if !ok {
 		http.Error(w, "", http.StatusBadRequest)
 		return
 	}
 
-	status, errors, err := models.GetJobStatus(jobID)
-	if err != nil {
+	job, err := models.SnapshotJob(jobID)
+	if err != nil || job.Tenant != requestTenant(r) {
 		http.Error(w, "", http.StatusBadRequest)
 		return
 	}
 
 	response := map[string]interface{}{
-		"status": status,
-		"job_id": jobID,
+		"status": job.Status,
+		"job_id": job.ID,
 	}
-	if status == "failed" {
-		response["error"] = errors
+	if LegacyJobIDs {
+		response["legacy_job_id"] = job.LegacyID
+	}
+	if job.StoreMasterVersion != "" {
+		response["store_master_version"] = job.StoreMasterVersion
+	}
+	if job.Status == "failed" || job.Status == models.JobStatusInterrupted {
+		response["error"] = job.Errors
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+// statusJobID returns the job ID named by the jobid parameter, which is a
+// UUID or, while LegacyJobIDs is set, a legacy numeric ID. Unknown legacy IDs
+// resolve to no job.
+func statusJobID(param string) (string, bool) {
+	if models.IsJobID(param) {
+		return param, true
+	}
+	if !LegacyJobIDs {
+		return "", false
+	}
+	legacyID, err := strconv.Atoi(param)
+	if err != nil {
+		return "", false
+	}
+	jobID, _ := models.LookupLegacyJobID(legacyID)
+	return jobID, true
+}
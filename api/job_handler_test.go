@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -10,20 +11,10 @@ import (
 
 	"backend-intern-assignment/models"
 	"backend-intern-assignment/worker"
-
-	"github.com/gorilla/mux"
 )
 
-// Helper to set up the router
-func setupRouter() *mux.Router {
-	router := mux.NewRouter()
-	router.HandleFunc("/api/submit/", SubmitJob).Methods("POST")
-	router.HandleFunc("/api/status", GetJobStatus).Methods("GET")
-	return router
-}
-
 func TestSubmitJob(t *testing.T) {
-	router := setupRouter()
+	router := NewRouter()
 
 	// Normal case: Valid job request
 	t.Run("ValidJobRequest", func(t *testing.T) {
@@ -47,10 +38,13 @@ func TestSubmitJob(t *testing.T) {
 			t.Errorf("Expected status code 201, got %d", resp.Code)
 		}
 
-		var response map[string]int
+		var response map[string]interface{}
 		json.Unmarshal(resp.Body.Bytes(), &response)
-		if _, exists := response["job_id"]; !exists {
-			t.Errorf("Response does not contain job_id")
+		if jobID, _ := response["job_id"].(string); !models.IsJobID(jobID) {
+			t.Errorf("Expected a UUID job_id, got %v", response["job_id"])
+		}
+		if _, exists := response["legacy_job_id"]; exists {
+			t.Errorf("Expected no legacy_job_id by default, got %v", response["legacy_job_id"])
 		}
 	})
 
@@ -66,6 +60,30 @@ func TestSubmitJob(t *testing.T) {
 		}
 	})
 
+	// Edge case: Unknown analyzer requested
+	t.Run("UnknownAnalyzer", func(t *testing.T) {
+		jobRequest := models.JobRequest{
+			Count: 1,
+			Visits: []models.Visit{
+				{
+					StoreID:   "RP00001",
+					ImageURLs: []string{"https://example.com/image.jpg"},
+					VisitTime: "2023-10-21T15:04:05Z",
+				},
+			},
+			Analyzers: []string{"perimeter", "bogus"},
+		}
+		payload, _ := json.Marshal(jobRequest)
+		req, _ := http.NewRequest("POST", "/api/submit/", bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code 400 for unknown analyzer, got %d", resp.Code)
+		}
+	})
+
 	// Edge case: Mismatched count and visits
 	t.Run("MismatchedCountAndVisits", func(t *testing.T) {
 		jobRequest := models.JobRequest{
@@ -88,10 +106,30 @@ func TestSubmitJob(t *testing.T) {
 			t.Errorf("Expected status code 400 for mismatched count, got %d", resp.Code)
 		}
 	})
+
+	// Edge case: Submissions are refused once the worker pool is shutting down
+	t.Run("ShuttingDown", func(t *testing.T) {
+		restartPool(1, 1)
+		worker.Shutdown(context.Background())
+		// Leave a running pool for later tests
+		defer worker.StartPool(1, 10)
+
+		payload, _ := json.Marshal(models.JobRequest{
+			Count:  1,
+			Visits: []models.Visit{{StoreID: "RP00001", ImageURLs: []string{"https://example.com/image.jpg"}, VisitTime: "2023-10-21T15:04:05Z"}},
+		})
+		req, _ := http.NewRequest("POST", "/api/submit/", bytes.NewBuffer(payload))
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status code 503 while shutting down, got %d", resp.Code)
+		}
+	})
 }
 
 func TestGetJobStatus(t *testing.T) {
-	router := setupRouter()
+	router := NewRouter()
 
 	// Normal case: Valid job ID
 	t.Run("ValidJobID", func(t *testing.T) {
@@ -109,7 +147,7 @@ func TestGetJobStatus(t *testing.T) {
 		jobID := models.CreateJob(jobRequest)
 		go worker.ProcessJob(jobID)
 
-		req, _ := http.NewRequest("GET", "/api/status?jobid="+strconv.Itoa(jobID), nil)
+		req, _ := http.NewRequest("GET", "/api/status?jobid="+jobID, nil)
 		resp := httptest.NewRecorder()
 		router.ServeHTTP(resp, req)
 
@@ -124,6 +162,51 @@ func TestGetJobStatus(t *testing.T) {
 		}
 	})
 
+	// Normal case: Legacy numeric IDs resolve to the job while turned on
+	t.Run("LegacyJobID", func(t *testing.T) {
+		LegacyJobIDs = true
+		defer func() { LegacyJobIDs = false }()
+		jobID := models.CreateJob(models.JobRequest{})
+		job, _ := models.SnapshotJob(jobID)
+
+		req, _ := http.NewRequest("GET", "/api/status?jobid="+strconv.Itoa(job.LegacyID), nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusOK {
+			t.Fatalf("Expected status code 200, got %d", resp.Code)
+		}
+		var response map[string]interface{}
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if response["job_id"] != jobID || response["legacy_job_id"] != float64(job.LegacyID) {
+			t.Errorf("Expected job %s with legacy ID %d, got %v", jobID, job.LegacyID, response)
+		}
+	})
+
+	// Edge case: Legacy numeric IDs are rejected by default
+	t.Run("LegacyJobIDDisabled", func(t *testing.T) {
+		jobID := models.CreateJob(models.JobRequest{})
+		job, _ := models.SnapshotJob(jobID)
+
+		req, _ := http.NewRequest("GET", "/api/status?jobid="+strconv.Itoa(job.LegacyID), nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code 400 for a legacy job ID, got %d", resp.Code)
+		}
+
+		req, _ = http.NewRequest("GET", "/api/status?jobid="+jobID, nil)
+		resp = httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		var response map[string]interface{}
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if _, exists := response["legacy_job_id"]; exists || resp.Code != http.StatusOK {
+			t.Errorf("Expected status code 200 without a legacy ID, got %d %v", resp.Code, response)
+		}
+	})
+
 	// Edge case: Missing job ID parameter
 	t.Run("MissingJobID", func(t *testing.T) {
 		req, _ := http.NewRequest("GET", "/api/status", nil)
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"backend-intern-assignment/logging"
+)
+
+// RequestIDHeader carries the request ID in requests and responses
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds client-supplied request IDs
+const maxRequestIDLength = 128
+
+// RequestLogger is router middleware that assigns each request an ID, taken
+// from the X-Request-ID header when the client sends a usable one, echoes it
+// in the response and logs the request once it completes. Handlers find the
+// ID and a logger carrying it in the request context.
+func RequestLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = logging.NewRequestID()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+		r = r.WithContext(logging.WithRequestID(r.Context(), requestID))
+
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		logging.FromContext(r.Context()).Info("Request completed",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"route", routeTemplate(r),
+			"status", recorder.status,
+			logging.Duration("duration", time.Since(start)))
+	})
+}
+
+// validRequestID accepts non-empty IDs of printable ASCII without spaces, so
+// they cannot break log lines
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] <= ' ' || requestID[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// requestLogger returns the logger of the request, which carries its request ID
+func requestLogger(r *http.Request) *slog.Logger {
+	return logging.FromContext(r.Context())
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"backend-intern-assignment/logging"
+	"backend-intern-assignment/models"
+)
+
+// syncBuffer collects log output that the worker may write concurrently
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestRequestLogger(t *testing.T) {
+	router := NewRouter()
+	var out syncBuffer
+	logger, _ := logging.New(&out, "info", logging.FormatText)
+	original := slog.Default()
+	slog.SetDefault(logger)
+	defer slog.SetDefault(original)
+
+	// Normal case: A client request ID is echoed, logged and recorded on the submitted job
+	t.Run("ClientRequestID", func(t *testing.T) {
+		payload := `{"count": 1, "visits": [{"store_id": "RX99999", "image_url": [], "visit_time": "2023-10-21T15:04:05Z"}]}`
+		req, _ := http.NewRequest("POST", "/api/submit/", strings.NewReader(payload))
+		req.Header.Set(RequestIDHeader, "client-req-1")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusCreated {
+			t.Fatalf("Expected status code 201, got %d", resp.Code)
+		}
+		if got := resp.Header().Get(RequestIDHeader); got != "client-req-1" {
+			t.Errorf("Expected the request ID to be echoed, got %q", got)
+		}
+		logs := out.String()
+		if !strings.Contains(logs, `msg="Request completed" request_id=client-req-1 method=POST`) ||
+			!strings.Contains(logs, "route=/api/submit/ status=201") {
+			t.Errorf("Expected a request log line with the request ID, got %q", logs)
+		}
+
+		var jobID string
+		for _, id := range models.JobIDs("") {
+			if job, _ := models.SnapshotJob(id); job.RequestID == "client-req-1" {
+				jobID = id
+			}
+		}
+		if jobID == "" {
+			t.Errorf("Expected the submitted job to record the request ID")
+		}
+	})
+
+	// Edge case: Missing or unusable request IDs are replaced by generated ones
+	t.Run("GeneratedRequestID", func(t *testing.T) {
+		for _, header := range []string{"", "has space", strings.Repeat("x", 200)} {
+			req, _ := http.NewRequest("GET", "/api/stores", nil)
+			req.Header.Set(RequestIDHeader, header)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			if got := resp.Header().Get(RequestIDHeader); len(got) != 32 {
+				t.Errorf("Expected a generated request ID for %q, got %q", header, got)
+			}
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"backend-intern-assignment/metrics"
+)
+
+var (
+	httpRequests = metrics.NewCounter("http_requests_total",
+		"HTTP requests, by route, method and status code.", "route", "method", "code")
+	httpDuration = metrics.NewHistogram("http_request_duration_seconds",
+		"HTTP request latency, by route and method.", metrics.DefaultBuckets, "route", "method")
+)
+
+// GetMetrics serves all metrics in the Prometheus text exposition format
+func GetMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	metrics.Default.WriteText(w)
+}
+
+// statusRecorder remembers the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Flush lets streaming handlers, such as exports, flush through the recorder
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// InstrumentRoutes is router middleware that counts requests and measures
+// their latency per route template, so /api/stores/{id} is one series
+func InstrumentRoutes(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeTemplate(r)
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		httpRequests.Inc(route, r.Method, strconv.Itoa(recorder.status))
+		httpDuration.Observe(time.Since(start).Seconds(), route, r.Method)
+	})
+}
+
+// routeTemplate returns the path template of the matched route, such as
+// /api/stores/{id}, or "unmatched"
+func routeTemplate(r *http.Request) string {
+	if current := mux.CurrentRoute(r); current != nil {
+		if template, err := current.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return "unmatched"
+}
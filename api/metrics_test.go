@@ -0,0 +1,58 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	router := NewRouter()
+
+	// Normal case: Requests are counted per route template, not per path
+	t.Run("RouteTemplates", func(t *testing.T) {
+		for _, path := range []string{"/api/stores/RP00001", "/api/stores/NOPE"} {
+			req, _ := http.NewRequest("GET", path, nil)
+			router.ServeHTTP(httptest.NewRecorder(), req)
+		}
+		before := httpRequests.Value("/api/stores/{id}", "GET", "404")
+
+		req, _ := http.NewRequest("GET", "/api/stores/MISSING", nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		if got := httpRequests.Value("/api/stores/{id}", "GET", "404"); got != before+1 {
+			t.Errorf("Expected the 404 count to grow by 1, got %v after %v", got, before)
+		}
+	})
+
+	// Normal case: The endpoint serves the text exposition format
+	t.Run("Endpoint", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/metrics", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusOK {
+			t.Fatalf("Expected status code 200, got %d", resp.Code)
+		}
+		if contentType := resp.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain") {
+			t.Errorf("Expected text/plain, got %q", contentType)
+		}
+		body := resp.Body.String()
+		for _, name := range []string{
+			"# TYPE http_requests_total counter",
+			"# TYPE http_request_duration_seconds histogram",
+			"# TYPE jobs_submitted_total counter",
+			"# TYPE job_queue_depth gauge",
+			"# TYPE image_download_duration_seconds histogram",
+			"# TYPE store_master_stores gauge",
+		} {
+			if !strings.Contains(body, name) {
+				t.Errorf("Expected metrics to contain %q", name)
+			}
+		}
+		if !strings.Contains(body, `http_requests_total{route="/api/stores/{id}",method="GET",code="404"}`) {
+			t.Errorf("Expected a series for /api/stores/{id}, got %s", body)
+		}
+	})
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend-intern-assignment/metrics"
+	"backend-intern-assignment/models"
+	"backend-intern-assignment/ratelimit"
+	"backend-intern-assignment/worker"
+)
+
+var (
+	// SubmitLimiter limits job submissions per tenant, or per client IP while
+	// authentication is disabled. It allows every submission by default.
+	SubmitLimiter = ratelimit.NewLimiter(0, 0)
+
+	// ImageQuota caps the images each tenant may submit per UTC day. It is
+	// unlimited by default.
+	ImageQuota = ratelimit.NewDailyQuota(0)
+
+	rateLimited = metrics.NewCounter("rate_limited_requests_total",
+		"Requests rejected with 429, by the limit they exceeded.", "limit")
+)
+
+// LimitSubmissions rejects submissions beyond SubmitLimiter with 429 and
+// reports the limit in X-RateLimit-* headers
+func LimitSubmissions(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		decision := SubmitLimiter.Allow(rateLimitKey(r))
+		if decision.Limit > 0 {
+			writeLimitHeaders(w, "X-RateLimit", decision)
+		}
+		if !decision.Allowed {
+			tooManyRequests(w, r, "submit_rate", decision, `{"error": "Rate limit exceeded"}`)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// takeImageQuota uses the images of a job request from the tenant's daily
+// quota, or responds with 429 and returns false when they exceed it
+func takeImageQuota(w http.ResponseWriter, r *http.Request, req models.JobRequest) bool {
+	decision := ImageQuota.Take(requestTenant(r), requestImages(req))
+	if decision.Limit > 0 {
+		writeLimitHeaders(w, "X-Quota", decision)
+	}
+	if !decision.Allowed {
+		tooManyRequests(w, r, "images_per_day", decision, `{"error": "Daily image quota exceeded"}`)
+		return false
+	}
+	return true
+}
+
+// concurrentJobsRetryAfter is how long a tenant with limits.concurrent_jobs
+// jobs in progress is asked to wait before submitting again
+const concurrentJobsRetryAfter = 5 * time.Second
+
+// checkConcurrentJobs responds with 429 and returns false when the tenant
+// already has worker.MaxJobsPerTenant jobs queued or running, and reports the
+// cap in X-Quota-Jobs-* headers
+func checkConcurrentJobs(w http.ResponseWriter, r *http.Request) bool {
+	limit := worker.MaxJobsPerTenant
+	if limit <= 0 {
+		return true
+	}
+	inProgress := worker.TenantJobs(requestTenant(r))
+	decision := ratelimit.Decision{
+		Allowed:    inProgress < limit,
+		Limit:      limit,
+		Remaining:  max(limit-inProgress-1, 0),
+		RetryAfter: concurrentJobsRetryAfter,
+	}
+	w.Header().Set("X-Quota-Jobs-Limit", strconv.Itoa(decision.Limit))
+	w.Header().Set("X-Quota-Jobs-Remaining", strconv.Itoa(decision.Remaining))
+	if !decision.Allowed {
+		tooManyRequests(w, r, "concurrent_jobs", decision, `{"error": "Too many jobs in progress"}`)
+		return false
+	}
+	return true
+}
+
+// requestImages counts the images of a job request
+func requestImages(req models.JobRequest) int {
+	images := 0
+	for _, visit := range req.Visits {
+		images += len(visit.ImageURLs)
+	}
+	return images
+}
+
+// rateLimitKey identifies the client a request is limited as
+func rateLimitKey(r *http.Request) string {
+	if tenant := requestTenant(r); tenant != "" {
+		return "tenant:" + tenant
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// writeLimitHeaders sets the -Limit, -Remaining and -Reset headers of a limit,
+// with the reset time in seconds
+func writeLimitHeaders(w http.ResponseWriter, prefix string, decision ratelimit.Decision) {
+	w.Header().Set(prefix+"-Limit", strconv.Itoa(decision.Limit))
+	w.Header().Set(prefix+"-Remaining", strconv.Itoa(decision.Remaining))
+	w.Header().Set(prefix+"-Reset", strconv.Itoa(ceilSeconds(decision.Reset)))
+}
+
+func tooManyRequests(w http.ResponseWriter, r *http.Request, limit string, decision ratelimit.Decision, body string) {
+	rateLimited.Inc(limit)
+	requestLogger(r).Warn("Request rate limited", "limit", limit, "retry_after", decision.RetryAfter.String())
+	w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(decision.RetryAfter)))
+	http.Error(w, body, http.StatusTooManyRequests)
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backend-intern-assignment/models"
+	"backend-intern-assignment/ratelimit"
+	"backend-intern-assignment/worker"
+)
+
+func TestRateLimits(t *testing.T) {
+	router := NewRouter()
+	originalLimiter, originalQuota := SubmitLimiter, ImageQuota
+	APIKeys = map[string]string{"k-acme": "acme", "k-globex": "globex"}
+	defer func() {
+		SubmitLimiter, ImageQuota = originalLimiter, originalQuota
+		APIKeys = nil
+	}()
+
+	submit := func(key string, images int) *httptest.ResponseRecorder {
+		visit := models.Visit{StoreID: "RP00001", VisitTime: "2023-10-21T15:04:05Z"}
+		for i := 0; i < images; i++ {
+			visit.ImageURLs = append(visit.ImageURLs, "https://example.com/image.jpg")
+		}
+		payload, _ := json.Marshal(models.JobRequest{Count: 1, Visits: []models.Visit{visit}})
+		req, _ := http.NewRequest("POST", "/api/submit/", bytes.NewBuffer(payload))
+		req.Header.Set(APIKeyHeader, key)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	// Normal case: Submissions beyond the burst are rejected with limit headers
+	t.Run("SubmitRate", func(t *testing.T) {
+		SubmitLimiter, ImageQuota = ratelimit.NewLimiter(0.5, 2), ratelimit.NewDailyQuota(0)
+		for i := 0; i < 2; i++ {
+			if resp := submit("k-acme", 1); resp.Code != http.StatusCreated {
+				t.Fatalf("Expected status code 201 within the burst, got %d", resp.Code)
+			}
+		}
+		resp := submit("k-acme", 1)
+		if resp.Code != http.StatusTooManyRequests {
+			t.Fatalf("Expected status code 429, got %d", resp.Code)
+		}
+		if resp.Header().Get("X-RateLimit-Limit") != "2" || resp.Header().Get("X-RateLimit-Remaining") != "0" {
+			t.Errorf("Expected limit 2 and 0 remaining, got %q and %q",
+				resp.Header().Get("X-RateLimit-Limit"), resp.Header().Get("X-RateLimit-Remaining"))
+		}
+		if resp.Header().Get("Retry-After") == "" || resp.Header().Get("X-RateLimit-Reset") == "" {
+			t.Errorf("Expected Retry-After and X-RateLimit-Reset headers, got %v", resp.Header())
+		}
+
+		// Edge case: Other tenants keep their own bucket
+		if resp := submit("k-globex", 1); resp.Code != http.StatusCreated {
+			t.Errorf("Expected status code 201 for another tenant, got %d", resp.Code)
+		}
+	})
+
+	// Normal case: Images beyond the daily quota are rejected
+	t.Run("ImageQuota", func(t *testing.T) {
+		SubmitLimiter, ImageQuota = ratelimit.NewLimiter(0, 0), ratelimit.NewDailyQuota(3)
+		resp := submit("k-acme", 2)
+		if resp.Code != http.StatusCreated || resp.Header().Get("X-Quota-Remaining") != "1" {
+			t.Fatalf("Expected status code 201 with 1 image remaining, got %d %q", resp.Code, resp.Header().Get("X-Quota-Remaining"))
+		}
+		resp = submit("k-acme", 2)
+		if resp.Code != http.StatusTooManyRequests {
+			t.Fatalf("Expected status code 429, got %d", resp.Code)
+		}
+		reset, _ := time.ParseDuration(resp.Header().Get("Retry-After") + "s")
+		if reset <= 0 || reset > 24*time.Hour {
+			t.Errorf("Expected Retry-After until the next UTC day, got %q", resp.Header().Get("Retry-After"))
+		}
+
+		// Edge case: A smaller job still fits the rest of the quota
+		if resp := submit("k-acme", 1); resp.Code != http.StatusCreated {
+			t.Errorf("Expected status code 201 for the remaining image, got %d", resp.Code)
+		}
+		if resp := submit("k-globex", 3); resp.Code != http.StatusCreated {
+			t.Errorf("Expected status code 201 for another tenant, got %d", resp.Code)
+		}
+	})
+
+	// Normal case: Submissions beyond the tenant's jobs in progress are rejected
+	t.Run("ConcurrentJobs", func(t *testing.T) {
+		SubmitLimiter, ImageQuota = ratelimit.NewLimiter(0, 0), ratelimit.NewDailyQuota(0)
+		// Without workers, submitted jobs stay queued until the pool is restarted
+		restartPool(0, 10)
+		defer restartPool(1, 10)
+		worker.MaxJobsPerTenant = 2
+		defer func() { worker.MaxJobsPerTenant = 0 }()
+
+		for i := 0; i < 2; i++ {
+			if resp := submit("k-acme", 1); resp.Code != http.StatusCreated {
+				t.Fatalf("Expected status code 201 within the cap, got %d", resp.Code)
+			}
+		}
+		resp := submit("k-acme", 1)
+		if resp.Code != http.StatusTooManyRequests {
+			t.Fatalf("Expected status code 429, got %d", resp.Code)
+		}
+		if resp.Header().Get("X-Quota-Jobs-Limit") != "2" || resp.Header().Get("X-Quota-Jobs-Remaining") != "0" {
+			t.Errorf("Expected limit 2 and 0 remaining, got %q and %q",
+				resp.Header().Get("X-Quota-Jobs-Limit"), resp.Header().Get("X-Quota-Jobs-Remaining"))
+		}
+		if resp.Header().Get("Retry-After") == "" {
+			t.Errorf("Expected a Retry-After header, got %v", resp.Header())
+		}
+
+		// Edge case: Other tenants keep their own jobs in progress
+		if resp := submit("k-globex", 1); resp.Code != http.StatusCreated || resp.Header().Get("X-Quota-Jobs-Remaining") != "1" {
+			t.Errorf("Expected status code 201 with 1 job remaining for another tenant, got %d", resp.Code)
+		}
+
+		// Normal case: Jobs stopped by the shutdown free the tenant's slots
+		restartPool(0, 10)
+		if jobs := worker.TenantJobs("acme"); jobs != 0 {
+			t.Errorf("Expected no acme jobs in progress, got %d", jobs)
+		}
+		if resp := submit("k-acme", 1); resp.Code != http.StatusCreated {
+			t.Errorf("Expected status code 201 once the slots are free, got %d", resp.Code)
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"backend-intern-assignment/models"
+)
+
+// DefaultReportWindow is the period covered by a report when "from" is not given
+const DefaultReportWindow = 7 * 24 * time.Hour
+
+// GetStoreReport returns visit, image and perimeter statistics of the tenant's jobs per store
+func GetStoreReport(w http.ResponseWriter, r *http.Request) {
+	period, err := reportPeriod(r)
+	if err != nil {
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"from":   period.From,
+		"to":     period.To,
+		"stores": models.StoreReport(requestTenant(r), period),
+	})
+}
+
+// GetAreaReport returns visit, image and perimeter statistics of the tenant's jobs per area code
+func GetAreaReport(w http.ResponseWriter, r *http.Request) {
+	period, err := reportPeriod(r)
+	if err != nil {
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"from":  period.From,
+		"to":    period.To,
+		"areas": models.AreaReport(requestTenant(r), period),
+	})
+}
+
+// GetCoverageReport lists the stores active during the period with the
+// tenant's successful visits, unvisited stores first. "area_code" limits the
+// report to one area and "unvisited=true" leaves out stores that were visited.
+func GetCoverageReport(w http.ResponseWriter, r *http.Request) {
+	period, err := reportPeriod(r)
+	if err != nil {
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	coverage := models.CoverageReport(requestTenant(r), period, query.Get("area_code"))
+	if query.Get("unvisited") == "true" {
+		coverage.Stores = coverage.Stores[:coverage.UnvisitedStores]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"from":      period.From,
+		"to":        period.To,
+		"area_code": query.Get("area_code"),
+		"coverage":  coverage,
+	})
+}
+
+// reportPeriod reads the "from" and "to" query parameters as dates or RFC 3339
+// timestamps. "to" defaults to now and "from" to DefaultReportWindow before "to".
+func reportPeriod(r *http.Request) (models.ReportPeriod, error) {
+	query := r.URL.Query()
+	period := models.ReportPeriod{To: time.Now().UTC()}
+
+	var err error
+	if value := query.Get("to"); value != "" {
+		if period.To, err = parseReportTime(value); err != nil {
+			return period, errors.New("Invalid to")
+		}
+	}
+	period.From = period.To.Add(-DefaultReportWindow)
+	if value := query.Get("from"); value != "" {
+		if period.From, err = parseReportTime(value); err != nil {
+			return period, errors.New("Invalid from")
+		}
+	}
+	if !period.From.Before(period.To) {
+		return period, errors.New("from must be before to")
+	}
+	return period, nil
+}
+
+// parseReportTime accepts a date (midnight UTC) or an RFC 3339 timestamp
+func parseReportTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
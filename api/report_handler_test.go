@@ -0,0 +1,130 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backend-intern-assignment/models"
+	"backend-intern-assignment/utils"
+)
+
+func TestReports(t *testing.T) {
+	router := NewRouter()
+	models.InitTestStoreMaster()
+
+	jobID := models.CreateJob(models.JobRequest{
+		Count: 2,
+		Visits: []models.Visit{
+			{StoreID: "RP00001", VisitTime: "2033-01-10T09:00:00Z", ImageURLs: []string{"a.jpg"}},
+			{StoreID: "RP00002", VisitTime: "2033-01-11T09:00:00Z", ImageURLs: []string{"b.jpg"}},
+		},
+	})
+	models.StoreImageResult(jobID, models.ImageResult{
+		StoreID: "RP00001", VisitTime: "2033-01-10T09:00:00Z", ImageURL: "a.jpg", Metrics: utils.Metrics{"perimeter": 42},
+	})
+	models.AppendJobError(jobID, models.JobError{
+		StoreID: "RP00002", VisitTime: "2033-01-11T09:00:00Z", Error: "Failed to download image", ImageURL: "b.jpg",
+	})
+
+	// Normal case: Store report over a date range
+	t.Run("StoreReport", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/reports/stores?from=2033-01-01&to=2033-02-01", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusOK {
+			t.Fatalf("Expected status code 200, got %d", resp.Code)
+		}
+		var response struct {
+			Stores []models.StoreReportRow `json:"stores"`
+		}
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if len(response.Stores) != 2 {
+			t.Fatalf("Expected 2 stores, got %+v", response.Stores)
+		}
+		first := response.Stores[0]
+		if first.StoreName != "B P STORE" || first.ProcessedImages != 1 || first.Perimeter == nil || first.Perimeter.Avg != 42 {
+			t.Errorf("Expected B P STORE with one processed image of perimeter 42, got %+v", first)
+		}
+		if response.Stores[1].Failures != 1 {
+			t.Errorf("Expected 1 failure for RP00002, got %+v", response.Stores[1])
+		}
+	})
+
+	// Normal case: Area report with RFC 3339 bounds
+	t.Run("AreaReport", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/reports/areas?from=2033-01-10T00:00:00Z&to=2033-01-11T00:00:00Z", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusOK {
+			t.Fatalf("Expected status code 200, got %d", resp.Code)
+		}
+		var response struct {
+			Areas []models.AreaReportRow `json:"areas"`
+		}
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if len(response.Areas) != 1 || response.Areas[0].AreaCode != "7100015" || response.Areas[0].Visits != 1 {
+			t.Errorf("Expected one visit in area 7100015, got %+v", response.Areas)
+		}
+	})
+
+	// Edge case: Invalid or reversed bounds are rejected
+	t.Run("InvalidPeriod", func(t *testing.T) {
+		for _, query := range []string{"from=yesterday", "to=2033-13-01", "from=2033-02-01&to=2033-01-01"} {
+			req, _ := http.NewRequest("GET", "/api/reports/stores?"+query, nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			if resp.Code != http.StatusBadRequest {
+				t.Errorf("Expected status code 400 for %q, got %d", query, resp.Code)
+			}
+		}
+	})
+}
+
+func TestCoverageReport(t *testing.T) {
+	router := NewRouter()
+	models.InitTestStoreMaster()
+
+	jobID := models.CreateJob(models.JobRequest{
+		Count:  1,
+		Visits: []models.Visit{{StoreID: "RP00001", VisitTime: "2038-04-05T09:00:00Z", ImageURLs: []string{"a.jpg"}}},
+	})
+	models.StoreImageResult(jobID, models.ImageResult{StoreID: "RP00001", VisitTime: "2038-04-05T09:00:00Z", ImageURL: "a.jpg"})
+
+	// Normal case: Coverage of an area over a week
+	t.Run("Coverage", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/reports/coverage?from=2038-04-01&to=2038-04-08&area_code=7100015", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusOK {
+			t.Fatalf("Expected status code 200, got %d", resp.Code)
+		}
+		var response struct {
+			Coverage models.StoreCoverage `json:"coverage"`
+		}
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if response.Coverage.TotalStores != 2 || response.Coverage.VisitedStores != 1 || len(response.Coverage.Stores) != 2 {
+			t.Errorf("Expected 1 of 2 stores visited, got %+v", response.Coverage)
+		}
+	})
+
+	// Normal case: Only unvisited stores are listed on request
+	t.Run("UnvisitedOnly", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/reports/coverage?from=2038-04-01&to=2038-04-08&unvisited=true", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		var response struct {
+			Coverage models.StoreCoverage `json:"coverage"`
+		}
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if len(response.Coverage.Stores) != 1 || response.Coverage.Stores[0].StoreID != "RP00002" {
+			t.Errorf("Expected only RP00002, got %+v", response.Coverage.Stores)
+		}
+	})
+}
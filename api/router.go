@@ -0,0 +1,32 @@
+package api
+
+import "github.com/gorilla/mux"
+
+// NewRouter returns the router serving every endpoint with its access checks
+// and the request logging, tracing and metrics middleware
+func NewRouter() *mux.Router {
+	r := mux.NewRouter()
+	r.HandleFunc("/api/submit/", RequireAPIKey(LimitSubmissions(SubmitJob))).Methods("POST")
+	r.HandleFunc("/api/status", RequireAPIKey(GetJobStatus)).Methods("GET")
+	r.HandleFunc("/api/images/{key}", RequireAPIKey(GetImage)).Methods("GET")
+	r.HandleFunc("/api/admin/stores/reload", RequireAdmin(ReloadStores)).Methods("POST")
+	r.HandleFunc("/api/admin/stores/version", RequireAdmin(GetStoreMasterStatus)).Methods("GET")
+	r.HandleFunc("/api/admin/stores/versions", RequireAdmin(ListStoreMasterVersions)).Methods("GET")
+	r.HandleFunc("/api/admin/stores/diff", RequireAdmin(DiffStoreMasterVersions)).Methods("GET")
+	r.HandleFunc("/api/stores", ListStores).Methods("GET")
+	r.HandleFunc("/api/stores", RequireAdmin(CreateStore)).Methods("POST")
+	r.HandleFunc("/api/stores/{id}", GetStore).Methods("GET")
+	r.HandleFunc("/api/stores/{id}", RequireAdmin(UpdateStore)).Methods("PUT")
+	r.HandleFunc("/api/stores/{id}/deactivate", RequireAdmin(DeactivateStore)).Methods("POST")
+	r.HandleFunc("/api/reports/stores", RequireAPIKey(GetStoreReport)).Methods("GET")
+	r.HandleFunc("/api/reports/areas", RequireAPIKey(GetAreaReport)).Methods("GET")
+	r.HandleFunc("/api/reports/coverage", RequireAPIKey(GetCoverageReport)).Methods("GET")
+	r.HandleFunc("/api/jobs/{id}/results", RequireAPIKey(ExportJobResults)).Methods("GET")
+	r.HandleFunc("/api/exports/results", RequireAPIKey(ExportResults)).Methods("GET")
+	r.HandleFunc("/metrics", GetMetrics).Methods("GET")
+	r.HandleFunc("/healthz", GetHealth).Methods("GET")
+	r.HandleFunc("/readyz", GetReadiness).Methods("GET")
+	r.HandleFunc("/version", GetVersion).Methods("GET")
+	r.Use(RequestLogger, TraceRequests, InstrumentRoutes)
+	return r
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewRouter(t *testing.T) {
+	router := NewRouter()
+	APIKeys = map[string]string{"k-acme": "acme"}
+	defer func() { APIKeys = nil }()
+
+	serve := func(method, path string) int {
+		req, _ := http.NewRequest(method, path, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp.Code
+	}
+
+	// Normal case: Tenant endpoints require an API key
+	for _, route := range [][2]string{
+		{"POST", "/api/submit/"},
+		{"GET", "/api/status"},
+		{"GET", "/api/images/abc"},
+		{"GET", "/api/reports/stores"},
+		{"GET", "/api/reports/areas"},
+		{"GET", "/api/reports/coverage"},
+		{"GET", "/api/jobs/abc/results"},
+		{"GET", "/api/exports/results"},
+	} {
+		if code := serve(route[0], route[1]); code != http.StatusUnauthorized {
+			t.Errorf("Expected status code 401 for %s %s without a key, got %d", route[0], route[1], code)
+		}
+	}
+
+	// Normal case: Admin endpoints are disabled without an admin token
+	for _, route := range [][2]string{
+		{"POST", "/api/admin/stores/reload"},
+		{"GET", "/api/admin/stores/version"},
+		{"GET", "/api/admin/stores/versions"},
+		{"GET", "/api/admin/stores/diff"},
+		{"POST", "/api/stores"},
+		{"PUT", "/api/stores/S1"},
+		{"POST", "/api/stores/S1/deactivate"},
+	} {
+		if code := serve(route[0], route[1]); code != http.StatusForbidden {
+			t.Errorf("Expected status code 403 for %s %s, got %d", route[0], route[1], code)
+		}
+	}
+
+	// Edge case: Unknown methods are not routed
+	if code := serve("DELETE", "/api/stores/S1"); code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status code 405, got %d", code)
+	}
+}
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"backend-intern-assignment/models"
+
+	"github.com/gorilla/mux"
+)
+
+// ReloadStores re-reads the store master file and swaps in the new registry
+func ReloadStores(w http.ResponseWriter, r *http.Request) {
+	force := r.URL.Query().Get("force") == "true"
+	changed, report, err := models.ReloadStoreMaster(force)
+
+	response := map[string]interface{}{
+		"reloaded":     changed,
+		"store_master": models.StoreMasterStatus(),
+	}
+	if report != nil {
+		response["report"] = report
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		response["error"] = err.Error()
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetStoreMasterStatus returns the version and checksum of the active store master
+func GetStoreMasterStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.StoreMasterStatus())
+}
+
+// ListStoreMasterVersions returns the retained store master versions, oldest first
+func ListStoreMasterVersions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"active":   models.StoreMasterStatus().VersionID,
+		"versions": models.StoreMasterVersions(),
+	})
+}
+
+// DiffStoreMasterVersions compares two store master versions. The "to"
+// version defaults to the active store master.
+func DiffStoreMasterVersions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	from, exists := models.StoreMasterVersion(query.Get("from"))
+	if !exists {
+		http.Error(w, `{"error": "Unknown from version"}`, http.StatusNotFound)
+		return
+	}
+	to := models.CurrentStoreMaster()
+	if versionID := query.Get("to"); versionID != "" {
+		if to, exists = models.StoreMasterVersion(versionID); !exists {
+			http.Error(w, `{"error": "Unknown to version"}`, http.StatusNotFound)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.DiffStoreMasters(from, to))
+}
+
+// Pagination defaults for store listings
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// ListStores returns a page of stores, optionally filtered by area code and name
+func ListStores(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	page, err := queryInt(query.Get("page"), 1)
+	if err != nil || page < 1 {
+		http.Error(w, `{"error": "Invalid page"}`, http.StatusBadRequest)
+		return
+	}
+	pageSize, err := queryInt(query.Get("page_size"), defaultPageSize)
+	if err != nil || pageSize < 1 || pageSize > maxPageSize {
+		http.Error(w, `{"error": "Invalid page_size"}`, http.StatusBadRequest)
+		return
+	}
+
+	stores, total := models.ListStores(models.StoreFilter{
+		AreaCode: query.Get("area_code"),
+		Query:    query.Get("q"),
+		Offset:   (page - 1) * pageSize,
+		Limit:    pageSize,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"stores":    stores,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// GetStore returns a single store by ID
+func GetStore(w http.ResponseWriter, r *http.Request) {
+	store, exists := models.LookupStore(mux.Vars(r)["id"])
+	if !exists {
+		http.Error(w, `{"error": "Store not found"}`, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(store)
+}
+
+// CreateStore adds a store to the store master
+func CreateStore(w http.ResponseWriter, r *http.Request) {
+	var store models.Store
+	if err := json.NewDecoder(r.Body).Decode(&store); err != nil {
+		http.Error(w, `{"error": "Invalid request"}`, http.StatusBadRequest)
+		return
+	}
+
+	created, err := models.CreateStore(store)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// UpdateStore replaces the details of a store
+func UpdateStore(w http.ResponseWriter, r *http.Request) {
+	var store models.Store
+	if err := json.NewDecoder(r.Body).Decode(&store); err != nil {
+		http.Error(w, `{"error": "Invalid request"}`, http.StatusBadRequest)
+		return
+	}
+
+	updated, err := models.UpdateStore(mux.Vars(r)["id"], store)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+// DeactivateStore marks a store inactive so new visits to it fail validation
+func DeactivateStore(w http.ResponseWriter, r *http.Request) {
+	store, err := models.DeactivateStore(mux.Vars(r)["id"])
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(store)
+}
+
+func writeStoreError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case errors.Is(err, models.ErrStoreNotFound):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, models.ErrStoreExists), errors.Is(err, models.ErrStoreMasterHasIssues):
+		w.WriteHeader(http.StatusConflict)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// queryInt parses an optional integer query parameter
+func queryInt(value string, fallback int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(value)
+}
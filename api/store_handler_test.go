@@ -0,0 +1,220 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"backend-intern-assignment/models"
+)
+
+func TestReloadStores(t *testing.T) {
+	router := NewRouter()
+
+	path := filepath.Join(t.TempDir(), "stores.csv")
+	os.WriteFile(path, []byte("AreaCode,StoreName,StoreID\n1,A,S1\n"), 0o644)
+	if _, err := models.LoadStoreMaster(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer models.InitTestStoreMaster()
+	AdminToken = "secret"
+	defer func() { AdminToken = "" }()
+
+	// Normal case: Changed file is reloaded
+	t.Run("Reload", func(t *testing.T) {
+		os.WriteFile(path, []byte("AreaCode,StoreName,StoreID\n1,A,S1\n2,B,S2\n"), 0o644)
+		req, _ := http.NewRequest("POST", "/api/admin/stores/reload", nil)
+		req.Header.Set("X-Admin-Token", "secret")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusOK {
+			t.Errorf("Expected status code 200, got %d", resp.Code)
+		}
+		var response struct {
+			Reloaded    bool                   `json:"reloaded"`
+			StoreMaster models.StoreMasterInfo `json:"store_master"`
+		}
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		if !response.Reloaded || response.StoreMaster.Stores != 2 {
+			t.Errorf("Expected reload to 2 stores, got %+v", response)
+		}
+	})
+
+	// Edge case: Invalid file is rejected and the old version kept
+	t.Run("InvalidFile", func(t *testing.T) {
+		before := models.StoreMasterStatus()
+		os.WriteFile(path, []byte(""), 0o644)
+		req, _ := http.NewRequest("POST", "/api/admin/stores/reload", nil)
+		req.Header.Set("X-Admin-Token", "secret")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusUnprocessableEntity {
+			t.Errorf("Expected status code 422 for invalid file, got %d", resp.Code)
+		}
+		if models.StoreMasterStatus() != before {
+			t.Errorf("Expected the previous store master to stay active")
+		}
+	})
+
+	// Normal case: Active version is exposed
+	t.Run("Version", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/admin/stores/version", nil)
+		req.Header.Set("X-Admin-Token", "secret")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		var info models.StoreMasterInfo
+		json.Unmarshal(resp.Body.Bytes(), &info)
+		if resp.Code != http.StatusOK || info.Checksum != models.StoreMasterStatus().Checksum {
+			t.Errorf("Expected active checksum, got %d %+v", resp.Code, info)
+		}
+	})
+}
+
+func TestStoreManagement(t *testing.T) {
+	router := NewRouter()
+
+	path := filepath.Join(t.TempDir(), "stores.csv")
+	os.WriteFile(path, []byte("AreaCode,StoreName,StoreID\n100,Alpha Mart,S1\n100,Beta Store,S2\n200,Gamma Mart,S3\n"), 0o644)
+	if _, err := models.LoadStoreMaster(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer models.InitTestStoreMaster()
+	AdminToken = "secret"
+	defer func() { AdminToken = "" }()
+
+	do := func(method, url string, body interface{}, admin bool) *httptest.ResponseRecorder {
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		req, _ := http.NewRequest(method, url, bytes.NewBuffer(payload))
+		if admin {
+			req.Header.Set("Authorization", "Bearer secret")
+		}
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	// Normal case: List with area filter, name search and pagination
+	t.Run("List", func(t *testing.T) {
+		var page struct {
+			Stores []models.Store `json:"stores"`
+			Total  int            `json:"total"`
+		}
+		resp := do("GET", "/api/stores?area_code=100&page_size=1&page=2", nil, false)
+		json.Unmarshal(resp.Body.Bytes(), &page)
+		if resp.Code != http.StatusOK || page.Total != 2 || len(page.Stores) != 1 || page.Stores[0].ID != "S2" {
+			t.Errorf("Expected page 2 of area 100 to hold S2, got %d %+v", resp.Code, page)
+		}
+
+		resp = do("GET", "/api/stores?q=mart", nil, false)
+		json.Unmarshal(resp.Body.Bytes(), &page)
+		if page.Total != 2 {
+			t.Errorf("Expected 2 stores matching 'mart', got %d", page.Total)
+		}
+
+		if resp := do("GET", "/api/stores?page=0", nil, false); resp.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code 400 for page 0, got %d", resp.Code)
+		}
+	})
+
+	// Normal case: Get a single store
+	t.Run("Get", func(t *testing.T) {
+		if resp := do("GET", "/api/stores/S3", nil, false); resp.Code != http.StatusOK {
+			t.Errorf("Expected status code 200, got %d", resp.Code)
+		}
+		if resp := do("GET", "/api/stores/NOPE", nil, false); resp.Code != http.StatusNotFound {
+			t.Errorf("Expected status code 404, got %d", resp.Code)
+		}
+	})
+
+	// Normal case: Create, update and deactivate are persisted to the CSV
+	t.Run("Mutations", func(t *testing.T) {
+		store := models.Store{ID: "S4", Name: "Delta", AreaCode: "300"}
+		if resp := do("POST", "/api/stores", store, true); resp.Code != http.StatusCreated {
+			t.Fatalf("Expected status code 201, got %d: %s", resp.Code, resp.Body.String())
+		}
+		if resp := do("POST", "/api/stores", store, true); resp.Code != http.StatusConflict {
+			t.Errorf("Expected status code 409 for duplicate store, got %d", resp.Code)
+		}
+
+		store.Name = "Delta Renamed"
+		if resp := do("PUT", "/api/stores/S4", store, true); resp.Code != http.StatusOK {
+			t.Errorf("Expected status code 200, got %d", resp.Code)
+		}
+		if resp := do("PUT", "/api/stores/NOPE", store, true); resp.Code != http.StatusNotFound {
+			t.Errorf("Expected status code 404 for unknown store, got %d", resp.Code)
+		}
+
+		if resp := do("POST", "/api/stores/S1/deactivate", nil, true); resp.Code != http.StatusOK {
+			t.Errorf("Expected status code 200, got %d", resp.Code)
+		}
+		if models.IsValidStore("S1") {
+			t.Errorf("Expected deactivated store to be invalid for visits")
+		}
+
+		// Reloading from disk gives the same stores back
+		if _, err := models.LoadStoreMaster(path); err != nil {
+			t.Fatalf("Expected persisted file to load, got %v", err)
+		}
+		if s, _ := models.LookupStore("S4"); s.Name != "Delta Renamed" {
+			t.Errorf("Expected persisted rename, got %+v", s)
+		}
+		if s, _ := models.LookupStore("S1"); s.Active() {
+			t.Errorf("Expected persisted deactivation, got %+v", s)
+		}
+	})
+
+	// Edge case: Mutations require the admin token
+	t.Run("Unauthorized", func(t *testing.T) {
+		if resp := do("POST", "/api/stores", models.Store{ID: "S9", Name: "X"}, false); resp.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status code 401 without token, got %d", resp.Code)
+		}
+	})
+}
+
+func TestDiffStoreMasterVersions(t *testing.T) {
+	router := NewRouter()
+
+	path := filepath.Join(t.TempDir(), "stores.csv")
+	os.WriteFile(path, []byte("AreaCode,StoreName,StoreID\n1,A,S1\n"), 0o644)
+	if _, err := models.LoadStoreMaster(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer models.InitTestStoreMaster()
+	AdminToken = "secret"
+	defer func() { AdminToken = "" }()
+	from := models.StoreMasterStatus().VersionID
+
+	os.WriteFile(path, []byte("AreaCode,StoreName,StoreID\n1,A,S1\n1,B,S2\n"), 0o644)
+	models.ReloadStoreMaster(false)
+
+	// Normal case: Diff against the active version
+	req, _ := http.NewRequest("GET", "/api/admin/stores/diff?from="+from, nil)
+	req.Header.Set("X-Admin-Token", "secret")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	var diff models.StoreMasterDiff
+	json.Unmarshal(resp.Body.Bytes(), &diff)
+	if resp.Code != http.StatusOK || len(diff.Added) != 1 || diff.Added[0].ID != "S2" {
+		t.Errorf("Expected S2 added, got %d %+v", resp.Code, diff)
+	}
+
+	// Edge case: Unknown version
+	req, _ = http.NewRequest("GET", "/api/admin/stores/diff?from=unknown", nil)
+	req.Header.Set("X-Admin-Token", "secret")
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != http.StatusNotFound {
+		t.Errorf("Expected status code 404 for unknown version, got %d", resp.Code)
+	}
+}
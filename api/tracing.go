@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http"
+
+	"backend-intern-assignment/logging"
+	"backend-intern-assignment/tracing"
+)
+
+// TraceRequests is router middleware that records a server span per request,
+// continuing the caller's trace when the request has a traceparent header
+func TraceRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeTemplate(r)
+		ctx := tracing.Extract(r.Context(), r.Header)
+		ctx, span := tracing.Start(ctx, r.Method+" "+route,
+			tracing.WithKind(tracing.SpanKindServer),
+			tracing.WithAttributes(
+				tracing.String("http.request.method", r.Method),
+				tracing.String("http.route", route),
+				tracing.String("url.path", r.URL.Path),
+				tracing.String("request.id", logging.RequestID(ctx))))
+		defer span.End()
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		span.SetAttributes(tracing.Int("http.response.status_code", recorder.status))
+		if recorder.status >= http.StatusInternalServerError {
+			span.SetStatus(tracing.StatusError, http.StatusText(recorder.status))
+		}
+	})
+}
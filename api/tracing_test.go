@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"backend-intern-assignment/models"
+	"backend-intern-assignment/tracing"
+)
+
+// recordingExporter keeps exported spans in memory
+type recordingExporter struct {
+	mu    sync.Mutex
+	spans []tracing.SpanData
+}
+
+func (e *recordingExporter) ExportSpans(ctx context.Context, spans []tracing.SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func TestTraceRequests(t *testing.T) {
+	router := NewRouter()
+	models.InitTestStoreMaster()
+	exporter := &recordingExporter{}
+	tracing.Setup(exporter, 1)
+	defer tracing.Setup(nil, 1)
+
+	// Normal case: The trace runs from the caller through the handler into the async worker
+	t.Run("SubmissionToWorker", func(t *testing.T) {
+		traceParent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+		payload := `{"count": 1, "visits": [{"store_id": "RX99999", "image_url": [], "visit_time": "2023-10-21T15:04:05Z"}]}`
+		req, _ := http.NewRequest("POST", "/api/submit/", strings.NewReader(payload))
+		req.Header.Set(tracing.TraceParentHeader, traceParent)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		if resp.Code != http.StatusCreated {
+			t.Fatalf("Expected status code 201, got %d", resp.Code)
+		}
+		var response map[string]interface{}
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		jobID, _ := response["job_id"].(string)
+
+		deadline := time.Now().Add(5 * time.Second)
+		for findSpan(exporter, "ProcessJob", jobAttribute(jobID)) == nil && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+			tracing.ForceFlush(context.Background())
+		}
+
+		submit := findSpan(exporter, "SubmitJob", jobAttribute(jobID))
+		process := findSpan(exporter, "ProcessJob", jobAttribute(jobID))
+		if submit == nil || process == nil {
+			t.Fatalf("Expected SubmitJob and ProcessJob spans for job %s", jobID)
+		}
+		server := findSpan(exporter, "POST /api/submit/", func(s tracing.SpanData) bool { return s.SpanContext.SpanID == submit.Parent })
+		visit := findSpan(exporter, "visit", func(s tracing.SpanData) bool { return s.Parent == process.SpanContext.SpanID })
+		if server == nil || visit == nil {
+			t.Fatalf("Expected the server span and a visit span")
+		}
+		if server.SpanContext.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || server.Parent.String() != "00f067aa0ba902b7" {
+			t.Errorf("Expected the server span to continue the caller's trace, got %+v", server)
+		}
+		if process.Parent != submit.SpanContext.SpanID {
+			t.Errorf("Expected ProcessJob to be a child of SubmitJob")
+		}
+		if process.SpanContext.TraceID != server.SpanContext.TraceID {
+			t.Errorf("Expected the worker spans in the request's trace")
+		}
+		if visit.StatusCode != tracing.StatusError || process.StatusCode != tracing.StatusError {
+			t.Errorf("Expected the invalid store to fail the visit and job spans")
+		}
+	})
+}
+
+// findSpan returns an exported span with the given name that matches, or nil
+func findSpan(exporter *recordingExporter, name string, match func(tracing.SpanData) bool) *tracing.SpanData {
+	exporter.mu.Lock()
+	defer exporter.mu.Unlock()
+
+	for _, span := range exporter.spans {
+		if span.Name == name && match(span) {
+			return &span
+		}
+	}
+	return nil
+}
+
+// jobAttribute matches spans whose job.id attribute is jobID
+func jobAttribute(jobID string) func(tracing.SpanData) bool {
+	return func(span tracing.SpanData) bool {
+		for _, attr := range span.Attributes {
+			if attr.Key == "job.id" && attr.Value == jobID {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,551 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds the settings of the server, the worker and the store master
+type Config struct {
+	Server  ServerConfig
+	Limits  LimitsConfig
+	Stores  StoreConfig
+	Worker  WorkerConfig
+	Egress  EgressConfig
+	Storage StorageConfig
+	Log     LogConfig
+	Tracing TracingConfig
+
+	// PrintConfig is set by the --print-config flag; it is not read from files or the environment
+	PrintConfig bool
+}
+
+// ServerConfig configures the HTTP server
+type ServerConfig struct {
+	ListenAddr      string
+	AdminToken      string
+	APIKeys         string
+	LegacyJobIDs    bool
+	ShutdownTimeout time.Duration
+}
+
+// LimitsConfig configures rate limits and per-tenant quotas. Zero disables a limit.
+type LimitsConfig struct {
+	SubmitRate     float64
+	SubmitBurst    int
+	ImagesPerDay   int
+	ConcurrentJobs int
+}
+
+// StoreConfig configures the store master
+type StoreConfig struct {
+	Path          string
+	Strictness    string
+	WatchInterval time.Duration
+	Columns       StoreColumnsConfig
+}
+
+// StoreColumnsConfig names the store master header columns holding each store
+// field. Header names are matched case-insensitively.
+type StoreColumnsConfig struct {
+	ID         string
+	Name       string
+	AreaCode   string
+	Status     string
+	ActiveFrom string
+	ActiveTo   string
+}
+
+// WorkerConfig configures job processing
+type WorkerConfig struct {
+	PoolSize        int
+	QueueSize       int
+	DownloadTimeout time.Duration
+	ThumbnailSize   int
+	GPU             GPUConfig
+}
+
+// GPUConfig configures the simulated GPU stage
+type GPUConfig struct {
+	Mode   string
+	Fixed  time.Duration
+	Min    time.Duration
+	Max    time.Duration
+	Median time.Duration
+	Sigma  float64
+	Seed   int64
+}
+
+// EgressConfig configures which image URLs the worker may download. The host
+// lists and schemes are comma-separated.
+type EgressConfig struct {
+	Schemes         string
+	AllowHosts      string
+	DenyHosts       string
+	AllowPrivateIPs bool
+}
+
+// StorageConfig configures where processed images are kept
+type StorageConfig struct {
+	Backend string
+	Dir     string
+}
+
+// LogConfig configures structured logging
+type LogConfig struct {
+	Level  string
+	Format string
+}
+
+// TracingConfig configures trace export
+type TracingConfig struct {
+	Exporter    string
+	Endpoint    string
+	ServiceName string
+	SampleRatio float64
+	// PropagateToImageHosts sends the traceparent header with image downloads
+	PropagateToImageHosts bool
+}
+
+// Storage backends
+const (
+	StorageNone  = "none"
+	StorageLocal = "local"
+)
+
+// Default returns the built-in configuration
+func Default() Config {
+	return Config{
+		Server: ServerConfig{ListenAddr: ":8080", ShutdownTimeout: 30 * time.Second},
+		Limits: LimitsConfig{SubmitBurst: 10},
+		Stores: StoreConfig{
+			Path:          "StoreMaster.csv",
+			Strictness:    "standard",
+			WatchInterval: 30 * time.Second,
+			Columns: StoreColumnsConfig{
+				ID:         "StoreID",
+				Name:       "StoreName",
+				AreaCode:   "AreaCode",
+				Status:     "Status",
+				ActiveFrom: "ActiveFrom",
+				ActiveTo:   "ActiveTo",
+			},
+		},
+		Worker: WorkerConfig{
+			PoolSize:        4,
+			QueueSize:       1000,
+			DownloadTimeout: 30 * time.Second,
+			ThumbnailSize:   256,
+			GPU: GPUConfig{
+				Mode:   "uniform",
+				Fixed:  250 * time.Millisecond,
+				Min:    100 * time.Millisecond,
+				Max:    400 * time.Millisecond,
+				Median: 200 * time.Millisecond,
+				Sigma:  0.5,
+			},
+		},
+		Egress: EgressConfig{Schemes: "http,https"},
+		Log:    LogConfig{Level: "info", Format: "text"},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			Endpoint:    "http://localhost:4318",
+			ServiceName: "backend-intern-assignment",
+			SampleRatio: 1,
+		},
+	}
+}
+
+// option maps one setting to its file key, flag name and environment variable
+type option struct {
+	key   string
+	env   string
+	usage string
+	set   func(c *Config, value string) error
+	get   func(c *Config) string
+}
+
+func stringOption(key, env, usage string, field func(*Config) *string) option {
+	return option{key, env, usage,
+		func(c *Config, value string) error {
+			*field(c) = value
+			return nil
+		},
+		func(c *Config) string { return *field(c) }}
+}
+
+func intOption(key, env, usage string, field func(*Config) *int) option {
+	return option{key, env, usage,
+		func(c *Config, value string) (err error) {
+			*field(c), err = strconv.Atoi(value)
+			return err
+		},
+		func(c *Config) string { return strconv.Itoa(*field(c)) }}
+}
+
+func int64Option(key, env, usage string, field func(*Config) *int64) option {
+	return option{key, env, usage,
+		func(c *Config, value string) (err error) {
+			*field(c), err = strconv.ParseInt(value, 10, 64)
+			return err
+		},
+		func(c *Config) string { return strconv.FormatInt(*field(c), 10) }}
+}
+
+func floatOption(key, env, usage string, field func(*Config) *float64) option {
+	return option{key, env, usage,
+		func(c *Config, value string) (err error) {
+			*field(c), err = strconv.ParseFloat(value, 64)
+			return err
+		},
+		func(c *Config) string { return strconv.FormatFloat(*field(c), 'g', -1, 64) }}
+}
+
+func durationOption(key, env, usage string, field func(*Config) *time.Duration) option {
+	return option{key, env, usage,
+		func(c *Config, value string) (err error) {
+			*field(c), err = time.ParseDuration(value)
+			return err
+		},
+		func(c *Config) string { return field(c).String() }}
+}
+
+func boolOption(key, env, usage string, field func(*Config) *bool) option {
+	return option{key, env, usage,
+		func(c *Config, value string) (err error) {
+			*field(c), err = strconv.ParseBool(value)
+			return err
+		},
+		func(c *Config) string { return strconv.FormatBool(*field(c)) }}
+}
+
+// options lists every setting in the order they are printed
+var options = []option{
+	stringOption("server.listen_addr", "LISTEN_ADDR", "address the HTTP server listens on",
+		func(c *Config) *string { return &c.Server.ListenAddr }),
+	stringOption("server.admin_token", "ADMIN_TOKEN", "token required by the admin endpoints; admin endpoints are disabled when empty",
+		func(c *Config) *string { return &c.Server.AdminToken }),
+	stringOption("server.api_keys", "API_KEYS", "comma-separated tenant:key pairs required by the job endpoints; job endpoints are open when empty",
+		func(c *Config) *string { return &c.Server.APIKeys }),
+	boolOption("server.legacy_job_ids", "LEGACY_JOB_IDS", "accept numeric job IDs on /api/status while clients move to UUIDs",
+		func(c *Config) *bool { return &c.Server.LegacyJobIDs }),
+	durationOption("server.shutdown_timeout", "SHUTDOWN_TIMEOUT", "how long in-flight jobs may run after a shutdown signal before they are interrupted",
+		func(c *Config) *time.Duration { return &c.Server.ShutdownTimeout }),
+	floatOption("limits.submit_rate", "SUBMIT_RATE_LIMIT", "job submissions per second allowed per tenant, or per client IP without API keys; 0 disables the limit",
+		func(c *Config) *float64 { return &c.Limits.SubmitRate }),
+	intOption("limits.submit_burst", "SUBMIT_BURST", "job submissions allowed at once before limits.submit_rate applies",
+		func(c *Config) *int { return &c.Limits.SubmitBurst }),
+	intOption("limits.images_per_day", "IMAGES_PER_DAY_QUOTA", "images each tenant may submit per UTC day; 0 is unlimited",
+		func(c *Config) *int { return &c.Limits.ImagesPerDay }),
+	intOption("limits.concurrent_jobs", "CONCURRENT_JOBS_PER_TENANT", "jobs of one tenant queued or processed at the same time; 0 is unlimited",
+		func(c *Config) *int { return &c.Limits.ConcurrentJobs }),
+	stringOption("stores.path", "STORE_MASTER_PATH", "path of the store master CSV",
+		func(c *Config) *string { return &c.Stores.Path }),
+	stringOption("stores.strictness", "STORE_MASTER_STRICTNESS", "store master validation: lenient, standard or strict",
+		func(c *Config) *string { return &c.Stores.Strictness }),
+	durationOption("stores.watch_interval", "STORE_MASTER_WATCH_INTERVAL", "how often the store master file is checked for changes; 0 disables watching",
+		func(c *Config) *time.Duration { return &c.Stores.WatchInterval }),
+	stringOption("stores.columns.id", "STORE_COLUMN_ID", "store master column holding the store ID",
+		func(c *Config) *string { return &c.Stores.Columns.ID }),
+	stringOption("stores.columns.name", "STORE_COLUMN_NAME", "store master column holding the store name",
+		func(c *Config) *string { return &c.Stores.Columns.Name }),
+	stringOption("stores.columns.area_code", "STORE_COLUMN_AREA_CODE", "store master column holding the area code",
+		func(c *Config) *string { return &c.Stores.Columns.AreaCode }),
+	stringOption("stores.columns.status", "STORE_COLUMN_STATUS", "optional store master column holding the store status",
+		func(c *Config) *string { return &c.Stores.Columns.Status }),
+	stringOption("stores.columns.active_from", "STORE_COLUMN_ACTIVE_FROM", "optional store master column holding the first day a store accepts visits",
+		func(c *Config) *string { return &c.Stores.Columns.ActiveFrom }),
+	stringOption("stores.columns.active_to", "STORE_COLUMN_ACTIVE_TO", "optional store master column holding the day a store stops accepting visits",
+		func(c *Config) *string { return &c.Stores.Columns.ActiveTo }),
+	intOption("worker.pool_size", "WORKER_POOL_SIZE", "number of jobs processed concurrently",
+		func(c *Config) *int { return &c.Worker.PoolSize }),
+	intOption("worker.queue_size", "WORKER_QUEUE_SIZE", "number of submitted jobs waiting for a worker before submissions block",
+		func(c *Config) *int { return &c.Worker.QueueSize }),
+	durationOption("worker.download_timeout", "DOWNLOAD_TIMEOUT", "timeout for downloading one image",
+		func(c *Config) *time.Duration { return &c.Worker.DownloadTimeout }),
+	intOption("worker.thumbnail_size", "THUMBNAIL_SIZE", "longest side of stored thumbnails in pixels",
+		func(c *Config) *int { return &c.Worker.ThumbnailSize }),
+	stringOption("worker.gpu.mode", "GPU_SIMULATION", "GPU simulation: none, fixed, uniform or realistic",
+		func(c *Config) *string { return &c.Worker.GPU.Mode }),
+	durationOption("worker.gpu.fixed", "GPU_FIXED_DELAY", "delay of the fixed GPU simulation",
+		func(c *Config) *time.Duration { return &c.Worker.GPU.Fixed }),
+	durationOption("worker.gpu.min", "GPU_MIN_DELAY", "minimum delay of the uniform GPU simulation",
+		func(c *Config) *time.Duration { return &c.Worker.GPU.Min }),
+	durationOption("worker.gpu.max", "GPU_MAX_DELAY", "maximum delay of the uniform and realistic GPU simulations",
+		func(c *Config) *time.Duration { return &c.Worker.GPU.Max }),
+	durationOption("worker.gpu.median", "GPU_MEDIAN_DELAY", "median delay of the realistic GPU simulation",
+		func(c *Config) *time.Duration { return &c.Worker.GPU.Median }),
+	floatOption("worker.gpu.sigma", "GPU_SIGMA", "log-normal sigma of the realistic GPU simulation",
+		func(c *Config) *float64 { return &c.Worker.GPU.Sigma }),
+	int64Option("worker.gpu.seed", "GPU_SEED", "seed of the random GPU simulations; 0 uses the current time",
+		func(c *Config) *int64 { return &c.Worker.GPU.Seed }),
+	stringOption("egress.schemes", "EGRESS_SCHEMES", "comma-separated URL schemes image downloads may use: http and/or https",
+		func(c *Config) *string { return &c.Egress.Schemes }),
+	stringOption("egress.allow_hosts", "EGRESS_ALLOW_HOSTS", "comma-separated hosts, with their subdomains, images may be downloaded from; any host when empty",
+		func(c *Config) *string { return &c.Egress.AllowHosts }),
+	stringOption("egress.deny_hosts", "EGRESS_DENY_HOSTS", "comma-separated hosts, with their subdomains, images are never downloaded from",
+		func(c *Config) *string { return &c.Egress.DenyHosts }),
+	boolOption("egress.allow_private_ips", "EGRESS_ALLOW_PRIVATE_IPS", "allow image downloads from private, loopback and link-local addresses",
+		func(c *Config) *bool { return &c.Egress.AllowPrivateIPs }),
+	stringOption("storage.backend", "STORAGE_BACKEND", "where processed images are kept: none or local; defaults to local when a directory is set",
+		func(c *Config) *string { return &c.Storage.Backend }),
+	stringOption("storage.dir", "IMAGE_STORE_DIR", "directory of the local image store",
+		func(c *Config) *string { return &c.Storage.Dir }),
+	stringOption("log.level", "LOG_LEVEL", "minimum log level: debug, info, warn or error",
+		func(c *Config) *string { return &c.Log.Level }),
+	stringOption("log.format", "LOG_FORMAT", "log output: text or json",
+		func(c *Config) *string { return &c.Log.Format }),
+	stringOption("tracing.exporter", "TRACING_EXPORTER", "where spans are sent: none, stdout or otlp",
+		func(c *Config) *string { return &c.Tracing.Exporter }),
+	stringOption("tracing.endpoint", "OTEL_EXPORTER_OTLP_ENDPOINT", "base URL of the OTLP/HTTP collector; /v1/traces is appended",
+		func(c *Config) *string { return &c.Tracing.Endpoint }),
+	stringOption("tracing.service_name", "OTEL_SERVICE_NAME", "service name reported with spans",
+		func(c *Config) *string { return &c.Tracing.ServiceName }),
+	floatOption("tracing.sample_ratio", "TRACING_SAMPLE_RATIO", "fraction of new traces that are recorded, from 0 to 1",
+		func(c *Config) *float64 { return &c.Tracing.SampleRatio }),
+	boolOption("tracing.propagate_to_image_hosts", "TRACING_PROPAGATE_TO_IMAGE_HOSTS", "send the traceparent header with image downloads; only enable when image hosts are internal",
+		func(c *Config) *bool { return &c.Tracing.PropagateToImageHosts }),
+}
+
+func findOption(key string) (option, bool) {
+	for _, opt := range options {
+		if opt.key == key {
+			return opt, true
+		}
+	}
+	return option{}, false
+}
+
+// Load builds the configuration from, in increasing order of precedence, the
+// defaults, the file named by --config or CONFIG_FILE, environment variables
+// and command-line flags. The result is validated.
+func Load(args []string, getenv func(string) string) (Config, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	configFile := fs.String("config", getenv("CONFIG_FILE"), "path of a YAML or TOML configuration file")
+	fs.BoolVar(&cfg.PrintConfig, "print-config", false, "print the effective configuration and exit")
+	for _, opt := range options {
+		fs.String(opt.key, "", fmt.Sprintf("%s (env %s)", opt.usage, opt.env))
+	}
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+
+	if *configFile != "" {
+		values, err := ReadFile(*configFile)
+		if err != nil {
+			return cfg, err
+		}
+		for key, value := range values {
+			opt, exists := findOption(key)
+			if !exists {
+				return cfg, fmt.Errorf("%s: unknown setting %q", *configFile, key)
+			}
+			if err := opt.set(&cfg, value); err != nil {
+				return cfg, fmt.Errorf("%s: invalid %s: %w", *configFile, key, err)
+			}
+		}
+	}
+
+	for _, opt := range options {
+		if value := getenv(opt.env); value != "" {
+			if err := opt.set(&cfg, value); err != nil {
+				return cfg, fmt.Errorf("invalid %s: %w", opt.env, err)
+			}
+		}
+	}
+
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		if opt, exists := findOption(f.Name); exists && flagErr == nil {
+			if err := opt.set(&cfg, f.Value.String()); err != nil {
+				flagErr = fmt.Errorf("invalid -%s: %w", f.Name, err)
+			}
+		}
+	})
+	if flagErr != nil {
+		return cfg, flagErr
+	}
+
+	if cfg.Storage.Backend == "" {
+		cfg.Storage.Backend = StorageNone
+		if cfg.Storage.Dir != "" {
+			cfg.Storage.Backend = StorageLocal
+		}
+	}
+	return cfg, cfg.Validate()
+}
+
+// Validate checks that every setting is usable and reports all problems at once
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.ListenAddr != "", "server.listen_addr must be set")
+	check(c.Server.ShutdownTimeout >= 0, "server.shutdown_timeout must not be negative")
+	if _, err := ParseAPIKeys(c.Server.APIKeys); err != nil {
+		check(false, "server.api_keys: %v", err)
+	}
+	check(c.Limits.SubmitRate >= 0, "limits.submit_rate must not be negative")
+	check(c.Limits.SubmitRate == 0 || c.Limits.SubmitBurst >= 1, "limits.submit_burst must be at least 1 when limits.submit_rate is set")
+	check(c.Limits.ImagesPerDay >= 0, "limits.images_per_day must not be negative")
+	check(c.Limits.ConcurrentJobs >= 0, "limits.concurrent_jobs must not be negative")
+	check(c.Stores.Path != "", "stores.path must be set")
+	check(c.Stores.Strictness == "lenient" || c.Stores.Strictness == "standard" || c.Stores.Strictness == "strict",
+		"stores.strictness must be lenient, standard or strict, got %q", c.Stores.Strictness)
+	check(c.Stores.WatchInterval >= 0, "stores.watch_interval must not be negative")
+	columns := c.Stores.Columns
+	seen := make(map[string]string)
+	for _, column := range []struct{ key, name string }{
+		{"id", columns.ID}, {"name", columns.Name}, {"area_code", columns.AreaCode},
+		{"status", columns.Status}, {"active_from", columns.ActiveFrom}, {"active_to", columns.ActiveTo},
+	} {
+		name := strings.ToLower(strings.TrimSpace(column.name))
+		check(name != "", "stores.columns.%s must be set", column.key)
+		if other, exists := seen[name]; exists && name != "" {
+			check(false, "stores.columns.%s and stores.columns.%s name the same column %q", other, column.key, column.name)
+		}
+		seen[name] = column.key
+	}
+	check(c.Worker.PoolSize >= 1, "worker.pool_size must be at least 1")
+	check(c.Worker.QueueSize >= 0, "worker.queue_size must not be negative")
+	check(c.Worker.DownloadTimeout > 0, "worker.download_timeout must be positive")
+	check(c.Worker.ThumbnailSize >= 1, "worker.thumbnail_size must be at least 1")
+
+	gpu := c.Worker.GPU
+	switch gpu.Mode {
+	case "none":
+	case "fixed":
+		check(gpu.Fixed >= 0, "worker.gpu.fixed must not be negative")
+	case "uniform":
+		check(gpu.Min >= 0 && gpu.Max >= gpu.Min, "worker.gpu.min and worker.gpu.max must satisfy 0 <= min <= max")
+	case "realistic":
+		check(gpu.Median > 0, "worker.gpu.median must be positive")
+		check(gpu.Sigma >= 0, "worker.gpu.sigma must not be negative")
+		check(gpu.Max >= 0, "worker.gpu.max must not be negative")
+	default:
+		check(false, "worker.gpu.mode must be none, fixed, uniform or realistic, got %q", gpu.Mode)
+	}
+
+	schemes := ParseList(c.Egress.Schemes)
+	check(len(schemes) > 0, "egress.schemes must list at least one scheme")
+	for _, scheme := range schemes {
+		check(scheme == "http" || scheme == "https", "egress.schemes may only contain http and https, got %q", scheme)
+	}
+
+	switch c.Storage.Backend {
+	case StorageNone:
+	case StorageLocal:
+		check(c.Storage.Dir != "", "storage.dir must be set for the local storage backend")
+	default:
+		check(false, "storage.backend must be none or local, got %q", c.Storage.Backend)
+	}
+
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		check(false, "log.level must be debug, info, warn or error, got %q", c.Log.Level)
+	}
+	check(c.Log.Format == "text" || c.Log.Format == "json", "log.format must be text or json, got %q", c.Log.Format)
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout":
+	case "otlp":
+		check(c.Tracing.Endpoint != "", "tracing.endpoint must be set for the otlp exporter")
+	default:
+		check(false, "tracing.exporter must be none, stdout or otlp, got %q", c.Tracing.Exporter)
+	}
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio must be between 0 and 1")
+
+	return errors.Join(errs...)
+}
+
+// ParseAPIKeys reads comma-separated tenant:key pairs into a map from key to
+// tenant. A tenant may have several keys, but a key belongs to one tenant.
+func ParseAPIKeys(value string) (map[string]string, error) {
+	keys := make(map[string]string)
+	if strings.TrimSpace(value) == "" {
+		return keys, nil
+	}
+	for _, pair := range strings.Split(value, ",") {
+		tenant, key, found := strings.Cut(strings.TrimSpace(pair), ":")
+		if !found || tenant == "" || key == "" {
+			return nil, fmt.Errorf("expected tenant:key, got %q", pair)
+		}
+		if other, exists := keys[key]; exists && other != tenant {
+			return nil, fmt.Errorf("key of tenant %q is also assigned to tenant %q", tenant, other)
+		}
+		keys[key] = tenant
+	}
+	return keys, nil
+}
+
+// ParseList splits a comma-separated setting, dropping blank entries
+func ParseList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// Print writes the configuration as YAML. The admin token and API keys are redacted.
+func (c Config) Print(w io.Writer) {
+	var section []string
+	for _, opt := range options {
+		parts := strings.Split(opt.key, ".")
+		common := 0
+		for common < len(section) && common < len(parts)-1 && section[common] == parts[common] {
+			common++
+		}
+		for i := common; i < len(parts)-1; i++ {
+			fmt.Fprintf(w, "%s%s:\n", strings.Repeat("  ", i), parts[i])
+		}
+		section = parts[:len(parts)-1]
+
+		value := opt.get(&c)
+		if (opt.key == "server.admin_token" || opt.key == "server.api_keys") && value != "" {
+			value = "<redacted>"
+		}
+		fmt.Fprintf(w, "%s%s: %s\n", strings.Repeat("  ", len(parts)-1), parts[len(parts)-1], yamlValue(value))
+	}
+}
+
+// yamlValue quotes values that would not read back as the same plain scalar
+func yamlValue(value string) string {
+	if value == "" || strings.TrimSpace(value) != value || strings.ContainsAny(value, ":#'\"") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+// MustLoad loads the configuration from the process arguments and environment.
+// It exits after printing the configuration when --print-config is given.
+func MustLoad() Config {
+	cfg, err := Load(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+		os.Exit(2)
+	}
+	if cfg.PrintConfig {
+		cfg.Print(os.Stdout)
+		os.Exit(0)
+	}
+	return cfg
+}
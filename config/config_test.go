@@ -0,0 +1,288 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func env(values map[string]string) func(string) string {
+	return func(key string) string { return values[key] }
+}
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	// Normal case: Defaults apply without a file, environment or flags
+	t.Run("Defaults", func(t *testing.T) {
+		cfg, err := Load(nil, env(nil))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.Server.ListenAddr != ":8080" || cfg.Stores.Path != "StoreMaster.csv" || cfg.Storage.Backend != StorageNone {
+			t.Errorf("Expected defaults, got %+v", cfg)
+		}
+	})
+
+	// Normal case: Flags override the environment, which overrides the file
+	t.Run("Precedence", func(t *testing.T) {
+		path := writeConfigFile(t, "config.yaml", `
+server:
+  listen_addr: ":9000"
+worker:
+  pool_size: 8
+  download_timeout: 5s
+  gpu:
+    mode: fixed
+`)
+		cfg, err := Load(
+			[]string{"--config", path, "--worker.pool_size=16"},
+			env(map[string]string{"WORKER_POOL_SIZE": "12", "DOWNLOAD_TIMEOUT": "10s"}),
+		)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.Server.ListenAddr != ":9000" {
+			t.Errorf("Expected listen address from the file, got %q", cfg.Server.ListenAddr)
+		}
+		if cfg.Worker.DownloadTimeout != 10*time.Second {
+			t.Errorf("Expected download timeout from the environment, got %v", cfg.Worker.DownloadTimeout)
+		}
+		if cfg.Worker.PoolSize != 16 {
+			t.Errorf("Expected pool size from the flag, got %d", cfg.Worker.PoolSize)
+		}
+		if cfg.Worker.GPU.Mode != "fixed" {
+			t.Errorf("Expected GPU mode from the file, got %q", cfg.Worker.GPU.Mode)
+		}
+	})
+
+	// Normal case: CONFIG_FILE names the file and TOML is supported
+	t.Run("TOMLFromEnvironment", func(t *testing.T) {
+		path := writeConfigFile(t, "config.toml", "[stores]\nstrictness = \"strict\"\n\n[worker.gpu]\nmode = \"none\"\n")
+		cfg, err := Load(nil, env(map[string]string{"CONFIG_FILE": path}))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.Stores.Strictness != "strict" || cfg.Worker.GPU.Mode != "none" {
+			t.Errorf("Expected settings from the TOML file, got %+v", cfg)
+		}
+	})
+
+	// Normal case: Store master columns are read from nested file sections and the environment
+	t.Run("StoreColumns", func(t *testing.T) {
+		path := writeConfigFile(t, "config.yaml", `
+stores:
+  columns:
+    id: Outlet Code
+    name: Outlet
+`)
+		cfg, err := Load([]string{"--config", path}, env(map[string]string{"STORE_COLUMN_AREA_CODE": "PIN"}))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		columns := cfg.Stores.Columns
+		if columns.ID != "Outlet Code" || columns.Name != "Outlet" || columns.AreaCode != "PIN" || columns.Status != "Status" {
+			t.Errorf("Expected configured columns with default optional columns, got %+v", columns)
+		}
+	})
+
+	// Normal case: Setting an image directory enables the local storage backend
+	t.Run("LocalStorage", func(t *testing.T) {
+		cfg, err := Load(nil, env(map[string]string{"IMAGE_STORE_DIR": "/tmp/images"}))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.Storage.Backend != StorageLocal || cfg.Storage.Dir != "/tmp/images" {
+			t.Errorf("Expected local storage in /tmp/images, got %+v", cfg.Storage)
+		}
+	})
+
+	// Normal case: Legacy job IDs are rejected unless turned on
+	t.Run("LegacyJobIDs", func(t *testing.T) {
+		cfg, _ := Load(nil, env(nil))
+		if cfg.Server.LegacyJobIDs {
+			t.Error("Expected legacy job IDs to be off by default")
+		}
+		cfg, err := Load(nil, env(map[string]string{"LEGACY_JOB_IDS": "true"}))
+		if err != nil || !cfg.Server.LegacyJobIDs {
+			t.Errorf("Expected legacy job IDs to be turned on, got %v, %v", cfg.Server.LegacyJobIDs, err)
+		}
+		if _, err := Load([]string{"--server.legacy_job_ids=maybe"}, env(nil)); err == nil {
+			t.Error("Expected error for invalid boolean, got none")
+		}
+	})
+
+	// Edge case: Unknown keys and unparseable values are rejected
+	t.Run("InvalidSources", func(t *testing.T) {
+		path := writeConfigFile(t, "config.yaml", "worker:\n  pool_sise: 8\n")
+		if _, err := Load([]string{"--config", path}, env(nil)); err == nil || !strings.Contains(err.Error(), "pool_sise") {
+			t.Errorf("Expected unknown setting error, got %v", err)
+		}
+		if _, err := Load(nil, env(map[string]string{"DOWNLOAD_TIMEOUT": "soon"})); err == nil {
+			t.Error("Expected error for invalid duration, got none")
+		}
+		if _, err := Load([]string{"--worker.pool_size=many"}, env(nil)); err == nil {
+			t.Error("Expected error for invalid flag value, got none")
+		}
+		if _, err := Load([]string{"--config", filepath.Join(t.TempDir(), "config.ini")}, env(nil)); err == nil {
+			t.Error("Expected error for missing file, got none")
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	// Normal case: The defaults are valid
+	t.Run("Defaults", func(t *testing.T) {
+		cfg := Default()
+		cfg.Storage.Backend = StorageNone
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("Expected defaults to be valid, got %v", err)
+		}
+	})
+
+	// Edge case: Every problem is reported
+	t.Run("AllProblems", func(t *testing.T) {
+		cfg := Default()
+		cfg.Worker.PoolSize = 0
+		cfg.Stores.Strictness = "picky"
+		cfg.Worker.GPU.Mode = "quantum"
+		cfg.Storage.Backend = StorageLocal
+		cfg.Log.Format = "xml"
+		cfg.Tracing.Exporter = "zipkin"
+		cfg.Server.APIKeys = "acme"
+		cfg.Limits.ImagesPerDay = -1
+		cfg.Egress.Schemes = "https,ftp"
+		err := cfg.Validate()
+		if err == nil {
+			t.Fatal("Expected validation errors, got none")
+		}
+		for _, want := range []string{"worker.pool_size", "stores.strictness", "worker.gpu.mode", "storage.dir", "log.format", "tracing.exporter", "server.api_keys", "limits.images_per_day", "egress.schemes"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("Expected error about %s, got %v", want, err)
+			}
+		}
+	})
+
+	// Edge case: A submission rate needs a burst of at least one
+	t.Run("SubmitBurst", func(t *testing.T) {
+		cfg := Default()
+		cfg.Storage.Backend = StorageNone
+		cfg.Limits.SubmitRate, cfg.Limits.SubmitBurst = 5, 0
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "limits.submit_burst") {
+			t.Errorf("Expected error about limits.submit_burst, got %v", err)
+		}
+	})
+
+	// Edge case: Uniform GPU bounds must be ordered
+	t.Run("GPUBounds", func(t *testing.T) {
+		cfg := Default()
+		cfg.Storage.Backend = StorageNone
+		cfg.Worker.GPU.Min = time.Second
+		if err := cfg.Validate(); err == nil {
+			t.Error("Expected error for min above max, got none")
+		}
+	})
+
+	// Edge case: Store master columns must be set and distinct, ignoring case
+	t.Run("StoreColumns", func(t *testing.T) {
+		cfg := Default()
+		cfg.Storage.Backend = StorageNone
+		cfg.Stores.Columns.Name = ""
+		cfg.Stores.Columns.Status = "storeid"
+		err := cfg.Validate()
+		for _, want := range []string{"stores.columns.name must be set", "stores.columns.id and stores.columns.status"} {
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("Expected error %q, got %v", want, err)
+			}
+		}
+	})
+}
+
+func TestPrint(t *testing.T) {
+	// Normal case: The printed configuration reads back as the same settings
+	t.Run("RoundTrip", func(t *testing.T) {
+		cfg, _ := Load([]string{"--stores.path=stores: main.csv", "--worker.gpu.sigma=0.25"}, env(nil))
+		var out bytes.Buffer
+		cfg.Print(&out)
+
+		path := writeConfigFile(t, "printed.yaml", out.String())
+		reloaded, err := Load([]string{"--config", path}, env(nil))
+		if err != nil {
+			t.Fatalf("Expected printed configuration to load, got %v\n%s", err, out.String())
+		}
+		if reloaded.Stores.Path != "stores: main.csv" || reloaded.Worker.GPU.Sigma != 0.25 {
+			t.Errorf("Expected printed settings to round trip, got %+v", reloaded)
+		}
+	})
+
+	// Edge case: The admin token is redacted
+	t.Run("RedactsAdminToken", func(t *testing.T) {
+		cfg, _ := Load(nil, env(map[string]string{"ADMIN_TOKEN": "secret"}))
+		var out bytes.Buffer
+		cfg.Print(&out)
+		if strings.Contains(out.String(), "secret") || !strings.Contains(out.String(), "<redacted>") {
+			t.Errorf("Expected admin token to be redacted, got %s", out.String())
+		}
+	})
+
+	// Edge case: API keys are redacted
+	t.Run("RedactsAPIKeys", func(t *testing.T) {
+		cfg, _ := Load(nil, env(map[string]string{"API_KEYS": "acme:secret"}))
+		var out bytes.Buffer
+		cfg.Print(&out)
+		if strings.Contains(out.String(), "secret") || !strings.Contains(out.String(), "<redacted>") {
+			t.Errorf("Expected API keys to be redacted, got %s", out.String())
+		}
+	})
+}
+
+func TestParseAPIKeys(t *testing.T) {
+	// Normal case: Pairs map keys to tenants, and a tenant may have several keys
+	t.Run("Pairs", func(t *testing.T) {
+		keys, err := ParseAPIKeys("acme:k1, acme:k2,globex:k3")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(keys) != 3 || keys["k1"] != "acme" || keys["k2"] != "acme" || keys["k3"] != "globex" {
+			t.Errorf("Unexpected keys: %v", keys)
+		}
+	})
+
+	// Edge case: An empty value configures no keys
+	t.Run("Empty", func(t *testing.T) {
+		keys, err := ParseAPIKeys("")
+		if err != nil || len(keys) != 0 {
+			t.Errorf("Expected no keys and no error, got %v, %v", keys, err)
+		}
+	})
+
+	// Edge case: Malformed pairs and keys shared by tenants are rejected
+	t.Run("Invalid", func(t *testing.T) {
+		for _, value := range []string{"acme", "acme:", ":k1", "acme:k1,,globex:k2", "acme:k1,globex:k1"} {
+			if _, err := ParseAPIKeys(value); err == nil {
+				t.Errorf("Expected an error for %q", value)
+			}
+		}
+	})
+}
+
+func TestParseList(t *testing.T) {
+	// Normal case: Entries are trimmed
+	if list := ParseList(" a.example.com,b.example.com "); len(list) != 2 || list[0] != "a.example.com" || list[1] != "b.example.com" {
+		t.Errorf("Expected two trimmed entries, got %q", list)
+	}
+
+	// Edge case: Blank entries are dropped
+	if list := ParseList(" , ,"); len(list) != 0 {
+		t.Errorf("Expected no entries, got %q", list)
+	}
+}
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ReadFile reads a configuration file into dotted keys, e.g. "worker.pool_size".
+// Files ending in .toml are read as TOML; .yaml and .yml files as YAML.
+func ReadFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var values map[string]string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		values, err = ParseTOML(f)
+	case ".yaml", ".yml":
+		values, err = ParseYAML(f)
+	default:
+		return nil, fmt.Errorf("%s: unsupported configuration format, expected .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return values, nil
+}
+
+// ParseYAML reads the subset of YAML used by configuration files: nested
+// mappings of scalars, indented with spaces, with # comments. Lists, anchors
+// and multi-line strings are not supported.
+func ParseYAML(r io.Reader) (map[string]string, error) {
+	type section struct {
+		indent int
+		key    string
+	}
+	values := make(map[string]string)
+	var sections []section
+	// pendingIndent is the indent of the last key without a value, whose
+	// children must be indented further
+	pendingIndent := -1
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := stripComment(scanner.Text())
+		if strings.TrimSpace(text) == "" || text == "---" {
+			continue
+		}
+		if strings.HasPrefix(strings.TrimLeft(text, " "), "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", line)
+		}
+		indent := len(text) - len(strings.TrimLeft(text, " "))
+		text = strings.TrimSpace(text)
+		if strings.HasPrefix(text, "- ") || text == "-" {
+			return nil, fmt.Errorf("line %d: lists are not supported", line)
+		}
+
+		if pendingIndent >= 0 && indent <= pendingIndent {
+			return nil, fmt.Errorf("line %d: section %q has no settings", line, sections[len(sections)-1].key)
+		}
+		pendingIndent = -1
+		for len(sections) > 0 && sections[len(sections)-1].indent >= indent {
+			sections = sections[:len(sections)-1]
+		}
+
+		key, value, found := strings.Cut(text, ":")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", line)
+		}
+		path := key
+		if len(sections) > 0 {
+			path = sections[len(sections)-1].key + "." + key
+		}
+
+		value = strings.TrimSpace(value)
+		if value == "" {
+			sections = append(sections, section{indent: indent, key: path})
+			pendingIndent = indent
+			continue
+		}
+		scalar, err := unquote(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		values[path] = scalar
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// ParseTOML reads the subset of TOML used by configuration files: [table]
+// headers, including dotted ones, and "key = value" pairs of scalars.
+// Arrays and inline tables are not supported.
+func ParseTOML(r io.Reader) (map[string]string, error) {
+	values := make(map[string]string)
+	table := ""
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(stripComment(scanner.Text()))
+		if text == "" {
+			continue
+		}
+		if strings.HasPrefix(text, "[") {
+			if !strings.HasSuffix(text, "]") || strings.HasPrefix(text, "[[") {
+				return nil, fmt.Errorf("line %d: invalid table header", line)
+			}
+			table = strings.TrimSpace(text[1 : len(text)-1])
+			continue
+		}
+
+		key, value, found := strings.Cut(text, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !found || key == "" || value == "" {
+			return nil, fmt.Errorf("line %d: expected \"key = value\"", line)
+		}
+		if strings.HasPrefix(value, "[") || strings.HasPrefix(value, "{") {
+			return nil, fmt.Errorf("line %d: arrays and inline tables are not supported", line)
+		}
+		if table != "" {
+			key = table + "." + key
+		}
+		scalar, err := unquote(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		values[key] = scalar
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// stripComment removes a # comment that is not inside a quoted string
+func stripComment(text string) string {
+	var quote rune
+	for i, c := range text {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return strings.TrimRight(text[:i], " \t")
+		}
+	}
+	return text
+}
+
+// unquote returns the value of a plain, single-quoted or double-quoted scalar
+func unquote(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid quoted string %s", value)
+		}
+		return unquoted, nil
+	case strings.HasPrefix(value, "'"):
+		if len(value) < 2 || !strings.HasSuffix(value, "'") {
+			return "", fmt.Errorf("invalid quoted string %s", value)
+		}
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'"), nil
+	default:
+		return value, nil
+	}
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	// Normal case: Nested sections become dotted keys
+	t.Run("Nested", func(t *testing.T) {
+		values, err := ParseYAML(strings.NewReader(`# server settings
+server:
+  listen_addr: ":9000"   # quoted because of the colon
+worker:
+  gpu:
+    mode: 'realistic'
+    sigma: 0.7
+  pool_size: 2
+stores:
+  path: "stores #1.csv"
+`))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		expected := map[string]string{
+			"server.listen_addr": ":9000",
+			"worker.gpu.mode":    "realistic",
+			"worker.gpu.sigma":   "0.7",
+			"worker.pool_size":   "2",
+			"stores.path":        "stores #1.csv",
+		}
+		for key, want := range expected {
+			if values[key] != want {
+				t.Errorf("Expected %s = %q, got %q", key, want, values[key])
+			}
+		}
+		if len(values) != len(expected) {
+			t.Errorf("Expected %d values, got %v", len(expected), values)
+		}
+	})
+
+	// Edge case: Unsupported or malformed YAML is rejected with its line
+	t.Run("Invalid", func(t *testing.T) {
+		cases := map[string]string{
+			"list":          "stores:\n  - a.csv\n",
+			"tab":           "server:\n\tlisten_addr: x\n",
+			"no colon":      "server\n",
+			"empty section": "server:\nworker:\n  pool_size: 1\n",
+			"bad quote":     "server:\n  listen_addr: \"x\n",
+		}
+		for name, input := range cases {
+			if _, err := ParseYAML(strings.NewReader(input)); err == nil || !strings.Contains(err.Error(), "line") {
+				t.Errorf("Expected line error for %s, got %v", name, err)
+			}
+		}
+	})
+}
+
+func TestParseTOML(t *testing.T) {
+	// Normal case: Tables prefix their keys
+	t.Run("Tables", func(t *testing.T) {
+		values, err := ParseTOML(strings.NewReader(`listen = "top"
+[server]
+listen_addr = ":9000" # comment
+[worker.gpu]
+seed = 42
+`))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if values["listen"] != "top" || values["server.listen_addr"] != ":9000" || values["worker.gpu.seed"] != "42" {
+			t.Errorf("Unexpected values %v", values)
+		}
+	})
+
+	// Edge case: Arrays and array tables are rejected
+	t.Run("Invalid", func(t *testing.T) {
+		for _, input := range []string{"paths = [\"a\"]\n", "[[stores]]\n", "[server\n", "key\n"} {
+			if _, err := ParseTOML(strings.NewReader(input)); err == nil {
+				t.Errorf("Expected error for %q, got none", input)
+			}
+		}
+	})
+}
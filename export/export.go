@@ -0,0 +1,106 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"backend-intern-assignment/models"
+)
+
+// Export formats
+const (
+	FormatCSV     = "csv"
+	FormatNDJSON  = "ndjson"
+	FormatParquet = "parquet"
+)
+
+// Columns are the exported fields, in order
+var Columns = []string{"job_id", "store_id", "store_name", "area_code", "visit_time", "image_url", "perimeter", "status", "error"}
+
+// Writer encodes result rows as they arrive. Close writes any buffered rows
+// and trailer but does not close the underlying writer.
+type Writer interface {
+	Write(row models.ResultRow) error
+	Close() error
+}
+
+// NewWriter returns a writer for the named format
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w)
+	case FormatNDJSON:
+		return &ndjsonWriter{encoder: json.NewEncoder(w)}, nil
+	case FormatParquet:
+		return newParquetWriter(w)
+	default:
+		return nil, fmt.Errorf("unknown export format: %s", format)
+	}
+}
+
+// ContentType returns the MIME type of a format, or "" if the format is unknown
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatParquet:
+		return "application/vnd.apache.parquet"
+	default:
+		return ""
+	}
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(Columns); err != nil {
+		return nil, err
+	}
+	return &csvWriter{writer: writer}, nil
+}
+
+func (c *csvWriter) Write(row models.ResultRow) error {
+	perimeter := ""
+	if row.Perimeter != nil {
+		perimeter = strconv.FormatFloat(*row.Perimeter, 'f', -1, 64)
+	}
+	return c.writer.Write([]string{
+		row.JobID, csvText(row.StoreID), csvText(row.StoreName), csvText(row.AreaCode), csvText(row.VisitTime),
+		csvText(row.ImageURL), perimeter, row.Status, csvText(row.Error),
+	})
+}
+
+// csvText neutralizes a text cell that a spreadsheet would run as a formula
+// by prefixing it with a single quote
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func (c *csvWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+type ndjsonWriter struct {
+	encoder *json.Encoder
+}
+
+func (n *ndjsonWriter) Write(row models.ResultRow) error {
+	return n.encoder.Encode(row)
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
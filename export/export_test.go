@@ -0,0 +1,112 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"backend-intern-assignment/models"
+)
+
+const testJobID = "018f3c2a-7b41-7c3e-9a52-4d6e8f0a1b2c"
+
+func testRows() []models.ResultRow {
+	perimeter := 600.0
+	return []models.ResultRow{
+		{JobID: testJobID, StoreID: "RP00001", StoreName: "B P STORE", AreaCode: "7100015", VisitTime: "2023-10-21T15:04:05Z",
+			ImageURL: "https://example.com/a.jpg", Perimeter: &perimeter, Status: "processed"},
+		{JobID: testJobID, StoreID: "RP00001", StoreName: "B P STORE", AreaCode: "7100015", VisitTime: "2023-10-21T15:04:05Z",
+			ImageURL: "https://example.com/b.jpg", Status: "failed", Error: "Failed to download image"},
+	}
+}
+
+func writeRows(t *testing.T, format string, rows []models.ResultRow) []byte {
+	var buf bytes.Buffer
+	writer, err := NewWriter(format, &buf)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, row := range rows {
+		if err := writer.Write(row); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestCSVWriter(t *testing.T) {
+	// Normal case: Header followed by one line per row, with an empty perimeter when missing
+	t.Run("Rows", func(t *testing.T) {
+		lines := strings.Split(strings.TrimSpace(string(writeRows(t, FormatCSV, testRows()))), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("Expected 3 lines, got %d", len(lines))
+		}
+		if lines[0] != strings.Join(Columns, ",") {
+			t.Errorf("Expected header %q, got %q", strings.Join(Columns, ","), lines[0])
+		}
+		if lines[1] != testJobID+",RP00001,B P STORE,7100015,2023-10-21T15:04:05Z,https://example.com/a.jpg,600,processed," {
+			t.Errorf("Unexpected first row %q", lines[1])
+		}
+		if lines[2] != testJobID+",RP00001,B P STORE,7100015,2023-10-21T15:04:05Z,https://example.com/b.jpg,,failed,Failed to download image" {
+			t.Errorf("Unexpected second row %q", lines[2])
+		}
+	})
+
+	// Edge case: Text cells that would run as spreadsheet formulas are quoted
+	t.Run("FormulaInjection", func(t *testing.T) {
+		rows := []models.ResultRow{{
+			JobID: testJobID, StoreID: "+RP1", StoreName: "=HYPERLINK(\"http://evil.io\")", AreaCode: "-1", VisitTime: "\t2023",
+			ImageURL: "@SUM(1)", Status: "failed", Error: "\rcmd",
+		}}
+		records, err := csv.NewReader(bytes.NewReader(writeRows(t, FormatCSV, rows))).ReadAll()
+		if err != nil || len(records) != 2 {
+			t.Fatalf("Expected a header and one row, got %v %v", records, err)
+		}
+		for i, want := range []string{testJobID, "'+RP1", "'=HYPERLINK(\"http://evil.io\")", "'-1", "'\t2023", "'@SUM(1)", "", "failed", "'\rcmd"} {
+			if records[1][i] != want {
+				t.Errorf("Expected %s %q, got %q", Columns[i], want, records[1][i])
+			}
+		}
+	})
+
+	// Edge case: No rows still writes the header
+	t.Run("Empty", func(t *testing.T) {
+		if out := strings.TrimSpace(string(writeRows(t, FormatCSV, nil))); out != strings.Join(Columns, ",") {
+			t.Errorf("Expected only the header, got %q", out)
+		}
+	})
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	// Normal case: One JSON object per line with a null perimeter when missing
+	t.Run("Rows", func(t *testing.T) {
+		lines := strings.Split(strings.TrimSpace(string(writeRows(t, FormatNDJSON, testRows()))), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("Expected 2 lines, got %d", len(lines))
+		}
+		var row map[string]interface{}
+		if err := json.Unmarshal([]byte(lines[1]), &row); err != nil {
+			t.Fatalf("Expected valid JSON, got %v", err)
+		}
+		if row["perimeter"] != nil || row["error"] != "Failed to download image" || row["job_id"] != testJobID {
+			t.Errorf("Unexpected row %v", row)
+		}
+	})
+}
+
+func TestNewWriter(t *testing.T) {
+	// Edge case: Unknown formats are rejected
+	t.Run("UnknownFormat", func(t *testing.T) {
+		if _, err := NewWriter("xlsx", &bytes.Buffer{}); err == nil {
+			t.Error("Expected error for unknown format, got none")
+		}
+		if ContentType("xlsx") != "" {
+			t.Errorf("Expected no content type for unknown format, got %q", ContentType("xlsx"))
+		}
+	})
+}
@@ -0,0 +1,255 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+
+	"backend-intern-assignment/models"
+)
+
+// RowGroupSize is the number of rows buffered before a Parquet row group is written
+var RowGroupSize = 10000
+
+const parquetMagic = "PAR1"
+
+// Parquet physical types, encodings and other enum values
+const (
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	repetitionRequired = 0
+	repetitionOptional = 1
+
+	convertedUTF8 = 0
+	pageTypeData  = 0
+	codecNone     = 0
+)
+
+type parquetColumn struct {
+	name         string
+	physicalType int32
+	optional     bool
+
+	// values holds the PLAIN encoded non-null values of the current row group
+	values bytes.Buffer
+	// defined records which rows of an optional column have a value
+	defined []bool
+}
+
+type columnChunkMeta struct {
+	offset    int64
+	size      int64
+	numValues int64
+}
+
+type rowGroupMeta struct {
+	columns []columnChunkMeta
+	rows    int64
+	size    int64
+}
+
+// parquetWriter writes an uncompressed Parquet file with PLAIN encoded values,
+// one data page per column chunk. Only the current row group is kept in memory.
+type parquetWriter struct {
+	w         io.Writer
+	offset    int64
+	columns   []*parquetColumn
+	rows      int
+	rowGroups []rowGroupMeta
+	totalRows int64
+}
+
+func newParquetWriter(w io.Writer) (*parquetWriter, error) {
+	p := &parquetWriter{w: w}
+	for _, name := range Columns {
+		column := &parquetColumn{name: name, physicalType: parquetByteArray}
+		if name == "perimeter" {
+			column.physicalType, column.optional = parquetDouble, true
+		}
+		p.columns = append(p.columns, column)
+	}
+	if err := p.write([]byte(parquetMagic)); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *parquetWriter) Write(row models.ResultRow) error {
+	values := []string{row.JobID, row.StoreID, row.StoreName, row.AreaCode, row.VisitTime, row.ImageURL, "", row.Status, row.Error}
+	for i, column := range p.columns {
+		switch column.physicalType {
+		case parquetDouble:
+			column.defined = append(column.defined, row.Perimeter != nil)
+			if row.Perimeter != nil {
+				column.values.Write(binary.LittleEndian.AppendUint64(nil, math.Float64bits(*row.Perimeter)))
+			}
+		default:
+			column.values.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(values[i]))))
+			column.values.WriteString(values[i])
+		}
+	}
+	p.rows++
+	if p.rows >= RowGroupSize {
+		return p.flushRowGroup()
+	}
+	return nil
+}
+
+func (p *parquetWriter) Close() error {
+	if p.rows > 0 {
+		if err := p.flushRowGroup(); err != nil {
+			return err
+		}
+	}
+	footer := p.footer()
+	if err := p.write(footer); err != nil {
+		return err
+	}
+	if err := p.write(binary.LittleEndian.AppendUint32(nil, uint32(len(footer)))); err != nil {
+		return err
+	}
+	return p.write([]byte(parquetMagic))
+}
+
+// flushRowGroup writes the buffered rows as a row group with one data page per column
+func (p *parquetWriter) flushRowGroup() error {
+	group := rowGroupMeta{rows: int64(p.rows)}
+	for _, column := range p.columns {
+		var page bytes.Buffer
+		if column.optional {
+			levels := encodeDefinitionLevels(column.defined)
+			page.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(levels))))
+			page.Write(levels)
+		}
+		page.Write(column.values.Bytes())
+
+		header := pageHeader(p.rows, page.Len())
+		chunk := columnChunkMeta{offset: p.offset, size: int64(len(header) + page.Len()), numValues: int64(p.rows)}
+		if err := p.write(header); err != nil {
+			return err
+		}
+		if err := p.write(page.Bytes()); err != nil {
+			return err
+		}
+		group.columns = append(group.columns, chunk)
+		group.size += chunk.size
+
+		column.values.Reset()
+		column.defined = column.defined[:0]
+	}
+	p.rowGroups = append(p.rowGroups, group)
+	p.totalRows += group.rows
+	p.rows = 0
+	return nil
+}
+
+func (p *parquetWriter) write(b []byte) error {
+	n, err := p.w.Write(b)
+	p.offset += int64(n)
+	return err
+}
+
+// encodeDefinitionLevels encodes 1-bit definition levels as runs of the RLE/bit-packing hybrid encoding
+func encodeDefinitionLevels(defined []bool) []byte {
+	var out []byte
+	for i := 0; i < len(defined); {
+		j := i
+		for j < len(defined) && defined[j] == defined[i] {
+			j++
+		}
+		out = binary.AppendUvarint(out, uint64(j-i)<<1)
+		if defined[i] {
+			out = append(out, 1)
+		} else {
+			out = append(out, 0)
+		}
+		i = j
+	}
+	return out
+}
+
+func pageHeader(numValues, size int) []byte {
+	var c compactWriter
+	c.beginStruct()
+	c.i32(1, pageTypeData)
+	c.i32(2, int32(size))
+	c.i32(3, int32(size))
+	c.structField(5)
+	c.i32(1, int32(numValues))
+	c.i32(2, encodingPlain)
+	c.i32(3, encodingRLE)
+	c.i32(4, encodingRLE)
+	c.endStruct()
+	c.endStruct()
+	return c.buf.Bytes()
+}
+
+// footer encodes the FileMetaData describing the schema and every row group
+func (p *parquetWriter) footer() []byte {
+	var c compactWriter
+	c.beginStruct()
+	c.i32(1, 1)
+
+	c.list(2, thriftStruct, len(p.columns)+1)
+	c.beginStruct()
+	c.string(4, "schema")
+	c.i32(5, int32(len(p.columns)))
+	c.endStruct()
+	for _, column := range p.columns {
+		c.beginStruct()
+		c.i32(1, column.physicalType)
+		if column.optional {
+			c.i32(3, repetitionOptional)
+		} else {
+			c.i32(3, repetitionRequired)
+		}
+		c.string(4, column.name)
+		if column.physicalType == parquetByteArray {
+			c.i32(6, convertedUTF8)
+			c.structField(10) // LogicalType union
+			c.structField(1)  // STRING
+			c.endStruct()
+			c.endStruct()
+		}
+		c.endStruct()
+	}
+
+	c.i64(3, p.totalRows)
+
+	c.list(4, thriftStruct, len(p.rowGroups))
+	for _, group := range p.rowGroups {
+		c.beginStruct()
+		c.list(1, thriftStruct, len(group.columns))
+		for i, chunk := range group.columns {
+			column := p.columns[i]
+			c.beginStruct()
+			c.i64(2, chunk.offset)
+			c.structField(3)
+			c.i32(1, column.physicalType)
+			c.list(2, thriftI32, 2)
+			c.varint(zigzag(encodingPlain))
+			c.varint(zigzag(encodingRLE))
+			c.list(3, thriftBinary, 1)
+			c.binary(column.name)
+			c.i32(4, codecNone)
+			c.i64(5, chunk.numValues)
+			c.i64(6, chunk.size)
+			c.i64(7, chunk.size)
+			c.i64(9, chunk.offset)
+			c.endStruct()
+			c.endStruct()
+		}
+		c.i64(2, group.size)
+		c.i64(3, group.rows)
+		c.endStruct()
+	}
+
+	c.string(6, "backend-intern-assignment")
+	c.endStruct()
+	return c.buf.Bytes()
+}
@@ -0,0 +1,344 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"backend-intern-assignment/models"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden Parquet file")
+
+// goldenRows are the rows of testdata/results.parquet, written in row groups of 2
+func goldenRows() []models.ResultRow {
+	perimeter := 1234.5
+	return append(testRows(), models.ResultRow{JobID: testJobID, StoreID: "RP00002", StoreName: "MONAJ STORE", AreaCode: "7100015",
+		VisitTime: "2023-10-22T09:00:00Z", ImageURL: "https://example.com/c.jpg", Perimeter: &perimeter, Status: "processed"})
+}
+
+func writeGolden(t *testing.T) []byte {
+	defer func(size int) { RowGroupSize = size }(RowGroupSize)
+	RowGroupSize = 2
+	return writeRows(t, FormatParquet, goldenRows())
+}
+
+func TestParquetWriter(t *testing.T) {
+	// Normal case: The file is framed by the magic bytes and ends with the footer length
+	t.Run("Framing", func(t *testing.T) {
+		out := writeRows(t, FormatParquet, testRows())
+		if !bytes.HasPrefix(out, []byte(parquetMagic)) || !bytes.HasSuffix(out, []byte(parquetMagic)) {
+			t.Fatalf("Expected file to start and end with %q", parquetMagic)
+		}
+		footerLength := int(binary.LittleEndian.Uint32(out[len(out)-8:]))
+		if footerLength <= 0 || footerLength > len(out)-12 {
+			t.Errorf("Expected footer length within the file, got %d", footerLength)
+		}
+		footer := out[len(out)-8-footerLength : len(out)-8]
+		for _, column := range Columns {
+			if !bytes.Contains(footer, []byte(column)) {
+				t.Errorf("Expected column %q in the footer schema", column)
+			}
+		}
+	})
+
+	// Normal case: The first column chunk holds the PLAIN encoded job IDs after its page header
+	t.Run("JobIDColumn", func(t *testing.T) {
+		out := writeRows(t, FormatParquet, testRows())
+		size := 2 * (4 + len(testJobID))
+		header := pageHeader(2, size)
+		chunk := out[len(parquetMagic)+len(header):]
+		if !bytes.HasPrefix(out[len(parquetMagic):], header) {
+			t.Fatalf("Expected a data page header for 2 values of %d bytes", size)
+		}
+		for i := 0; i < 2; i++ {
+			value := chunk[i*(4+len(testJobID)):]
+			length := int(binary.LittleEndian.Uint32(value))
+			if length != len(testJobID) || string(value[4:4+length]) != testJobID {
+				t.Errorf("Expected job ID %s, got %q", testJobID, value[4:4+length])
+			}
+		}
+	})
+
+	// Normal case: Rows are flushed as separate row groups once RowGroupSize is reached
+	t.Run("RowGroups", func(t *testing.T) {
+		defer func(size int) { RowGroupSize = size }(RowGroupSize)
+		RowGroupSize = 1
+
+		var buf bytes.Buffer
+		writer, _ := NewWriter(FormatParquet, &buf)
+		writer.Write(testRows()[0])
+		afterFirst := buf.Len()
+		if afterFirst <= len(parquetMagic) {
+			t.Errorf("Expected the first row group to be written before Close")
+		}
+		writer.Write(testRows()[1])
+		writer.Close()
+		if len(writer.(*parquetWriter).rowGroups) != 2 {
+			t.Errorf("Expected 2 row groups, got %d", len(writer.(*parquetWriter).rowGroups))
+		}
+	})
+
+	// Edge case: No rows produces a valid file with an empty footer row group list
+	t.Run("Empty", func(t *testing.T) {
+		out := writeRows(t, FormatParquet, nil)
+		if !bytes.HasPrefix(out, []byte(parquetMagic)) || !bytes.HasSuffix(out, []byte(parquetMagic)) {
+			t.Errorf("Expected an empty but framed Parquet file")
+		}
+	})
+}
+
+func TestEncodeDefinitionLevels(t *testing.T) {
+	// Normal case: Consecutive equal levels are written as RLE runs
+	t.Run("Runs", func(t *testing.T) {
+		got := encodeDefinitionLevels([]bool{true, true, true, false, true})
+		want := []byte{3 << 1, 1, 1 << 1, 0, 1 << 1, 1}
+		if !bytes.Equal(got, want) {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	})
+}
+
+// TestParquetGolden compares the writer with testdata/results.parquet, which
+// testdata/verify_parquet.py checks with pyarrow. Run with -update after an
+// intended format change, then rerun the script.
+func TestParquetGolden(t *testing.T) {
+	out := writeGolden(t)
+	path := filepath.Join("testdata", "results.parquet")
+	if *update {
+		if err := os.WriteFile(path, out, 0o644); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !bytes.Equal(out, golden) {
+		t.Errorf("Expected the output to match %s; run with -update and verify it with testdata/verify_parquet.py", path)
+	}
+}
+
+// TestParquetSpec decodes the golden file with a reader written from the
+// Parquet and Thrift compact protocol specifications, independently of the writer
+func TestParquetSpec(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "results.parquet"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(data[:4]) != "PAR1" || string(data[len(data)-4:]) != "PAR1" {
+		t.Fatalf("Expected PAR1 magic at both ends")
+	}
+	footerLength := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footerStart := len(data) - 8 - footerLength
+	r := &compactReader{data: data, pos: footerStart}
+	meta := r.readStruct()
+	if r.pos != len(data)-8 {
+		t.Fatalf("Expected the footer to end at the length field, ended at %d", r.pos)
+	}
+
+	// Normal case: FileMetaData describes the version, schema and rows
+	if meta[1] != int64(1) || meta[3] != int64(3) {
+		t.Errorf("Expected version 1 and 3 rows, got %v and %v", meta[1], meta[3])
+	}
+	schema := meta[2].([]any)
+	root := schema[0].(map[int16]any)
+	if root[5] != int64(len(Columns)) || len(schema) != len(Columns)+1 {
+		t.Fatalf("Expected a root with %d children, got %v", len(Columns), root)
+	}
+	for i, column := range Columns {
+		element := schema[i+1].(map[int16]any)
+		wantType, wantRepetition := int64(6), int64(0) // BYTE_ARRAY, REQUIRED
+		if column == "perimeter" {
+			wantType, wantRepetition = 5, 1 // DOUBLE, OPTIONAL
+		}
+		if string(element[4].([]byte)) != column || element[1] != wantType || element[3] != wantRepetition {
+			t.Errorf("Unexpected schema element for %s: %v", column, element)
+		}
+		if wantType == 6 && element[6] != int64(0) { // UTF8
+			t.Errorf("Expected %s to be annotated as UTF8, got %v", column, element[6])
+		}
+	}
+
+	// Normal case: Row groups cover the rows, and each column chunk holds one data page with its values
+	var values [][]any
+	for range Columns {
+		values = append(values, nil)
+	}
+	groups := meta[4].([]any)
+	if len(groups) != 2 {
+		t.Fatalf("Expected 2 row groups, got %d", len(groups))
+	}
+	var totalRows int64
+	for _, g := range groups {
+		group := g.(map[int16]any)
+		rows := group[3].(int64)
+		totalRows += rows
+		var groupSize int64
+		for i, c := range group[1].([]any) {
+			chunk := c.(map[int16]any)
+			columnMeta := chunk[3].(map[int16]any)
+			if path := columnMeta[3].([]any); len(path) != 1 || string(path[0].([]byte)) != Columns[i] {
+				t.Errorf("Expected path %s, got %v", Columns[i], path)
+			}
+			if columnMeta[4] != int64(0) || columnMeta[5] != rows {
+				t.Errorf("Expected an uncompressed chunk of %d values, got %v", rows, columnMeta)
+			}
+			offset := columnMeta[9].(int64)
+			page := &compactReader{data: data, pos: int(offset)}
+			header := page.readStruct()
+			dataHeader := header[5].(map[int16]any)
+			if header[1] != int64(0) || header[2] != header[3] || dataHeader[1] != rows || dataHeader[2] != int64(0) {
+				t.Errorf("Expected an uncompressed PLAIN data page of %d values, got %v", rows, header)
+			}
+			end := page.pos + int(header[3].(int64))
+			if int64(end)-offset != columnMeta[6] || columnMeta[6] != columnMeta[7] {
+				t.Errorf("Expected chunk size %v to span the page, got %d", columnMeta[6], int64(end)-offset)
+			}
+			groupSize += columnMeta[6].(int64)
+			values[i] = append(values[i], page.readPlainValues(int(rows), Columns[i] == "perimeter")...)
+			if page.pos != end {
+				t.Errorf("Expected the %s values to fill the page, %d bytes left", Columns[i], end-page.pos)
+			}
+		}
+		if group[2] != groupSize {
+			t.Errorf("Expected row group size %d, got %v", groupSize, group[2])
+		}
+	}
+	if totalRows != 3 {
+		t.Errorf("Expected 3 rows in the row groups, got %d", totalRows)
+	}
+
+	// Normal case: The values read back as the rows, with a null for a missing perimeter
+	for row, want := range goldenRows() {
+		var perimeter any
+		if want.Perimeter != nil {
+			perimeter = *want.Perimeter
+		}
+		wantValues := []any{want.JobID, want.StoreID, want.StoreName, want.AreaCode, want.VisitTime, want.ImageURL, perimeter, want.Status, want.Error}
+		for i := range Columns {
+			if !reflect.DeepEqual(values[i][row], wantValues[i]) {
+				t.Errorf("Row %d: expected %s %v, got %v", row, Columns[i], wantValues[i], values[i][row])
+			}
+		}
+	}
+}
+
+// compactReader decodes Thrift compact protocol structs into maps from field
+// ID to value: int64 for integers, []byte for binary, []any for lists
+type compactReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *compactReader) byte() byte {
+	b := r.data[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *compactReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *compactReader) zigzag() int64 {
+	v := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *compactReader) readStruct() map[int16]any {
+	fields := make(map[int16]any)
+	var last int16
+	for {
+		header := r.byte()
+		if header == 0 {
+			return fields
+		}
+		id := last + int16(header>>4)
+		if header>>4 == 0 {
+			id = int16(r.zigzag())
+		}
+		last = id
+		fields[id] = r.readValue(header & 0x0f)
+	}
+}
+
+func (r *compactReader) readValue(typ byte) any {
+	switch typ {
+	case 1, 2: // boolean true, false
+		return typ == 1
+	case 3:
+		return int64(int8(r.byte()))
+	case 4, 5, 6: // i16, i32, i64
+		return r.zigzag()
+	case 7:
+		v := math.Float64frombits(binary.LittleEndian.Uint64(r.data[r.pos:]))
+		r.pos += 8
+		return v
+	case 8:
+		n := int(r.uvarint())
+		r.pos += n
+		return r.data[r.pos-n : r.pos]
+	case 9:
+		header := r.byte()
+		size, elemType := int(header>>4), header&0x0f
+		if size == 15 {
+			size = int(r.uvarint())
+		}
+		list := make([]any, size)
+		for i := range list {
+			list[i] = r.readValue(elemType)
+		}
+		return list
+	case 12:
+		return r.readStruct()
+	}
+	panic(fmt.Sprintf("unsupported thrift type %d", typ))
+}
+
+// readPlainValues reads a data page body: PLAIN byte arrays, or for the
+// optional double column RLE definition levels followed by PLAIN doubles
+func (r *compactReader) readPlainValues(n int, optionalDouble bool) []any {
+	defined := make([]bool, n)
+	for i := range defined {
+		defined[i] = true
+	}
+	if optionalDouble {
+		length := int(binary.LittleEndian.Uint32(r.data[r.pos:]))
+		r.pos += 4
+		end, i := r.pos+length, 0
+		for r.pos < end {
+			header := r.uvarint()
+			if header&1 != 0 {
+				panic("bit-packed definition levels are not expected")
+			}
+			value := r.byte() == 1
+			for run := 0; run < int(header>>1); run++ {
+				defined[i] = value
+				i++
+			}
+		}
+	}
+	values := make([]any, n)
+	for i := range values {
+		switch {
+		case !defined[i]:
+		case optionalDouble:
+			values[i] = math.Float64frombits(binary.LittleEndian.Uint64(r.data[r.pos:]))
+			r.pos += 8
+		default:
+			length := int(binary.LittleEndian.Uint32(r.data[r.pos:]))
+			values[i] = string(r.data[r.pos+4 : r.pos+4+length])
+			r.pos += 4 + length
+		}
+	}
+	return values
+}
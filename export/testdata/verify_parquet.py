@@ -0,0 +1,35 @@
+"""Reads results.parquet with pyarrow and checks it holds the rows of goldenRows
+in export/parquet_test.go. Run after regenerating the file with
+
+    go test ./export -run TestParquetGolden -update
+    pip install pyarrow && python3 export/testdata/verify_parquet.py
+"""
+import os
+
+import pyarrow as pa
+import pyarrow.parquet as pq
+
+JOB_ID = "018f3c2a-7b41-7c3e-9a52-4d6e8f0a1b2c"
+COLUMNS = ["job_id", "store_id", "store_name", "area_code", "visit_time",
+           "image_url", "perimeter", "status", "error"]
+ROWS = [
+    [JOB_ID, "RP00001", "B P STORE", "7100015", "2023-10-21T15:04:05Z",
+     "https://example.com/a.jpg", 600.0, "processed", ""],
+    [JOB_ID, "RP00001", "B P STORE", "7100015", "2023-10-21T15:04:05Z",
+     "https://example.com/b.jpg", None, "failed", "Failed to download image"],
+    [JOB_ID, "RP00002", "MONAJ STORE", "7100015", "2023-10-22T09:00:00Z",
+     "https://example.com/c.jpg", 1234.5, "processed", ""],
+]
+
+path = os.path.join(os.path.dirname(os.path.abspath(__file__)), "results.parquet")
+parquet_file = pq.ParquetFile(path)
+assert parquet_file.metadata.num_rows == len(ROWS), parquet_file.metadata
+assert parquet_file.metadata.num_row_groups == 2, parquet_file.metadata
+
+table = parquet_file.read()
+expected_schema = pa.schema(
+    [pa.field(name, pa.float64(), nullable=True) if name == "perimeter"
+     else pa.field(name, pa.string(), nullable=False) for name in COLUMNS])
+assert table.schema.equals(expected_schema), table.schema
+assert table.to_pylist() == [dict(zip(COLUMNS, row)) for row in ROWS], table.to_pylist()
+print("ok: %s reads back with pyarrow %s" % (path, pa.__version__))
@@ -0,0 +1,86 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Thrift compact protocol type codes
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// compactWriter encodes the subset of the Thrift compact protocol needed for
+// Parquet metadata: integers, strings, lists and nested structs
+type compactWriter struct {
+	buf bytes.Buffer
+	// lastID holds the previous field ID of each open struct
+	lastID []int16
+}
+
+func (c *compactWriter) beginStruct() {
+	c.lastID = append(c.lastID, 0)
+}
+
+func (c *compactWriter) endStruct() {
+	c.buf.WriteByte(0)
+	c.lastID = c.lastID[:len(c.lastID)-1]
+}
+
+func (c *compactWriter) field(id int16, typ byte) {
+	last := &c.lastID[len(c.lastID)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		c.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		c.buf.WriteByte(typ)
+		c.varint(zigzag(int64(id)))
+	}
+	*last = id
+}
+
+func (c *compactWriter) i32(id int16, v int32) {
+	c.field(id, thriftI32)
+	c.varint(zigzag(int64(v)))
+}
+
+func (c *compactWriter) i64(id int16, v int64) {
+	c.field(id, thriftI64)
+	c.varint(zigzag(v))
+}
+
+func (c *compactWriter) string(id int16, s string) {
+	c.field(id, thriftBinary)
+	c.binary(s)
+}
+
+func (c *compactWriter) structField(id int16) {
+	c.field(id, thriftStruct)
+	c.beginStruct()
+}
+
+func (c *compactWriter) list(id int16, elemType byte, size int) {
+	c.field(id, thriftList)
+	if size < 15 {
+		c.buf.WriteByte(byte(size)<<4 | elemType)
+		return
+	}
+	c.buf.WriteByte(0xf0 | elemType)
+	c.varint(uint64(size))
+}
+
+func (c *compactWriter) binary(s string) {
+	c.varint(uint64(len(s)))
+	c.buf.WriteString(s)
+}
+
+func (c *compactWriter) varint(v uint64) {
+	c.buf.Write(binary.AppendUvarint(nil, v))
+}
+
+func zigzag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
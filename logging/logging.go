@@ -0,0 +1,89 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// Output formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// New returns a logger writing to w. level is debug, info, warn or error and
+// format is text or json.
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	var minLevel slog.Level
+	if err := minLevel.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", level)
+	}
+	opts := &slog.HandlerOptions{Level: minLevel}
+
+	switch format {
+	case FormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q", format)
+	}
+}
+
+// Setup makes a new logger the default for slog and the standard log package
+func Setup(w io.Writer, level, format string) error {
+	logger, err := New(w, level, format)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+type loggerKey struct{}
+
+type requestIDKey struct{}
+
+// WithLogger returns a context carrying logger
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or the default logger
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// WithRequestID returns a context carrying the request ID and a logger that
+// adds it to every record
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	ctx = context.WithValue(ctx, requestIDKey{}, requestID)
+	return WithLogger(ctx, FromContext(ctx).With("request_id", requestID))
+}
+
+// RequestID returns the request ID carried by ctx, or ""
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// NewRequestID returns a random 128-bit ID in hex
+func NewRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// Duration returns an attribute with d in milliseconds, named key_ms, which
+// reads the same in text and JSON output
+func Duration(key string, d time.Duration) slog.Attr {
+	return slog.Float64(key+"_ms", float64(d.Microseconds())/1000)
+}
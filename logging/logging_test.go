@@ -0,0 +1,91 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	// Normal case: JSON output filtered by level
+	t.Run("JSON", func(t *testing.T) {
+		var out bytes.Buffer
+		logger, err := New(&out, "warn", FormatJSON)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		logger.Info("hidden")
+		logger.Warn("shown", "job_id", 7)
+
+		var record map[string]interface{}
+		if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+			t.Fatalf("Expected one JSON record, got %q", out.String())
+		}
+		if record["msg"] != "shown" || record["job_id"] != float64(7) {
+			t.Errorf("Expected the warning with job_id 7, got %v", record)
+		}
+	})
+
+	// Normal case: Text output
+	t.Run("Text", func(t *testing.T) {
+		var out bytes.Buffer
+		logger, _ := New(&out, "debug", FormatText)
+		logger.Debug("details", "store_id", "RP00001")
+		if !strings.Contains(out.String(), "msg=details store_id=RP00001") {
+			t.Errorf("Expected a text record, got %q", out.String())
+		}
+	})
+
+	// Edge case: Unknown levels and formats are rejected
+	t.Run("Invalid", func(t *testing.T) {
+		if _, err := New(&bytes.Buffer{}, "loud", FormatText); err == nil {
+			t.Errorf("Expected an error for an unknown level")
+		}
+		if _, err := New(&bytes.Buffer{}, "info", "xml"); err == nil {
+			t.Errorf("Expected an error for an unknown format")
+		}
+	})
+}
+
+func TestContext(t *testing.T) {
+	// Normal case: The request ID is carried and added to log records
+	t.Run("RequestID", func(t *testing.T) {
+		var out bytes.Buffer
+		logger, _ := New(&out, "info", FormatText)
+		ctx := WithRequestID(WithLogger(context.Background(), logger), "abc123")
+
+		if RequestID(ctx) != "abc123" {
+			t.Errorf("Expected request ID abc123, got %q", RequestID(ctx))
+		}
+		FromContext(ctx).Info("hello")
+		if !strings.Contains(out.String(), "request_id=abc123") {
+			t.Errorf("Expected the record to carry the request ID, got %q", out.String())
+		}
+	})
+
+	// Edge case: A bare context has no request ID and uses the default logger
+	t.Run("Empty", func(t *testing.T) {
+		if RequestID(context.Background()) != "" || FromContext(context.Background()) == nil {
+			t.Errorf("Expected no request ID and the default logger")
+		}
+	})
+
+	// Normal case: Generated request IDs are unique
+	t.Run("NewRequestID", func(t *testing.T) {
+		first, second := NewRequestID(), NewRequestID()
+		if len(first) != 32 || first == second {
+			t.Errorf("Expected two distinct 32-character IDs, got %q and %q", first, second)
+		}
+	})
+}
+
+func TestDuration(t *testing.T) {
+	// Normal case: Durations are logged in milliseconds
+	attr := Duration("download", 1500*time.Microsecond)
+	if attr.Key != "download_ms" || attr.Value.Float64() != 1.5 {
+		t.Errorf("Expected download_ms=1.5, got %v", attr)
+	}
+}
@@ -1,27 +1,165 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"backend-intern-assignment/api"
+	"backend-intern-assignment/config"
 	"backend-intern-assignment/db"
+	"backend-intern-assignment/logging"
 	"backend-intern-assignment/models"
-
-	"github.com/gorilla/mux"
+	"backend-intern-assignment/ratelimit"
+	"backend-intern-assignment/storage"
+	"backend-intern-assignment/tracing"
+	"backend-intern-assignment/worker"
 )
 
 func main() {
+	cfg := config.MustLoad()
+	if err := logging.Setup(os.Stderr, cfg.Log.Level, cfg.Log.Format); err != nil {
+		fatal("Invalid logging configuration", err)
+	}
+	switch cfg.Tracing.Exporter {
+	case tracing.ExporterStdout:
+		tracing.Setup(tracing.NewStdoutExporter(os.Stdout), cfg.Tracing.SampleRatio)
+	case tracing.ExporterOTLP:
+		tracing.Setup(tracing.NewOTLPExporter(cfg.Tracing.Endpoint, cfg.Tracing.ServiceName), cfg.Tracing.SampleRatio)
+	default:
+		tracing.Setup(nil, cfg.Tracing.SampleRatio)
+	}
+
 	// Initialize the database and preload StoreMaster data
 	db.InitDB()
-	models.LoadStoreMaster("StoreMaster.csv")
+	storeOptions := models.StoreLoadOptions{
+		Columns: models.StoreColumns{
+			ID:         cfg.Stores.Columns.ID,
+			Name:       cfg.Stores.Columns.Name,
+			AreaCode:   cfg.Stores.Columns.AreaCode,
+			Status:     cfg.Stores.Columns.Status,
+			ActiveFrom: cfg.Stores.Columns.ActiveFrom,
+			ActiveTo:   cfg.Stores.Columns.ActiveTo,
+		},
+		Strictness: cfg.Stores.Strictness,
+	}
+	report, err := models.LoadStoreMasterWithOptions(cfg.Stores.Path, storeOptions)
+	if err != nil {
+		fatal("Failed to load store master", err)
+	}
+	slog.Info("Store master loaded", "path", cfg.Stores.Path, "report", report.String())
+
+	// Reload the store master when the file changes or on SIGHUP
+	watchCtx, stopWatching := context.WithCancel(context.Background())
+	defer stopWatching()
+	if cfg.Stores.WatchInterval > 0 {
+		go models.WatchStoreMaster(watchCtx, cfg.Stores.WatchInterval)
+	}
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	go func() {
+		for range hangup {
+			changed, report, err := models.ReloadStoreMaster(true)
+			if err != nil {
+				slog.Error("Store master reload on SIGHUP failed", "error", err)
+				continue
+			}
+			if changed {
+				slog.Info("Store master reloaded", "version", models.StoreMasterStatus().Version, "report", report.String())
+			}
+		}
+	}()
 
-	// Set up router and endpoints
-	r := mux.NewRouter()
-	r.HandleFunc("/api/submit/", api.SubmitJob).Methods("POST")
-	r.HandleFunc("/api/status", api.GetJobStatus).Methods("GET")
+	// Keep originals and thumbnails when the local image store is configured
+	if cfg.Storage.Backend == config.StorageLocal {
+		store, err := storage.NewLocalStore(cfg.Storage.Dir)
+		if err != nil {
+			fatal("Failed to open image store", err)
+		}
+		storage.Default = store
+	}
+
+	// Configure and start the workers
+	gpu := cfg.Worker.GPU
+	stage, err := worker.NewGPUStage(worker.GPUConfig{
+		Mode:   gpu.Mode,
+		Fixed:  gpu.Fixed,
+		Min:    gpu.Min,
+		Max:    gpu.Max,
+		Median: gpu.Median,
+		Sigma:  gpu.Sigma,
+		Seed:   gpu.Seed,
+	})
+	if err != nil {
+		fatal("Invalid GPU simulation", err)
+	}
+	worker.GPU = stage
+	worker.HTTPClient.Timeout = cfg.Worker.DownloadTimeout
+	worker.ThumbnailSize = cfg.Worker.ThumbnailSize
+	worker.MaxJobsPerTenant = cfg.Limits.ConcurrentJobs
+	worker.PropagateTraceContext = cfg.Tracing.PropagateToImageHosts
+	worker.Egress = worker.EgressPolicy{
+		Schemes:         config.ParseList(cfg.Egress.Schemes),
+		AllowHosts:      config.ParseList(cfg.Egress.AllowHosts),
+		DenyHosts:       config.ParseList(cfg.Egress.DenyHosts),
+		AllowPrivateIPs: cfg.Egress.AllowPrivateIPs,
+	}
+	worker.StartPool(cfg.Worker.PoolSize, cfg.Worker.QueueSize)
+
+	api.AdminToken = cfg.Server.AdminToken
+	api.LegacyJobIDs = cfg.Server.LegacyJobIDs
+	// The keys were checked when the configuration was validated
+	api.APIKeys, _ = config.ParseAPIKeys(cfg.Server.APIKeys)
+	api.SubmitLimiter = ratelimit.NewLimiter(cfg.Limits.SubmitRate, cfg.Limits.SubmitBurst)
+	api.ImageQuota = ratelimit.NewDailyQuota(cfg.Limits.ImagesPerDay)
 
 	// Start the server
-	log.Println("Server running on port 8080")
-	log.Fatal(http.ListenAndServe(":8080", r))
+	server := &http.Server{Addr: cfg.Server.ListenAddr, Handler: api.NewRouter()}
+	go func() {
+		slog.Info("Server listening", "addr", cfg.Server.ListenAddr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fatal("Server failed", err)
+		}
+	}()
+
+	// On SIGTERM or interrupt, stop taking jobs, let running jobs finish, then stop serving
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGTERM, os.Interrupt)
+	<-stop
+	shutdown(server, cfg.Server.ShutdownTimeout)
+}
+
+// shutdown drains the worker pool and then the HTTP server within timeout,
+// then flushes pending traces. Status requests keep being served while jobs drain.
+func shutdown(server *http.Server, timeout time.Duration) {
+	slog.Info("Shutting down, waiting for running jobs", "timeout", timeout.String())
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := worker.Shutdown(ctx); err != nil {
+		slog.Warn("Interrupted unfinished jobs", "error", err)
+	}
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Warn("Closing remaining connections", "error", err)
+		server.Close()
+	}
+
+	// Flush spans of the drained jobs, even if the shutdown deadline has passed
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), tracing.BatchInterval)
+	defer cancelFlush()
+	if err := tracing.Shutdown(flushCtx); err != nil {
+		slog.Warn("Flushing traces", "error", err)
+	}
+	slog.Info("Server stopped")
+}
+
+// fatal logs err and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
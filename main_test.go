@@ -15,10 +15,7 @@ import (
 func setupTestServer() *mux.Router {
 	db.InitDB()
 	models.LoadStoreMaster("StoreMaster.csv")
-	r := mux.NewRouter()
-	r.HandleFunc("/api/submit/", api.SubmitJob).Methods("POST")
-	r.HandleFunc("/api/status", api.GetJobStatus).Methods("GET")
-	return r
+	return api.NewRouter()
 }
 
 func TestServerStartup(t *testing.T) {
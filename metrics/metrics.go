@@ -0,0 +1,309 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Metric types, as written in the exposition format
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// DefaultBuckets suit request and processing latencies in seconds
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// collector is a named metric family that can write itself
+type collector interface {
+	name() string
+	write(w io.Writer)
+}
+
+// Registry holds metric families and writes them in the Prometheus text format
+type Registry struct {
+	mu         sync.Mutex
+	collectors map[string]collector
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]collector)}
+}
+
+// Default is the registry served on /metrics
+var Default = NewRegistry()
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.collectors[c.name()]; exists {
+		panic("metrics: duplicate metric " + c.name())
+	}
+	r.collectors[c.name()] = c
+}
+
+// WriteText writes every metric, ordered by name, in the text exposition format
+func (r *Registry) WriteText(w io.Writer) {
+	r.mu.Lock()
+	collectors := make([]collector, 0, len(r.collectors))
+	for _, c := range r.collectors {
+		collectors = append(collectors, c)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(collectors, func(i, j int) bool {
+		return collectors[i].name() < collectors[j].name()
+	})
+	for _, c := range collectors {
+		c.write(w)
+	}
+}
+
+// family holds the series of one metric, keyed by label values
+type family struct {
+	metricName string
+	help       string
+	kind       string
+	labelNames []string
+	// upperBounds are the bucket bounds of a histogram
+	upperBounds []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+	// buckets and sum are used by histograms; value holds the count
+	buckets []uint64
+	sum     float64
+}
+
+func newFamily(registry *Registry, name, help, kind string, labelNames []string) *family {
+	f := &family{metricName: name, help: help, kind: kind, labelNames: labelNames, series: make(map[string]*series)}
+	registry.register(f)
+	return f
+}
+
+func (f *family) name() string { return f.metricName }
+
+// get returns the series for the label values, creating it if needed. The caller holds f.mu.
+func (f *family) get(labelValues []string) *series {
+	if len(labelValues) != len(f.labelNames) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.metricName, len(f.labelNames), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, exists := f.series[key]
+	if !exists {
+		s = &series{labelValues: append([]string(nil), labelValues...), buckets: make([]uint64, len(f.upperBounds))}
+		f.series[key] = s
+	}
+	return s
+}
+
+func (f *family) write(w io.Writer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.metricName, escapeHelp(f.help), f.metricName, f.kind)
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		f.writeSeries(w, f.series[key])
+	}
+}
+
+func (f *family) writeSeries(w io.Writer, s *series) {
+	if f.kind != TypeHistogram {
+		fmt.Fprintf(w, "%s%s %s\n", f.metricName, labels(f.labelNames, s.labelValues, "", ""), formatFloat(s.value))
+		return
+	}
+	for i, bound := range f.upperBounds {
+		fmt.Fprintf(w, "%s_bucket%s %d\n", f.metricName, labels(f.labelNames, s.labelValues, "le", formatFloat(bound)), s.buckets[i])
+	}
+	fmt.Fprintf(w, "%s_bucket%s %s\n", f.metricName, labels(f.labelNames, s.labelValues, "le", "+Inf"), formatFloat(s.value))
+	fmt.Fprintf(w, "%s_sum%s %s\n", f.metricName, labels(f.labelNames, s.labelValues, "", ""), formatFloat(s.sum))
+	fmt.Fprintf(w, "%s_count%s %s\n", f.metricName, labels(f.labelNames, s.labelValues, "", ""), formatFloat(s.value))
+}
+
+// Counter is a monotonically increasing value, optionally split by labels
+type Counter struct{ *family }
+
+// NewCounter registers a counter in the default registry
+func NewCounter(name, help string, labelNames ...string) *Counter {
+	return &Counter{newFamily(Default, name, help, TypeCounter, labelNames)}
+}
+
+// Inc adds one to the series with the given label values
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds a non-negative delta to the series with the given label values
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic("metrics: counter " + c.metricName + " cannot decrease")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.get(labelValues).value += delta
+}
+
+// Value returns the current value of a series
+func (c *Counter) Value(labelValues ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.get(labelValues).value
+}
+
+// Gauge is a value that can go up and down, optionally split by labels
+type Gauge struct{ *family }
+
+// NewGauge registers a gauge in the default registry
+func NewGauge(name, help string, labelNames ...string) *Gauge {
+	return &Gauge{newFamily(Default, name, help, TypeGauge, labelNames)}
+}
+
+// Set replaces the value of a series
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.get(labelValues).value = value
+}
+
+// Add changes the value of a series by delta
+func (g *Gauge) Add(delta float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.get(labelValues).value += delta
+}
+
+// Inc adds one to a series
+func (g *Gauge) Inc(labelValues ...string) { g.Add(1, labelValues...) }
+
+// Dec subtracts one from a series
+func (g *Gauge) Dec(labelValues ...string) { g.Add(-1, labelValues...) }
+
+// Value returns the current value of a series
+func (g *Gauge) Value(labelValues ...string) float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.get(labelValues).value
+}
+
+// GaugeFunc is an unlabelled gauge whose value is read when metrics are written
+type GaugeFunc struct {
+	metricName string
+	help       string
+	fn         func() float64
+}
+
+// NewGaugeFunc registers a gauge func in the default registry
+func NewGaugeFunc(name, help string, fn func() float64) *GaugeFunc {
+	g := &GaugeFunc{metricName: name, help: help, fn: fn}
+	Default.register(g)
+	return g
+}
+
+func (g *GaugeFunc) name() string { return g.metricName }
+
+func (g *GaugeFunc) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %s\n",
+		g.metricName, escapeHelp(g.help), g.metricName, TypeGauge, g.metricName, formatFloat(g.fn()))
+}
+
+// Histogram counts observations in cumulative buckets, optionally split by labels
+type Histogram struct{ *family }
+
+// NewHistogram registers a histogram with the given bucket upper bounds in the default registry
+func NewHistogram(name, help string, buckets []float64, labelNames ...string) *Histogram {
+	upperBounds := append([]float64(nil), buckets...)
+	sort.Float64s(upperBounds)
+	f := newFamily(Default, name, help, TypeHistogram, labelNames)
+	f.upperBounds = upperBounds
+	return &Histogram{f}
+}
+
+// Observe records a value in the series with the given label values
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := h.get(labelValues)
+	for i, bound := range h.upperBounds {
+		if value <= bound {
+			s.buckets[i]++
+		}
+	}
+	s.value++
+	s.sum += value
+}
+
+// Count returns the number of observations in a series
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return uint64(h.get(labelValues).value)
+}
+
+// ExponentialBuckets returns count buckets starting at start, each factor times the previous
+func ExponentialBuckets(start, factor float64, count int) []float64 {
+	buckets := make([]float64, count)
+	for i := range buckets {
+		buckets[i] = start
+		start *= factor
+	}
+	return buckets
+}
+
+// labels formats label pairs, adding an extra pair when extraName is set
+func labels(names, values []string, extraName, extraValue string) string {
+	if len(names) == 0 && extraName == "" {
+		return ""
+	}
+	pairs := make([]string, 0, len(names)+1)
+	for i, name := range names {
+		pairs = append(pairs, name+`="`+escapeLabel(values[i])+`"`)
+	}
+	if extraName != "" {
+		pairs = append(pairs, extraName+`="`+escapeLabel(extraValue)+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
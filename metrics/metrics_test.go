@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func writeText() string {
+	var out strings.Builder
+	Default.WriteText(&out)
+	return out.String()
+}
+
+func TestCounter(t *testing.T) {
+	// Normal case: Labelled series are counted separately and written with HELP and TYPE lines
+	t.Run("LabelledSeries", func(t *testing.T) {
+		c := NewCounter("test_requests_total", "Requests served.", "code")
+		c.Inc("200")
+		c.Inc("200")
+		c.Add(3, "500")
+
+		if c.Value("200") != 2 || c.Value("500") != 3 {
+			t.Errorf("Expected 2 and 3, got %v and %v", c.Value("200"), c.Value("500"))
+		}
+		want := "# HELP test_requests_total Requests served.\n" +
+			"# TYPE test_requests_total counter\n" +
+			"test_requests_total{code=\"200\"} 2\n" +
+			"test_requests_total{code=\"500\"} 3\n"
+		if out := writeText(); !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q, got %q", want, out)
+		}
+	})
+
+	// Edge case: Label values are escaped
+	t.Run("EscapesLabels", func(t *testing.T) {
+		c := NewCounter("test_escaped_total", "Escaped labels.", "path")
+		c.Inc("a\"b\\c\nd")
+
+		want := `test_escaped_total{path="a\"b\\c\nd"} 1`
+		if out := writeText(); !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q, got %q", want, out)
+		}
+	})
+
+	// Edge case: Counters cannot decrease
+	t.Run("NegativeDelta", func(t *testing.T) {
+		c := NewCounter("test_negative_total", "Never decreases.")
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Expected a panic for a negative delta")
+			}
+		}()
+		c.Add(-1)
+	})
+
+	// Edge case: Registering the same name twice panics
+	t.Run("Duplicate", func(t *testing.T) {
+		NewCounter("test_duplicate_total", "First.")
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Expected a panic for a duplicate metric")
+			}
+		}()
+		NewCounter("test_duplicate_total", "Second.")
+	})
+}
+
+func TestGauge(t *testing.T) {
+	// Normal case: Gauges go up and down
+	t.Run("IncDec", func(t *testing.T) {
+		g := NewGauge("test_in_flight", "In flight.")
+		g.Inc()
+		g.Inc()
+		g.Dec()
+		if g.Value() != 1 {
+			t.Errorf("Expected 1, got %v", g.Value())
+		}
+		g.Set(7.5)
+		if out := writeText(); !strings.Contains(out, "test_in_flight 7.5\n") {
+			t.Errorf("Expected test_in_flight 7.5, got %q", out)
+		}
+	})
+
+	// Normal case: Gauge funcs are read when written
+	t.Run("GaugeFunc", func(t *testing.T) {
+		value := 1.0
+		NewGaugeFunc("test_gauge_func", "Read on write.", func() float64 { return value })
+		value = 4
+		want := "# TYPE test_gauge_func gauge\ntest_gauge_func 4\n"
+		if out := writeText(); !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q, got %q", want, out)
+		}
+	})
+}
+
+func TestHistogram(t *testing.T) {
+	// Normal case: Buckets are cumulative and end with +Inf, followed by sum and count
+	t.Run("Buckets", func(t *testing.T) {
+		h := NewHistogram("test_latency_seconds", "Latency.", []float64{1, 0.1}, "route")
+		h.Observe(0.05, "/a")
+		h.Observe(0.5, "/a")
+		h.Observe(5, "/a")
+
+		if h.Count("/a") != 3 {
+			t.Errorf("Expected 3 observations, got %d", h.Count("/a"))
+		}
+		want := "# TYPE test_latency_seconds histogram\n" +
+			"test_latency_seconds_bucket{route=\"/a\",le=\"0.1\"} 1\n" +
+			"test_latency_seconds_bucket{route=\"/a\",le=\"1\"} 2\n" +
+			"test_latency_seconds_bucket{route=\"/a\",le=\"+Inf\"} 3\n" +
+			"test_latency_seconds_sum{route=\"/a\"} 5.55\n" +
+			"test_latency_seconds_count{route=\"/a\"} 3\n"
+		if out := writeText(); !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q, got %q", want, out)
+		}
+	})
+
+	// Edge case: Exponential buckets grow by the factor
+	t.Run("ExponentialBuckets", func(t *testing.T) {
+		buckets := ExponentialBuckets(1, 4, 3)
+		if len(buckets) != 3 || buckets[0] != 1 || buckets[1] != 4 || buckets[2] != 16 {
+			t.Errorf("Expected [1 4 16], got %v", buckets)
+		}
+	})
+}
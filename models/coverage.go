@@ -0,0 +1,99 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// StoreCoverageRow is the visit history of one store over a coverage period
+type StoreCoverageRow struct {
+	StoreID   string `json:"store_id"`
+	StoreName string `json:"store_name"`
+	AreaCode  string `json:"area_code"`
+	// Visits counts successful visits within the period
+	Visits        int     `json:"visits"`
+	VisitsPerWeek float64 `json:"visits_per_week"`
+	// LastVisitTime is the latest successful visit before the end of the period, which may precede it
+	LastVisitTime *time.Time `json:"last_visit_time"`
+}
+
+// StoreCoverage summarizes which stores were visited during a period
+type StoreCoverage struct {
+	TotalStores     int                `json:"total_stores"`
+	VisitedStores   int                `json:"visited_stores"`
+	UnvisitedStores int                `json:"unvisited_stores"`
+	Coverage        float64            `json:"coverage"`
+	Stores          []StoreCoverageRow `json:"stores"`
+}
+
+// CoverageReport lists the stores of the current store master that were
+// active during the period, optionally limited to one area code, with the
+// visits of a tenant's jobs. A visit is successful if at least one of its
+// images was processed. Unvisited stores come first, then stores ordered by
+// ID. The period must have both bounds.
+func CoverageReport(tenant string, period ReportPeriod, areaCode string) StoreCoverage {
+	type history struct {
+		visits int
+		last   time.Time
+	}
+	histories := make(map[string]*history)
+	upToEnd := ReportPeriod{To: period.To}
+	for _, job := range SnapshotJobs(tenant) {
+		for key, stats := range jobVisitStats(job, upToEnd) {
+			if stats.ProcessedImages == 0 {
+				continue
+			}
+			visitTime, _ := time.Parse(time.RFC3339, key.visitTime)
+			h, exists := histories[key.storeID]
+			if !exists {
+				h = &history{}
+				histories[key.storeID] = h
+			}
+			if visitTime.After(h.last) {
+				h.last = visitTime
+			}
+			if period.Contains(visitTime) {
+				h.visits += stats.Visits
+			}
+		}
+	}
+
+	stores := CurrentStoreMaster().All()
+	if areaCode != "" {
+		stores = CurrentStoreMaster().ByAreaCode(areaCode)
+	}
+	weeks := period.To.Sub(period.From).Hours() / (24 * 7)
+
+	coverage := StoreCoverage{Stores: []StoreCoverageRow{}}
+	for _, store := range stores {
+		if !store.ActiveDuring(period.From, period.To) {
+			continue
+		}
+		row := StoreCoverageRow{StoreID: store.ID, StoreName: store.Name, AreaCode: store.AreaCode}
+		if h, exists := histories[store.ID]; exists {
+			last := h.last
+			row.Visits, row.LastVisitTime = h.visits, &last
+			if weeks > 0 {
+				row.VisitsPerWeek = float64(h.visits) / weeks
+			}
+		}
+		coverage.TotalStores++
+		if row.Visits > 0 {
+			coverage.VisitedStores++
+		}
+		coverage.Stores = append(coverage.Stores, row)
+	}
+	coverage.UnvisitedStores = coverage.TotalStores - coverage.VisitedStores
+	if coverage.TotalStores > 0 {
+		coverage.Coverage = float64(coverage.VisitedStores) / float64(coverage.TotalStores)
+	}
+
+	sort.SliceStable(coverage.Stores, func(i, j int) bool {
+		a, b := coverage.Stores[i], coverage.Stores[j]
+		if (a.Visits == 0) != (b.Visits == 0) {
+			return a.Visits == 0
+		}
+		return a.StoreID < b.StoreID
+	})
+	return coverage
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"backend-intern-assignment/utils"
+)
+
+func TestCoverageReport(t *testing.T) {
+	InitTestStoreMaster()
+	defer InitTestStoreMaster()
+	closed := time.Date(2037, 1, 1, 0, 0, 0, 0, time.UTC)
+	UpdateTestStore(Store{ID: "CV00001", Name: "VISITED", AreaCode: "9900001"})
+	UpdateTestStore(Store{ID: "CV00002", Name: "VISITED BEFORE", AreaCode: "9900001"})
+	UpdateTestStore(Store{ID: "CV00003", Name: "NEVER VISITED", AreaCode: "9900001"})
+	UpdateTestStore(Store{ID: "CV00004", Name: "FAILED VISIT", AreaCode: "9900001"})
+	UpdateTestStore(Store{ID: "CV00005", Name: "CLOSED", AreaCode: "9900001", Status: StoreStatusInactive, ActiveTo: &closed})
+
+	createReportJob(
+		[]Visit{
+			{StoreID: "CV00001", VisitTime: "2037-03-02T10:00:00Z", ImageURLs: []string{"a.jpg"}},
+			{StoreID: "CV00001", VisitTime: "2037-03-09T10:00:00Z", ImageURLs: []string{"b.jpg"}},
+			{StoreID: "CV00002", VisitTime: "2037-02-01T10:00:00Z", ImageURLs: []string{"c.jpg"}},
+			{StoreID: "CV00004", VisitTime: "2037-03-03T10:00:00Z", ImageURLs: []string{"d.jpg"}},
+		},
+		[]ImageResult{
+			{StoreID: "CV00001", VisitTime: "2037-03-02T10:00:00Z", ImageURL: "a.jpg", Metrics: utils.Metrics{"perimeter": 1}},
+			{StoreID: "CV00001", VisitTime: "2037-03-09T10:00:00Z", ImageURL: "b.jpg", Metrics: utils.Metrics{"perimeter": 1}},
+			{StoreID: "CV00002", VisitTime: "2037-02-01T10:00:00Z", ImageURL: "c.jpg", Metrics: utils.Metrics{"perimeter": 1}},
+		},
+		[]JobError{
+			{StoreID: "CV00004", VisitTime: "2037-03-03T10:00:00Z", Error: "Failed to download image", ImageURL: "d.jpg"},
+		},
+	)
+	fortnight := ReportPeriod{
+		From: time.Date(2037, 3, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2037, 3, 15, 0, 0, 0, 0, time.UTC),
+	}
+	coverage := CoverageReport("", fortnight, "9900001")
+
+	// Normal case: Stores inactive during the period are left out
+	t.Run("Totals", func(t *testing.T) {
+		if coverage.TotalStores != 4 || coverage.VisitedStores != 1 || coverage.UnvisitedStores != 3 || coverage.Coverage != 0.25 {
+			t.Errorf("Expected 1 of 4 stores visited, got %+v", coverage)
+		}
+	})
+
+	// Normal case: Unvisited stores come first with their last visit before the period
+	t.Run("Unvisited", func(t *testing.T) {
+		if len(coverage.Stores) != 4 {
+			t.Fatalf("Expected 4 rows, got %d", len(coverage.Stores))
+		}
+		ids := []string{coverage.Stores[0].StoreID, coverage.Stores[1].StoreID, coverage.Stores[2].StoreID, coverage.Stores[3].StoreID}
+		if ids[0] != "CV00002" || ids[1] != "CV00003" || ids[2] != "CV00004" || ids[3] != "CV00001" {
+			t.Errorf("Expected unvisited stores first, got %v", ids)
+		}
+		before := coverage.Stores[0]
+		if before.Visits != 0 || before.LastVisitTime == nil || !before.LastVisitTime.Equal(time.Date(2037, 2, 1, 10, 0, 0, 0, time.UTC)) {
+			t.Errorf("Expected last visit on 2037-02-01, got %+v", before)
+		}
+		if coverage.Stores[1].LastVisitTime != nil {
+			t.Errorf("Expected no last visit for a store never visited, got %v", coverage.Stores[1].LastVisitTime)
+		}
+	})
+
+	// Edge case: Visits where no image was processed do not count
+	t.Run("FailedVisit", func(t *testing.T) {
+		if failed := coverage.Stores[2]; failed.Visits != 0 || failed.LastVisitTime != nil {
+			t.Errorf("Expected failed visit to be ignored, got %+v", failed)
+		}
+	})
+
+	// Normal case: Visit frequency is per week of the period
+	t.Run("Frequency", func(t *testing.T) {
+		visited := coverage.Stores[3]
+		if visited.Visits != 2 || visited.VisitsPerWeek != 1 {
+			t.Errorf("Expected 2 visits at 1 per week, got %+v", visited)
+		}
+		if !visited.LastVisitTime.Equal(time.Date(2037, 3, 9, 10, 0, 0, 0, time.UTC)) {
+			t.Errorf("Expected last visit on 2037-03-09, got %v", visited.LastVisitTime)
+		}
+	})
+
+	// Edge case: An unknown area code yields an empty report
+	t.Run("UnknownArea", func(t *testing.T) {
+		if empty := CoverageReport("", fortnight, "0000000"); empty.TotalStores != 0 || len(empty.Stores) != 0 {
+			t.Errorf("Expected empty report, got %+v", empty)
+		}
+	})
+}
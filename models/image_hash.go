@@ -0,0 +1,152 @@
+package models
+
+import (
+	"slices"
+	"sync"
+
+	"backend-intern-assignment/utils"
+)
+
+// DuplicateHammingThreshold is the maximum Hamming distance at which two
+// perceptual hashes are considered the same photo
+var DuplicateHammingThreshold = 6
+
+// MaxImageHashes caps the duplicate detection index. Once it is full, the
+// oldest images are forgotten first.
+var MaxImageHashes = 100000
+
+// hashBands is how many 8-bit bands of a hash are indexed. Two hashes less
+// than hashBands bits apart agree on at least one band, so only images
+// sharing a band with a hash need to be compared with it.
+const hashBands = 8
+
+// HashRecord is a processed image indexed by its perceptual hash. Images are
+// only compared with images of the same tenant.
+type HashRecord struct {
+	Tenant    string `json:"-"`
+	JobID     string `json:"job_id"`
+	StoreID   string `json:"store_id"`
+	VisitTime string `json:"visit_time"`
+	ImageURL  string `json:"image_url"`
+	Hash      uint64 `json:"-"`
+	Distance  int    `json:"distance"`
+}
+
+// imageHashIndex holds the indexed images by sequence number, oldest first,
+// and the sequence numbers of the images in each bucket of each band
+type imageHashIndex struct {
+	records     map[uint64]HashRecord
+	first, next uint64
+	bands       [hashBands]map[uint8][]uint64
+}
+
+var (
+	imageHashes      = newImageHashIndex()
+	imageHashesMutex sync.RWMutex
+)
+
+func newImageHashIndex() *imageHashIndex {
+	index := &imageHashIndex{records: make(map[uint64]HashRecord)}
+	for i := range index.bands {
+		index.bands[i] = make(map[uint8][]uint64)
+	}
+	return index
+}
+
+// FindDuplicateImages returns previously indexed images of tenant within the
+// Hamming threshold of hash that belong to a different store or a different visit
+func FindDuplicateImages(tenant string, hash uint64, storeID, visitTime string) []HashRecord {
+	imageHashesMutex.RLock()
+	defer imageHashesMutex.RUnlock()
+
+	return imageHashes.duplicates(tenant, hash, storeID, visitTime)
+}
+
+// IndexImageHash records an image's perceptual hash for later duplicate checks
+// and returns the images it duplicates. Both happen under one lock, so of two
+// copies processed at the same time the second is flagged.
+func IndexImageHash(record HashRecord) []HashRecord {
+	imageHashesMutex.Lock()
+	defer imageHashesMutex.Unlock()
+
+	matches := imageHashes.duplicates(record.Tenant, record.Hash, record.StoreID, record.VisitTime)
+	imageHashes.add(record)
+	for len(imageHashes.records) > max(MaxImageHashes, 1) {
+		imageHashes.evictOldest()
+	}
+	return matches
+}
+
+// ResetImageHashes clears the duplicate detection index
+func ResetImageHashes() {
+	imageHashesMutex.Lock()
+	defer imageHashesMutex.Unlock()
+
+	imageHashes = newImageHashIndex()
+}
+
+// duplicates returns the indexed images of tenant within the threshold of
+// hash, oldest first
+func (index *imageHashIndex) duplicates(tenant string, hash uint64, storeID, visitTime string) []HashRecord {
+	var matches []HashRecord
+	for _, seq := range index.candidates(hash) {
+		record := index.records[seq]
+		if record.Tenant != tenant || (record.StoreID == storeID && record.VisitTime == visitTime) {
+			continue
+		}
+		distance := utils.HammingDistance(hash, record.Hash)
+		if distance <= DuplicateHammingThreshold {
+			record.Distance = distance
+			matches = append(matches, record)
+		}
+	}
+	return matches
+}
+
+// candidates returns the sequence numbers of the images that may be within
+// the threshold of hash, in order. Thresholds too wide for the bands fall
+// back to every image.
+func (index *imageHashIndex) candidates(hash uint64) []uint64 {
+	var seqs []uint64
+	if DuplicateHammingThreshold >= hashBands {
+		for seq := index.first; seq < index.next; seq++ {
+			seqs = append(seqs, seq)
+		}
+		return seqs
+	}
+	for band := range index.bands {
+		seqs = append(seqs, index.bands[band][hashBand(hash, band)]...)
+	}
+	slices.Sort(seqs)
+	return slices.Compact(seqs)
+}
+
+func (index *imageHashIndex) add(record HashRecord) {
+	seq := index.next
+	index.next++
+	index.records[seq] = record
+	for band := range index.bands {
+		key := hashBand(record.Hash, band)
+		index.bands[band][key] = append(index.bands[band][key], seq)
+	}
+}
+
+// evictOldest forgets the oldest image, which is first in each of its buckets
+func (index *imageHashIndex) evictOldest() {
+	seq := index.first
+	record := index.records[seq]
+	delete(index.records, seq)
+	index.first++
+	for band := range index.bands {
+		key := hashBand(record.Hash, band)
+		if bucket := index.bands[band][key][1:]; len(bucket) > 0 {
+			index.bands[band][key] = bucket
+		} else {
+			delete(index.bands[band], key)
+		}
+	}
+}
+
+func hashBand(hash uint64, band int) uint8 {
+	return uint8(hash >> (8 * band))
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestFindDuplicateImages(t *testing.T) {
+	ResetImageHashes()
+	defer ResetImageHashes()
+
+	IndexImageHash(HashRecord{JobID: "job-1", StoreID: "RP00001", VisitTime: "2023-10-21T15:04:05Z", ImageURL: "a.jpg", Hash: 0xFF00})
+
+	// Normal case: Near-identical hash from another store is flagged
+	t.Run("OtherStore", func(t *testing.T) {
+		matches := FindDuplicateImages("", 0xFF01, "RP00002", "2023-10-21T15:04:05Z")
+		if len(matches) != 1 {
+			t.Fatalf("Expected 1 match, got %d", len(matches))
+		}
+		if matches[0].Distance != 1 || matches[0].ImageURL != "a.jpg" {
+			t.Errorf("Unexpected match: %+v", matches[0])
+		}
+	})
+
+	// Normal case: Same store but an earlier visit is flagged
+	t.Run("EarlierVisit", func(t *testing.T) {
+		if matches := FindDuplicateImages("", 0xFF00, "RP00001", "2023-10-28T10:00:00Z"); len(matches) != 1 {
+			t.Errorf("Expected 1 match, got %d", len(matches))
+		}
+	})
+
+	// Edge case: Images from the same visit are not duplicates of each other
+	t.Run("SameVisit", func(t *testing.T) {
+		if matches := FindDuplicateImages("", 0xFF00, "RP00001", "2023-10-21T15:04:05Z"); len(matches) != 0 {
+			t.Errorf("Expected no matches, got %d", len(matches))
+		}
+	})
+
+	// Edge case: Hashes beyond the threshold are not flagged
+	t.Run("BeyondThreshold", func(t *testing.T) {
+		if matches := FindDuplicateImages("", 0x00FF, "RP00002", "2023-10-21T15:04:05Z"); len(matches) != 0 {
+			t.Errorf("Expected no matches, got %d", len(matches))
+		}
+	})
+}
+
+func TestIndexImageHash(t *testing.T) {
+	ResetImageHashes()
+	defer ResetImageHashes()
+
+	// Normal case: Of two copies indexed at the same time exactly one is flagged
+	t.Run("Concurrent", func(t *testing.T) {
+		var wg sync.WaitGroup
+		results := make([][]HashRecord, 2)
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i] = IndexImageHash(HashRecord{StoreID: "RP0000" + string(rune('1'+i)), VisitTime: "2023-10-21T15:04:05Z", Hash: 0xABCD})
+			}(i)
+		}
+		wg.Wait()
+		if len(results[0])+len(results[1]) != 1 {
+			t.Errorf("Expected exactly one copy to be flagged, got %v", results)
+		}
+	})
+
+	// Edge case: The oldest images are forgotten once the index is full
+	t.Run("Eviction", func(t *testing.T) {
+		defer func(n int) { MaxImageHashes = n }(MaxImageHashes)
+		MaxImageHashes = 2
+		ResetImageHashes()
+		IndexImageHash(HashRecord{StoreID: "RP00001", Hash: 0x1})
+		IndexImageHash(HashRecord{StoreID: "RP00002", Hash: 0x1})
+		IndexImageHash(HashRecord{StoreID: "RP00003", Hash: 0x1})
+		matches := FindDuplicateImages("", 0x1, "RP00004", "")
+		if len(matches) != 2 || matches[0].StoreID != "RP00002" || matches[1].StoreID != "RP00003" {
+			t.Errorf("Expected the two newest images, got %+v", matches)
+		}
+	})
+
+	// Edge case: Images are only compared within a tenant
+	t.Run("Tenants", func(t *testing.T) {
+		ResetImageHashes()
+		IndexImageHash(HashRecord{Tenant: "acme", JobID: "job-acme", StoreID: "RP00001", ImageURL: "private.jpg", Hash: 0x42})
+		if matches := IndexImageHash(HashRecord{Tenant: "globex", StoreID: "RP00002", Hash: 0x42}); len(matches) != 0 {
+			t.Errorf("Expected no match across tenants, got %+v", matches)
+		}
+		if matches := FindDuplicateImages("", 0x42, "RP00003", ""); len(matches) != 0 {
+			t.Errorf("Expected no match for jobs without a tenant, got %+v", matches)
+		}
+		matches := IndexImageHash(HashRecord{Tenant: "acme", StoreID: "RP00002", Hash: 0x42})
+		if len(matches) != 1 || matches[0].JobID != "job-acme" {
+			t.Errorf("Expected the tenant's own image, got %+v", matches)
+		}
+	})
+
+	// Edge case: Hashes differing in every band are found with a wide threshold
+	t.Run("WideThreshold", func(t *testing.T) {
+		defer func(n int) { DuplicateHammingThreshold = n }(DuplicateHammingThreshold)
+		ResetImageHashes()
+		IndexImageHash(HashRecord{StoreID: "RP00001", Hash: 0})
+		if matches := FindDuplicateImages("", 0x0101010101010101, "RP00002", ""); len(matches) != 0 {
+			t.Errorf("Expected no match 8 bits apart with the default threshold, got %d", len(matches))
+		}
+		DuplicateHammingThreshold = 8
+		if matches := FindDuplicateImages("", 0x0101010101010101, "RP00002", ""); len(matches) != 1 {
+			t.Errorf("Expected 1 match with a threshold of 8, got %d", len(matches))
+		}
+	})
+}
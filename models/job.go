@@ -2,13 +2,18 @@ package models
 
 import (
 	"errors"
+	"sort"
 	"sync"
 	"sync/atomic"
+
+	"backend-intern-assignment/utils"
 )
 
 type JobRequest struct {
-	Count  int     `json:"count"`
-	Visits []Visit `json:"visits"`
+	Count             int                      `json:"count"`
+	Visits            []Visit                  `json:"visits"`
+	Analyzers         []string                 `json:"analyzers,omitempty"`
+	QualityThresholds *utils.QualityThresholds `json:"quality_thresholds,omitempty"`
 }
 
 type Visit struct {
@@ -18,47 +23,124 @@ type Visit struct {
 }
 
 var (
-	jobs      = make(map[int]*Job)
+	jobs      = make(map[string]*Job)
 	jobsMutex sync.Mutex
-	jobIDSeq  int32 = 1
+
+	// legacyJobIDs maps the numeric IDs clients used before job IDs became
+	// UUIDs to the jobs they now refer to
+	legacyJobIDs       = make(map[int]string)
+	legacyJobSeq int32 = 1
 )
 
 type Job struct {
-	ID      int
+	// ID is the job's UUIDv7
+	ID string
+	// LegacyID is a sequential numeric alias accepted by /api/status while
+	// clients move to UUIDs
+	LegacyID int
+
 	Request JobRequest
 	Status  string
 	Errors  []JobError
 	Results []ImageResult
+
+	// Tenant owns the job; only requests authenticated as the tenant can see it.
+	// Jobs submitted while authentication is disabled have no tenant.
+	Tenant string
+
+	// StoreMasterVersion is the version ID of the store master the job's visits were validated against
+	StoreMasterVersion string
+
+	// RequestID is the ID of the HTTP request that submitted the job, used to correlate logs
+	RequestID string
+
+	// TraceParent is the W3C traceparent of the span that submitted the job,
+	// so processing continues the submitting request's trace
+	TraceParent string
 }
 
 type JobError struct {
-	StoreID string `json:"store_id"`
-	Error   string `json:"error"`
+	StoreID    string            `json:"store_id"`
+	StoreName  string            `json:"store_name,omitempty"`
+	AreaCode   string            `json:"area_code,omitempty"`
+	VisitTime  string            `json:"visit_time,omitempty"`
+	Error      string            `json:"error"`
+	ImageURL   string            `json:"image_url,omitempty"`
+	Reason     string            `json:"reason,omitempty"`
+	DidYouMean []StoreSuggestion `json:"did_you_mean,omitempty"`
 }
 
+// Image result statuses
+const (
+	ImageStatusProcessed       = "processed"
+	ImageStatusQualityRejected = "quality_rejected"
+)
+
+// Job error codes
+const (
+	// ErrDuplicateImage is recorded for images that match a photo from another visit
+	ErrDuplicateImage = "duplicate_image"
+	// ErrStoreInactiveAtVisitTime is recorded for visits outside the store's active window
+	ErrStoreInactiveAtVisitTime = "store_inactive_at_visit_time"
+	// ErrJobInterrupted is recorded for jobs stopped by a server shutdown
+	ErrJobInterrupted = "job_interrupted"
+	// ErrURLNotAllowed is recorded for image URLs blocked by the egress policy
+	ErrURLNotAllowed = "url_not_allowed"
+)
+
+// JobStatusInterrupted marks a job that was stopped before it finished
+const JobStatusInterrupted = "interrupted"
+
 type ImageResult struct {
-	StoreID   string
-	ImageURL  string
-	Perimeter int
+	StoreID        string
+	StoreName      string
+	AreaCode       string
+	VisitTime      string
+	ImageURL       string
+	Status         string
+	Metrics        utils.Metrics
+	PerceptualHash uint64
+	Duplicates     []HashRecord
+	OriginalKey    string
+	ThumbnailKey   string
 }
 
-// CreateJob creates a new job and returns its ID
-func CreateJob(req JobRequest) int {
-	jobID := int(atomic.AddInt32(&jobIDSeq, 1)) - 1
+// CreateJob creates a new job without a tenant and returns its ID
+func CreateJob(req JobRequest) string {
+	return CreateTenantJob("", req)
+}
+
+// CreateTenantJob creates a new job owned by tenant and returns its ID
+func CreateTenantJob(tenant string, req JobRequest) string {
+	jobID := NewJobID()
+	legacyID := int(atomic.AddInt32(&legacyJobSeq, 1)) - 1
 
 	jobsMutex.Lock()
 	jobs[jobID] = &Job{
-		ID:      jobID,
-		Request: req,
-		Status:  "ongoing",
+		ID:       jobID,
+		LegacyID: legacyID,
+		Request:  req,
+		Status:   "ongoing",
+		Tenant:   tenant,
 	}
+	legacyJobIDs[legacyID] = jobID
 	jobsMutex.Unlock()
+	jobsSubmitted.Inc()
 
 	return jobID
 }
 
+// LookupLegacyJobID returns the ID of the job with a legacy numeric ID
+func LookupLegacyJobID(legacyID int) (string, bool) {
+	jobsMutex.Lock()
+	defer jobsMutex.Unlock()
+
+	jobID, exists := legacyJobIDs[legacyID]
+	return jobID, exists
+}
+
 // FetchJob retrieves a job by ID
-func FetchJob(jobID int) (*Job, error) {
+func FetchJob(jobID string) (*Job, error) {
 	jobsMutex.Lock()
 	defer jobsMutex.Unlock()
 
@@ -69,48 +151,170 @@ func FetchJob(jobID int) (*Job, error) {
 	return job, nil
 }
 
+// SnapshotJob returns a copy of a job that is safe to read while the job is being processed
+func SnapshotJob(jobID string) (Job, error) {
+	jobsMutex.Lock()
+	defer jobsMutex.Unlock()
+
+	job, exists := jobs[jobID]
+	if !exists {
+		return Job{}, errors.New("job not found")
+	}
+	snapshot := *job
+	snapshot.Errors = append([]JobError(nil), job.Errors...)
+	snapshot.Results = append([]ImageResult(nil), job.Results...)
+	return snapshot, nil
+}
+
+// SnapshotJobs returns a copy of every job of a tenant, ordered by job ID
+func SnapshotJobs(tenant string) []Job {
+	jobsMutex.Lock()
+	defer jobsMutex.Unlock()
+
+	snapshots := make([]Job, 0, len(jobs))
+	for _, job := range jobs {
+		if job.Tenant != tenant {
+			continue
+		}
+		snapshot := *job
+		snapshot.Errors = append([]JobError(nil), job.Errors...)
+		snapshot.Results = append([]ImageResult(nil), job.Results...)
+		snapshots = append(snapshots, snapshot)
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].ID < snapshots[j].ID
+	})
+	return snapshots
+}
+
+// SetJobStoreMasterVersion records the store master version a job is validated
+// against and keeps that version available for diffs
+func SetJobStoreMasterVersion(jobID string, versionID string) {
+	useStoreMasterVersion(versionID)
+
+	jobsMutex.Lock()
+	defer jobsMutex.Unlock()
+
+	job := jobs[jobID]
+	job.StoreMasterVersion = versionID
+}
+
+// SetJobRequestID records the ID of the request that submitted a job
+func SetJobRequestID(jobID string, requestID string) {
+	jobsMutex.Lock()
+	defer jobsMutex.Unlock()
+
+	job := jobs[jobID]
+	job.RequestID = requestID
+}
+
+// SetJobTraceParent records the trace context processing of a job continues
+func SetJobTraceParent(jobID string, traceParent string) {
+	jobsMutex.Lock()
+	defer jobsMutex.Unlock()
+
+	job := jobs[jobID]
+	job.TraceParent = traceParent
+}
+
 // AddJobError adds an error to a job
-func AddJobError(jobID int, storeID, errMsg string) {
+func AddJobError(jobID string, storeID, errMsg string) {
+	AppendJobError(jobID, JobError{StoreID: storeID, Error: errMsg})
+}
+
+// AppendJobError adds a fully populated error to a job, filling in the
+// store name and area code from the store master
+func AppendJobError(jobID string, jobErr JobError) {
+	if store, exists := LookupStore(jobErr.StoreID); exists {
+		jobErr.StoreName, jobErr.AreaCode = store.Name, store.AreaCode
+	}
+
 	jobsMutex.Lock()
 	defer jobsMutex.Unlock()
 
 	job := jobs[jobID]
-	job.Errors = append(job.Errors, JobError{StoreID: storeID, Error: errMsg})
+	job.Errors = append(job.Errors, jobErr)
 }
 
 // FailJob sets the job status to "failed"
-func FailJob(jobID int) {
+func FailJob(jobID string) {
 	jobsMutex.Lock()
 	defer jobsMutex.Unlock()
 
 	job := jobs[jobID]
 	job.Status = "failed"
+	jobsFinished.Inc(job.Status)
 }
 
 // CompleteJob sets the job status to "completed"
-func CompleteJob(jobID int) {
+func CompleteJob(jobID string) {
 	jobsMutex.Lock()
 	defer jobsMutex.Unlock()
 
 	job := jobs[jobID]
 	job.Status = "completed"
+	jobsFinished.Inc(job.Status)
 }
 
 // StoreImageResult stores the result of image processing
-func StoreImageResult(jobID int, storeID, imageURL string, perimeter int) {
+func StoreImageResult(jobID string, result ImageResult) {
+	enrichImageResult(&result)
+
+	jobsMutex.Lock()
+	defer jobsMutex.Unlock()
+
+	if result.Status == "" {
+		result.Status = ImageStatusProcessed
+	}
+	job := jobs[jobID]
+	job.Results = append(job.Results, result)
+	imagesStored.Inc(result.Status)
+}
+
+// RejectImageResult stores the result of an image that failed the quality
+// checks and records the reason in the job's errors
+func RejectImageResult(jobID string, result ImageResult, reason string) {
+	enrichImageResult(&result)
+
 	jobsMutex.Lock()
 	defer jobsMutex.Unlock()
 
+	result.Status = ImageStatusQualityRejected
 	job := jobs[jobID]
-	job.Results = append(job.Results, ImageResult{
-		StoreID:   storeID,
-		ImageURL:  imageURL,
-		Perimeter: perimeter,
+	job.Results = append(job.Results, result)
+	imagesStored.Inc(result.Status)
+	job.Errors = append(job.Errors, JobError{
+		StoreID:   result.StoreID,
+		StoreName: result.StoreName,
+		AreaCode:  result.AreaCode,
+		VisitTime: result.VisitTime,
+		Error:     ImageStatusQualityRejected,
+		ImageURL:  result.ImageURL,
+		Reason:    reason,
 	})
 }
 
+// enrichImageResult fills in the store name and area code from the store master
+func enrichImageResult(result *ImageResult) {
+	if store, exists := LookupStore(result.StoreID); exists {
+		result.StoreName, result.AreaCode = store.Name, store.AreaCode
+	}
+}
+
+// InterruptJob sets the job status to "interrupted" and records why. Results
+// stored before the interruption are kept.
+func InterruptJob(jobID string, reason string) {
+	jobsMutex.Lock()
+	defer jobsMutex.Unlock()
+
+	job := jobs[jobID]
+	job.Status = JobStatusInterrupted
+	job.Errors = append(job.Errors, JobError{Error: ErrJobInterrupted, Reason: reason})
+	jobsFinished.Inc(job.Status)
+}
+
 // GetJobStatus returns the status and errors of a job
-func GetJobStatus(jobID int) (string, []JobError, error) {
+func GetJobStatus(jobID string) (string, []JobError, error) {
 	jobsMutex.Lock()
 	defer jobsMutex.Unlock()
 
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+var (
+	// jobIDMutex keeps IDs generated within the same millisecond in order
+	jobIDMutex  sync.Mutex
+	lastIDMilli int64
+	lastIDSeq   uint16
+)
+
+// NewJobID returns a UUIDv7: 48 bits of Unix milliseconds, a 12-bit counter
+// that orders IDs created in the same millisecond, and 62 random bits. IDs
+// sort as strings in the order they were created, including across restarts.
+func NewJobID() string {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		panic("models: reading random bytes: " + err.Error())
+	}
+
+	milli, seq := nextJobIDTime(time.Now().UnixMilli(), binary.BigEndian.Uint16(id[6:8])&0x07ff)
+	binary.BigEndian.PutUint64(id[:8], uint64(milli)<<16)
+	binary.BigEndian.PutUint16(id[6:8], 0x7000|seq)
+	id[8] = id[8]&0x3f | 0x80
+
+	var s [36]byte
+	hex.Encode(s[0:8], id[0:4])
+	hex.Encode(s[9:13], id[4:6])
+	hex.Encode(s[14:18], id[6:8])
+	hex.Encode(s[19:23], id[8:10])
+	hex.Encode(s[24:36], id[10:16])
+	s[8], s[13], s[18], s[23] = '-', '-', '-', '-'
+	return string(s[:])
+}
+
+// nextJobIDTime returns the timestamp and counter of the next ID. A new
+// millisecond starts the counter at a random value below 2048 so it has room
+// to count up; when it runs out, or the clock goes back, the timestamp of the
+// previous ID is advanced instead.
+func nextJobIDTime(now int64, random uint16) (int64, uint16) {
+	jobIDMutex.Lock()
+	defer jobIDMutex.Unlock()
+
+	if now > lastIDMilli {
+		lastIDMilli, lastIDSeq = now, random
+	} else if lastIDSeq < 0x0fff {
+		lastIDSeq++
+	} else {
+		lastIDMilli, lastIDSeq = lastIDMilli+1, random
+	}
+	return lastIDMilli, lastIDSeq
+}
+
+// IsJobID reports whether id is formatted like a job ID: a UUID in lowercase hex
+func IsJobID(id string) bool {
+	if len(id) != 36 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		switch c := id[i]; {
+		case i == 8 || i == 13 || i == 18 || i == 23:
+			if c != '-' {
+				return false
+			}
+		case !('0' <= c && c <= '9' || 'a' <= c && c <= 'f'):
+			return false
+		}
+	}
+	return true
+}
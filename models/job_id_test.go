@@ -0,0 +1,74 @@
+package models
+
+import (
+	"regexp"
+	"testing"
+)
+
+var uuidV7Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestNewJobID(t *testing.T) {
+	// Normal case: IDs are UUIDv7 strings
+	t.Run("Format", func(t *testing.T) {
+		id := NewJobID()
+		if !uuidV7Pattern.MatchString(id) {
+			t.Errorf("Expected a UUIDv7, got %s", id)
+		}
+	})
+
+	// Normal case: IDs are unique and sort in creation order
+	t.Run("UniqueAndOrdered", func(t *testing.T) {
+		previous := NewJobID()
+		seen := map[string]bool{previous: true}
+		for i := 0; i < 10000; i++ {
+			id := NewJobID()
+			if seen[id] {
+				t.Fatalf("Expected unique IDs, got %s twice", id)
+			}
+			if id <= previous {
+				t.Fatalf("Expected %s to sort after %s", id, previous)
+			}
+			seen[id], previous = true, id
+		}
+	})
+}
+
+func TestIsJobID(t *testing.T) {
+	// Normal case: Generated IDs are recognized
+	if id := NewJobID(); !IsJobID(id) {
+		t.Errorf("Expected %s to be a job ID", id)
+	}
+
+	// Edge case: Legacy numbers, uppercase hex and misplaced dashes are not job IDs
+	for _, id := range []string{"42", "018F3C2A-7B41-7C3E-9A52-4D6E8F0A1B2C", "018f3c2a7b41-7c3e-9a52-4d6e8f0a1b2c-", "018f3c2a-7b41-7c3e-9a52-4d6e8f0a1b2g"} {
+		if IsJobID(id) {
+			t.Errorf("Expected %s not to be a job ID", id)
+		}
+	}
+}
+
+func TestNextJobIDTime(t *testing.T) {
+	defer func(milli int64, seq uint16) { lastIDMilli, lastIDSeq = milli, seq }(lastIDMilli, lastIDSeq)
+
+	// Normal case: A new millisecond starts from the random counter
+	lastIDMilli, lastIDSeq = 1000, 5
+	if milli, seq := nextJobIDTime(1001, 42); milli != 1001 || seq != 42 {
+		t.Errorf("Expected 1001/42, got %d/%d", milli, seq)
+	}
+
+	// Normal case: The same millisecond counts up
+	if milli, seq := nextJobIDTime(1001, 7); milli != 1001 || seq != 43 {
+		t.Errorf("Expected 1001/43, got %d/%d", milli, seq)
+	}
+
+	// Edge case: A clock that goes back keeps counting from the previous ID
+	if milli, seq := nextJobIDTime(900, 7); milli != 1001 || seq != 44 {
+		t.Errorf("Expected 1001/44, got %d/%d", milli, seq)
+	}
+
+	// Edge case: An exhausted counter advances the timestamp
+	lastIDSeq = 0x0fff
+	if milli, seq := nextJobIDTime(1001, 7); milli != 1002 || seq != 7 {
+		t.Errorf("Expected 1002/7, got %d/%d", milli, seq)
+	}
+}
@@ -23,13 +23,13 @@ func TestCreateAndFetchJob(t *testing.T) {
 			t.Errorf("Expected to fetch job without error, got %v", err)
 		}
 		if job.ID != jobID {
-			t.Errorf("Expected job ID %d, got %d", jobID, job.ID)
+			t.Errorf("Expected job ID %s, got %s", jobID, job.ID)
 		}
 	})
 
 	// Edge case: Fetch a non-existent job
 	t.Run("FetchNonExistentJob", func(t *testing.T) {
-		_, err := FetchJob(999) // Assuming 999 is an invalid job ID
+		_, err := FetchJob("missing-job")
 		if err == nil {
 			t.Error("Expected error for non-existent job, got none")
 		}
@@ -52,6 +52,59 @@ func TestCreateAndFetchJob(t *testing.T) {
 	})
 }
 
+func TestLookupLegacyJobID(t *testing.T) {
+	jobID := CreateJob(JobRequest{})
+	job, _ := SnapshotJob(jobID)
+
+	// Normal case: The legacy numeric ID resolves to the job
+	t.Run("Known", func(t *testing.T) {
+		if id, exists := LookupLegacyJobID(job.LegacyID); !exists || id != jobID {
+			t.Errorf("Expected legacy ID %d to resolve to %s, got %q", job.LegacyID, jobID, id)
+		}
+	})
+
+	// Edge case: Unknown legacy IDs do not resolve
+	t.Run("Unknown", func(t *testing.T) {
+		if _, exists := LookupLegacyJobID(-1); exists {
+			t.Error("Expected legacy ID -1 to be unknown")
+		}
+	})
+}
+
+func TestTenantJobs(t *testing.T) {
+	acmeJob := CreateTenantJob("acme", JobRequest{})
+	globexJob := CreateTenantJob("globex", JobRequest{})
+
+	// Normal case: Jobs are tagged with their tenant
+	t.Run("Tagged", func(t *testing.T) {
+		job, _ := SnapshotJob(acmeJob)
+		if job.Tenant != "acme" {
+			t.Errorf("Expected tenant acme, got %q", job.Tenant)
+		}
+	})
+
+	// Normal case: Listings only include the tenant's jobs
+	t.Run("Scoped", func(t *testing.T) {
+		ids := JobIDs("acme")
+		if len(ids) != 1 || ids[0] != acmeJob {
+			t.Errorf("Expected only job %s, got %v", acmeJob, ids)
+		}
+		snapshots := SnapshotJobs("globex")
+		if len(snapshots) != 1 || snapshots[0].ID != globexJob {
+			t.Errorf("Expected only job %s, got %+v", globexJob, snapshots)
+		}
+	})
+
+	// Edge case: Jobs without a tenant do not include tenants' jobs
+	t.Run("NoTenant", func(t *testing.T) {
+		for _, id := range JobIDs("") {
+			if id == acmeJob || id == globexJob {
+				t.Errorf("Expected tenant jobs to be left out, got job %s", id)
+			}
+		}
+	})
+}
+
 func TestAddJobError(t *testing.T) {
 	// Normal case: Add a single error to a job
 	t.Run("AddSingleError", func(t *testing.T) {
@@ -88,6 +141,21 @@ func TestAddJobError(t *testing.T) {
 		}
 	})
 
+	// Normal case: Errors for known stores carry the store name and area code
+	t.Run("EnrichedWithStore", func(t *testing.T) {
+		InitTestStoreMaster()
+		jobID := CreateJob(JobRequest{Count: 0, Visits: []Visit{}})
+		AddJobError(jobID, "RP00002", "Failed to download image")
+		AddJobError(jobID, "UNKNOWN", "Invalid Store ID")
+		job, _ := FetchJob(jobID)
+		if job.Errors[0].StoreName != "MONAJ STORE" || job.Errors[0].AreaCode != "7100015" {
+			t.Errorf("Expected error enriched with store details, got %+v", job.Errors[0])
+		}
+		if job.Errors[1].StoreName != "" || job.Errors[1].AreaCode != "" {
+			t.Errorf("Expected no store details for an unknown store, got %+v", job.Errors[1])
+		}
+	})
+
 	// Edge case: Add an error to a non-existent job
 	t.Run("AddErrorToNonExistentJob", func(t *testing.T) {
 		defer func() {
@@ -95,7 +163,7 @@ func TestAddJobError(t *testing.T) {
 				t.Errorf("Expected panic when adding error to non-existent job, got none")
 			}
 		}()
-		AddJobError(999, "RP00001", "Non-existent job error")
+		AddJobError("missing-job", "RP00001", "Non-existent job error")
 	})
 }
 
@@ -156,6 +224,26 @@ func TestJobStatusFunctions(t *testing.T) {
 				t.Errorf("Expected panic when changing status of non-existent job, got none")
 			}
 		}()
-		FailJob(999) // Assuming 999 is an invalid job ID
+		FailJob("missing-job")
+	})
+}
+
+func TestInterruptJob(t *testing.T) {
+	// Normal case: Interrupted jobs keep their results and record why they stopped
+	t.Run("KeepsResults", func(t *testing.T) {
+		jobID := CreateJob(JobRequest{Count: 0, Visits: []Visit{}})
+		StoreImageResult(jobID, ImageResult{StoreID: "RP00001", ImageURL: "a.jpg"})
+		InterruptJob(jobID, "server shut down before the job finished")
+
+		job, _ := SnapshotJob(jobID)
+		if job.Status != JobStatusInterrupted {
+			t.Errorf("Expected status %q, got %q", JobStatusInterrupted, job.Status)
+		}
+		if len(job.Results) != 1 {
+			t.Errorf("Expected 1 result to be kept, got %d", len(job.Results))
+		}
+		if len(job.Errors) != 1 || job.Errors[0].Error != ErrJobInterrupted || job.Errors[0].Reason == "" {
+			t.Errorf("Expected an interruption error with a reason, got %+v", job.Errors)
+		}
 	})
 }
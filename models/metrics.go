@@ -0,0 +1,14 @@
+package models
+
+import "backend-intern-assignment/metrics"
+
+var (
+	jobsSubmitted = metrics.NewCounter("jobs_submitted_total", "Jobs accepted for processing.")
+	jobsFinished  = metrics.NewCounter("jobs_finished_total", "Jobs that stopped processing, by final status.", "status")
+	imagesStored  = metrics.NewCounter("images_processed_total", "Images analyzed and stored, by result status.", "status")
+
+	storeMasterReloads = metrics.NewCounter("store_master_reloads_total", "Store master loads, by result.", "result")
+	_                  = metrics.NewGaugeFunc("store_master_stores", "Stores in the active store master.", func() float64 {
+		return float64(CurrentStoreMaster().Len())
+	})
+)
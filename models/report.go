@@ -0,0 +1,203 @@
+package models
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// UnknownAreaCode groups visits to stores that are not in the store master
+const UnknownAreaCode = "unknown"
+
+// PerimeterStats summarizes the perimeters of processed images
+type PerimeterStats struct {
+	Count int     `json:"count"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Avg   float64 `json:"avg"`
+
+	sum float64
+}
+
+func (p *PerimeterStats) add(perimeter float64) {
+	if p.Count == 0 {
+		p.Min, p.Max = perimeter, perimeter
+	}
+	p.Count++
+	p.sum += perimeter
+	p.Min = math.Min(p.Min, perimeter)
+	p.Max = math.Max(p.Max, perimeter)
+	p.Avg = p.sum / float64(p.Count)
+}
+
+func (p *PerimeterStats) merge(other *PerimeterStats) {
+	if other == nil || other.Count == 0 {
+		return
+	}
+	if p.Count == 0 {
+		p.Min, p.Max = other.Min, other.Max
+	}
+	p.Count += other.Count
+	p.sum += other.sum
+	p.Min = math.Min(p.Min, other.Min)
+	p.Max = math.Max(p.Max, other.Max)
+	p.Avg = p.sum / float64(p.Count)
+}
+
+// VisitStats counts visits, images and failures. Perimeter is nil when no
+// image had a perimeter metric.
+type VisitStats struct {
+	Visits          int             `json:"visits"`
+	Images          int             `json:"images"`
+	ProcessedImages int             `json:"processed_images"`
+	Failures        int             `json:"failures"`
+	Perimeter       *PerimeterStats `json:"perimeter,omitempty"`
+}
+
+func (v *VisitStats) addPerimeter(perimeter float64) {
+	if v.Perimeter == nil {
+		v.Perimeter = &PerimeterStats{}
+	}
+	v.Perimeter.add(perimeter)
+}
+
+func (v *VisitStats) merge(other VisitStats) {
+	v.Visits += other.Visits
+	v.Images += other.Images
+	v.ProcessedImages += other.ProcessedImages
+	v.Failures += other.Failures
+	if other.Perimeter != nil {
+		if v.Perimeter == nil {
+			v.Perimeter = &PerimeterStats{}
+		}
+		v.Perimeter.merge(other.Perimeter)
+	}
+}
+
+// StoreReportRow is the activity of one store over a report period
+type StoreReportRow struct {
+	StoreID   string `json:"store_id"`
+	StoreName string `json:"store_name,omitempty"`
+	AreaCode  string `json:"area_code"`
+	VisitStats
+}
+
+// AreaReportRow is the activity of one area code over a report period
+type AreaReportRow struct {
+	AreaCode string `json:"area_code"`
+	Stores   int    `json:"stores"`
+	VisitStats
+}
+
+// ReportPeriod selects visits with From <= visit time < To. A zero bound is open.
+type ReportPeriod struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// Contains reports whether t falls within the period
+func (p ReportPeriod) Contains(t time.Time) bool {
+	if !p.From.IsZero() && t.Before(p.From) {
+		return false
+	}
+	return p.To.IsZero() || t.Before(p.To)
+}
+
+// visitKey identifies a visit within a job
+type visitKey struct {
+	storeID   string
+	visitTime string
+}
+
+// StoreReport aggregates the visits of a tenant's jobs in the period by store,
+// ordered by store ID. Store names and area codes come from the current
+// store master; unknown stores are reported under UnknownAreaCode.
+func StoreReport(tenant string, period ReportPeriod) []StoreReportRow {
+	stores := CurrentStoreMaster()
+	rows := make(map[string]*StoreReportRow)
+
+	for _, job := range SnapshotJobs(tenant) {
+		for key, stats := range jobVisitStats(job, period) {
+			row, exists := rows[key.storeID]
+			if !exists {
+				row = &StoreReportRow{StoreID: key.storeID, AreaCode: UnknownAreaCode}
+				if store, known := stores.Get(key.storeID); known {
+					row.StoreName, row.AreaCode = store.Name, store.AreaCode
+				}
+				rows[key.storeID] = row
+			}
+			row.merge(stats)
+		}
+	}
+
+	report := make([]StoreReportRow, 0, len(rows))
+	for _, row := range rows {
+		report = append(report, *row)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		return report[i].StoreID < report[j].StoreID
+	})
+	return report
+}
+
+// AreaReport aggregates a tenant's store report by area code, ordered by area code
+func AreaReport(tenant string, period ReportPeriod) []AreaReportRow {
+	rows := make(map[string]*AreaReportRow)
+	for _, store := range StoreReport(tenant, period) {
+		row, exists := rows[store.AreaCode]
+		if !exists {
+			row = &AreaReportRow{AreaCode: store.AreaCode}
+			rows[store.AreaCode] = row
+		}
+		row.Stores++
+		row.merge(store.VisitStats)
+	}
+
+	report := make([]AreaReportRow, 0, len(rows))
+	for _, row := range rows {
+		report = append(report, *row)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		return report[i].AreaCode < report[j].AreaCode
+	})
+	return report
+}
+
+// jobVisitStats computes the stats of each visit of a job within the period.
+// Visits whose time cannot be parsed are left out.
+func jobVisitStats(job Job, period ReportPeriod) map[visitKey]VisitStats {
+	stats := make(map[visitKey]VisitStats)
+	for _, visit := range job.Request.Visits {
+		visitTime, err := time.Parse(time.RFC3339, visit.VisitTime)
+		if err != nil || !period.Contains(visitTime) {
+			continue
+		}
+		key := visitKey{visit.StoreID, visit.VisitTime}
+		s := stats[key]
+		s.Visits++
+		s.Images += len(visit.ImageURLs)
+		stats[key] = s
+	}
+
+	for _, result := range job.Results {
+		key := visitKey{result.StoreID, result.VisitTime}
+		s, exists := stats[key]
+		if !exists || result.Status != ImageStatusProcessed {
+			continue
+		}
+		s.ProcessedImages++
+		if perimeter, ok := result.Metrics["perimeter"]; ok {
+			s.addPerimeter(perimeter)
+		}
+		stats[key] = s
+	}
+
+	for _, jobErr := range job.Errors {
+		key := visitKey{jobErr.StoreID, jobErr.VisitTime}
+		if s, exists := stats[key]; exists {
+			s.Failures++
+			stats[key] = s
+		}
+	}
+	return stats
+}
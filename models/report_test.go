@@ -0,0 +1,172 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"backend-intern-assignment/utils"
+)
+
+// createReportJob creates a job with results and errors as the worker would record them
+func createReportJob(visits []Visit, results []ImageResult, errs []JobError) string {
+	jobID := CreateJob(JobRequest{Count: len(visits), Visits: visits})
+	for _, result := range results {
+		StoreImageResult(jobID, result)
+	}
+	for _, jobErr := range errs {
+		AppendJobError(jobID, jobErr)
+	}
+	return jobID
+}
+
+func TestStoreReport(t *testing.T) {
+	InitTestStoreMaster()
+
+	// Report periods in 2031 keep these jobs apart from jobs created by other tests
+	createReportJob(
+		[]Visit{
+			{StoreID: "RP00001", VisitTime: "2031-03-02T10:00:00Z", ImageURLs: []string{"a.jpg", "b.jpg"}},
+			{StoreID: "RP00002", VisitTime: "2031-03-03T10:00:00Z", ImageURLs: []string{"c.jpg"}},
+			{StoreID: "RX99999", VisitTime: "2031-03-03T11:00:00Z", ImageURLs: []string{"d.jpg"}},
+		},
+		[]ImageResult{
+			{StoreID: "RP00001", VisitTime: "2031-03-02T10:00:00Z", ImageURL: "a.jpg", Metrics: utils.Metrics{"perimeter": 100}},
+			{StoreID: "RP00001", VisitTime: "2031-03-02T10:00:00Z", ImageURL: "b.jpg", Metrics: utils.Metrics{"perimeter": 300}},
+		},
+		[]JobError{
+			{StoreID: "RP00002", VisitTime: "2031-03-03T10:00:00Z", Error: "Failed to download image", ImageURL: "c.jpg"},
+			{StoreID: "RX99999", VisitTime: "2031-03-03T11:00:00Z", Error: "Invalid Store ID"},
+		},
+	)
+	createReportJob(
+		[]Visit{
+			{StoreID: "RP00001", VisitTime: "2031-03-04T10:00:00Z", ImageURLs: []string{"e.jpg"}},
+			{StoreID: "RP00001", VisitTime: "2031-04-01T10:00:00Z", ImageURLs: []string{"f.jpg"}},
+		},
+		[]ImageResult{
+			{StoreID: "RP00001", VisitTime: "2031-03-04T10:00:00Z", ImageURL: "e.jpg", Metrics: utils.Metrics{"perimeter": 500}},
+			{StoreID: "RP00001", VisitTime: "2031-04-01T10:00:00Z", ImageURL: "f.jpg", Metrics: utils.Metrics{"perimeter": 9000}},
+		},
+		nil,
+	)
+	march := ReportPeriod{
+		From: time.Date(2031, 3, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2031, 4, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	// Normal case: Visits are aggregated per store across jobs
+	t.Run("AggregatesPerStore", func(t *testing.T) {
+		report := StoreReport("", march)
+		if len(report) != 3 {
+			t.Fatalf("Expected 3 stores, got %d", len(report))
+		}
+		row := report[0]
+		if row.StoreID != "RP00001" || row.StoreName != "B P STORE" || row.AreaCode != "7100015" {
+			t.Errorf("Expected RP00001 joined with the store master, got %+v", row)
+		}
+		if row.Visits != 2 || row.Images != 3 || row.ProcessedImages != 3 || row.Failures != 0 {
+			t.Errorf("Expected 2 visits, 3 images, 3 processed and 0 failures, got %+v", row.VisitStats)
+		}
+		if row.Perimeter == nil || row.Perimeter.Min != 100 || row.Perimeter.Max != 500 || row.Perimeter.Avg != 300 {
+			t.Errorf("Expected perimeter min 100, max 500, avg 300, got %+v", row.Perimeter)
+		}
+	})
+
+	// Normal case: Failed visits count failures and have no perimeter stats
+	t.Run("CountsFailures", func(t *testing.T) {
+		row := StoreReport("", march)[1]
+		if row.StoreID != "RP00002" || row.Failures != 1 || row.ProcessedImages != 0 {
+			t.Errorf("Expected RP00002 with 1 failure and no processed images, got %+v", row)
+		}
+		if row.Perimeter != nil {
+			t.Errorf("Expected no perimeter stats, got %+v", row.Perimeter)
+		}
+	})
+
+	// Edge case: Stores missing from the store master are reported under the unknown area
+	t.Run("UnknownStore", func(t *testing.T) {
+		row := StoreReport("", march)[2]
+		if row.StoreID != "RX99999" || row.AreaCode != UnknownAreaCode || row.StoreName != "" {
+			t.Errorf("Expected RX99999 in area %q, got %+v", UnknownAreaCode, row)
+		}
+	})
+
+	// Edge case: The end of the period is exclusive
+	t.Run("ExclusiveEnd", func(t *testing.T) {
+		april := ReportPeriod{From: march.To, To: march.To.AddDate(0, 1, 0)}
+		report := StoreReport("", april)
+		if len(report) != 1 || report[0].Visits != 1 || report[0].Perimeter.Max != 9000 {
+			t.Errorf("Expected only the April visit, got %+v", report)
+		}
+	})
+
+	// Edge case: A period with no visits yields an empty report
+	t.Run("EmptyPeriod", func(t *testing.T) {
+		report := StoreReport("", ReportPeriod{From: march.From.AddDate(5, 0, 0), To: march.To.AddDate(5, 0, 0)})
+		if len(report) != 0 {
+			t.Errorf("Expected empty report, got %+v", report)
+		}
+	})
+
+	// Edge case: Reports only include the tenant's jobs
+	t.Run("TenantScoped", func(t *testing.T) {
+		jobID := CreateTenantJob("acme", JobRequest{Count: 1, Visits: []Visit{
+			{StoreID: "RP00002", VisitTime: "2031-03-05T10:00:00Z", ImageURLs: []string{"g.jpg"}},
+		}})
+		StoreImageResult(jobID, ImageResult{StoreID: "RP00002", VisitTime: "2031-03-05T10:00:00Z", ImageURL: "g.jpg", Metrics: utils.Metrics{"perimeter": 7}})
+
+		report := StoreReport("acme", march)
+		if len(report) != 1 || report[0].StoreID != "RP00002" || report[0].ProcessedImages != 1 {
+			t.Errorf("Expected only the tenant's visit, got %+v", report)
+		}
+		if row := StoreReport("", march)[1]; row.ProcessedImages != 0 {
+			t.Errorf("Expected the tenant's visit to be left out of other reports, got %+v", row)
+		}
+	})
+}
+
+func TestAreaReport(t *testing.T) {
+	InitTestStoreMaster()
+
+	createReportJob(
+		[]Visit{
+			{StoreID: "RP00001", VisitTime: "2032-05-01T10:00:00Z", ImageURLs: []string{"a.jpg"}},
+			{StoreID: "RP00002", VisitTime: "2032-05-02T10:00:00Z", ImageURLs: []string{"b.jpg"}},
+			{StoreID: "RX99999", VisitTime: "2032-05-02T11:00:00Z", ImageURLs: []string{"c.jpg"}},
+		},
+		[]ImageResult{
+			{StoreID: "RP00001", VisitTime: "2032-05-01T10:00:00Z", ImageURL: "a.jpg", Metrics: utils.Metrics{"perimeter": 200}},
+			{StoreID: "RP00002", VisitTime: "2032-05-02T10:00:00Z", ImageURL: "b.jpg", Metrics: utils.Metrics{"perimeter": 400}},
+		},
+		[]JobError{
+			{StoreID: "RX99999", VisitTime: "2032-05-02T11:00:00Z", Error: "Invalid Store ID"},
+		},
+	)
+	may := ReportPeriod{
+		From: time.Date(2032, 5, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2032, 6, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	// Normal case: Stores are rolled up into their area code
+	t.Run("AggregatesPerArea", func(t *testing.T) {
+		report := AreaReport("", may)
+		if len(report) != 2 {
+			t.Fatalf("Expected 2 areas, got %d", len(report))
+		}
+		row := report[0]
+		if row.AreaCode != "7100015" || row.Stores != 2 || row.Visits != 2 || row.ProcessedImages != 2 {
+			t.Errorf("Expected area 7100015 with 2 stores, 2 visits and 2 processed images, got %+v", row)
+		}
+		if row.Perimeter == nil || row.Perimeter.Count != 2 || row.Perimeter.Min != 200 || row.Perimeter.Max != 400 || row.Perimeter.Avg != 300 {
+			t.Errorf("Expected perimeter min 200, max 400, avg 300, got %+v", row.Perimeter)
+		}
+	})
+
+	// Edge case: Unknown stores are grouped under the unknown area
+	t.Run("UnknownArea", func(t *testing.T) {
+		row := AreaReport("", may)[1]
+		if row.AreaCode != UnknownAreaCode || row.Stores != 1 || row.Failures != 1 {
+			t.Errorf("Expected unknown area with 1 store and 1 failure, got %+v", row)
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Result row statuses for images that have no stored result
+const (
+	ResultStatusFailed  = "failed"
+	ResultStatusPending = "pending"
+)
+
+// ResultRow is one image of a job flattened for export
+type ResultRow struct {
+	JobID     string   `json:"job_id"`
+	StoreID   string   `json:"store_id"`
+	StoreName string   `json:"store_name"`
+	AreaCode  string   `json:"area_code"`
+	VisitTime string   `json:"visit_time"`
+	ImageURL  string   `json:"image_url"`
+	Perimeter *float64 `json:"perimeter"`
+	Status    string   `json:"status"`
+	Error     string   `json:"error"`
+}
+
+// JobIDs returns the IDs of every job of a tenant in ascending order, which
+// is the order they were created in
+func JobIDs(tenant string) []string {
+	jobsMutex.Lock()
+	defer jobsMutex.Unlock()
+
+	ids := make([]string, 0, len(jobs))
+	for id, job := range jobs {
+		if job.Tenant == tenant {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// JobResultRows flattens a job into one row per requested image, in request
+// order. A visit without images yields a single row with no image URL. Errors
+// recorded for the whole visit are repeated on each of its rows.
+func JobResultRows(job Job) []ResultRow {
+	stores := CurrentStoreMaster()
+	var rows []ResultRow
+	for _, visit := range job.Request.Visits {
+		imageURLs := visit.ImageURLs
+		if len(imageURLs) == 0 {
+			imageURLs = []string{""}
+		}
+		for _, imageURL := range imageURLs {
+			row := ResultRow{
+				JobID:     job.ID,
+				StoreID:   visit.StoreID,
+				VisitTime: visit.VisitTime,
+				ImageURL:  imageURL,
+				Status:    ResultStatusPending,
+			}
+			if store, exists := stores.Get(visit.StoreID); exists {
+				row.StoreName, row.AreaCode = store.Name, store.AreaCode
+			}
+			for _, result := range job.Results {
+				if result.StoreID == visit.StoreID && result.VisitTime == visit.VisitTime && result.ImageURL == imageURL {
+					row.Status = result.Status
+					if perimeter, ok := result.Metrics["perimeter"]; ok {
+						row.Perimeter = &perimeter
+					}
+					break
+				}
+			}
+			var errs []string
+			for _, jobErr := range job.Errors {
+				if jobErr.StoreID == visit.StoreID && jobErr.VisitTime == visit.VisitTime &&
+					(jobErr.ImageURL == "" || jobErr.ImageURL == imageURL) {
+					errs = append(errs, jobErr.Error)
+				}
+			}
+			row.Error = strings.Join(errs, "; ")
+			if row.Status == ResultStatusPending && len(errs) > 0 {
+				row.Status = ResultStatusFailed
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// InPeriod reports whether the row's visit time falls within the period.
+// Rows with an unparseable visit time are never in a period.
+func (r ResultRow) InPeriod(period ReportPeriod) bool {
+	visitTime, err := time.Parse(time.RFC3339, r.VisitTime)
+	return err == nil && period.Contains(visitTime)
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"backend-intern-assignment/utils"
+)
+
+func TestJobResultRows(t *testing.T) {
+	InitTestStoreMaster()
+
+	jobID := createReportJob(
+		[]Visit{
+			{StoreID: "RP00001", VisitTime: "2034-01-01T10:00:00Z", ImageURLs: []string{"a.jpg", "b.jpg", "c.jpg"}},
+			{StoreID: "RX99999", VisitTime: "2034-01-02T10:00:00Z", ImageURLs: []string{"d.jpg"}},
+			{StoreID: "RP00002", VisitTime: "2034-01-03T10:00:00Z"},
+		},
+		[]ImageResult{
+			{StoreID: "RP00001", VisitTime: "2034-01-01T10:00:00Z", ImageURL: "a.jpg", Metrics: utils.Metrics{"perimeter": 600}},
+		},
+		[]JobError{
+			{StoreID: "RP00001", VisitTime: "2034-01-01T10:00:00Z", Error: "Failed to download image", ImageURL: "b.jpg"},
+			{StoreID: "RX99999", VisitTime: "2034-01-02T10:00:00Z", Error: "Invalid Store ID"},
+			{StoreID: "RP00002", VisitTime: "2034-01-03T10:00:00Z", Error: "No images provided for processing"},
+		},
+	)
+	job, _ := SnapshotJob(jobID)
+	rows := JobResultRows(job)
+
+	// Normal case: One row per image in request order
+	t.Run("RowPerImage", func(t *testing.T) {
+		if len(rows) != 5 {
+			t.Fatalf("Expected 5 rows, got %d", len(rows))
+		}
+		row := rows[0]
+		if row.JobID != jobID || row.StoreName != "B P STORE" || row.AreaCode != "7100015" || row.ImageURL != "a.jpg" {
+			t.Errorf("Expected a.jpg joined with the store master, got %+v", row)
+		}
+		if row.Status != ImageStatusProcessed || row.Perimeter == nil || *row.Perimeter != 600 || row.Error != "" {
+			t.Errorf("Expected processed row with perimeter 600, got %+v", row)
+		}
+	})
+
+	// Normal case: Image errors only apply to their image
+	t.Run("ImageErrors", func(t *testing.T) {
+		if rows[1].Status != ResultStatusFailed || rows[1].Error != "Failed to download image" {
+			t.Errorf("Expected failed b.jpg, got %+v", rows[1])
+		}
+		if rows[2].Status != ResultStatusPending || rows[2].Error != "" {
+			t.Errorf("Expected pending c.jpg, got %+v", rows[2])
+		}
+	})
+
+	// Edge case: Visit errors apply to every image of the visit
+	t.Run("VisitErrors", func(t *testing.T) {
+		if rows[3].Status != ResultStatusFailed || rows[3].Error != "Invalid Store ID" || rows[3].StoreName != "" {
+			t.Errorf("Expected failed unknown store row, got %+v", rows[3])
+		}
+	})
+
+	// Edge case: A visit without images yields one row without an image URL
+	t.Run("NoImages", func(t *testing.T) {
+		if rows[4].ImageURL != "" || rows[4].Error != "No images provided for processing" {
+			t.Errorf("Expected a row for the empty visit, got %+v", rows[4])
+		}
+	})
+
+	// Normal case: Rows are filtered by visit time
+	t.Run("InPeriod", func(t *testing.T) {
+		period := ReportPeriod{From: time.Date(2034, 1, 2, 0, 0, 0, 0, time.UTC), To: time.Date(2034, 1, 3, 0, 0, 0, 0, time.UTC)}
+		if rows[0].InPeriod(period) || !rows[3].InPeriod(period) || rows[4].InPeriod(period) {
+			t.Errorf("Expected only the 2034-01-02 visit in the period")
+		}
+		if (ResultRow{VisitTime: "yesterday"}).InPeriod(ReportPeriod{}) {
+			t.Errorf("Expected unparseable visit time to be outside every period")
+		}
+	})
+}
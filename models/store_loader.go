@@ -0,0 +1,250 @@
+package models
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Store master load strictness levels
+const (
+	// StrictnessLenient reports problems but loads whatever rows are usable
+	StrictnessLenient = "lenient"
+	// StrictnessStandard fails only when the file yields no stores
+	StrictnessStandard = "standard"
+	// StrictnessStrict fails on any reported problem
+	StrictnessStrict = "strict"
+)
+
+// StoreColumns names the header columns holding each store field
+type StoreColumns struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	AreaCode   string `json:"area_code"`
+	Status     string `json:"status"`
+	ActiveFrom string `json:"active_from"`
+	ActiveTo   string `json:"active_to"`
+}
+
+// StoreLoadOptions controls how the store master CSV is parsed
+type StoreLoadOptions struct {
+	Columns    StoreColumns
+	Strictness string
+}
+
+// DefaultStoreLoadOptions match the layout of StoreMaster.csv
+var DefaultStoreLoadOptions = StoreLoadOptions{
+	Columns: StoreColumns{
+		ID:         "StoreID",
+		Name:       "StoreName",
+		AreaCode:   "AreaCode",
+		Status:     "Status",
+		ActiveFrom: "ActiveFrom",
+		ActiveTo:   "ActiveTo",
+	},
+	Strictness: StrictnessStandard,
+}
+
+// LineIssue describes a problem with one line of the store master
+type LineIssue struct {
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+}
+
+// DuplicateStore records a store ID that appeared more than once. The last occurrence is kept.
+type DuplicateStore struct {
+	StoreID string `json:"store_id"`
+	Lines   []int  `json:"lines"`
+}
+
+// StoreLoadReport summarizes a store master load
+type StoreLoadReport struct {
+	Loaded         int              `json:"loaded"`
+	MissingColumns []string         `json:"missing_columns,omitempty"`
+	DuplicateIDs   []DuplicateStore `json:"duplicate_ids,omitempty"`
+	BlankRows      []int            `json:"blank_rows,omitempty"`
+	MalformedLines []LineIssue      `json:"malformed_lines,omitempty"`
+}
+
+// HasIssues reports whether anything other than clean rows was found
+func (r *StoreLoadReport) HasIssues() bool {
+	return len(r.MissingColumns) > 0 || len(r.DuplicateIDs) > 0 || len(r.BlankRows) > 0 || len(r.MalformedLines) > 0
+}
+
+// String returns a one-line summary suitable for logs
+func (r *StoreLoadReport) String() string {
+	return fmt.Sprintf("%d stores loaded, %d missing columns, %d duplicate IDs, %d blank rows, %d malformed lines",
+		r.Loaded, len(r.MissingColumns), len(r.DuplicateIDs), len(r.BlankRows), len(r.MalformedLines))
+}
+
+// ParseStoreMaster reads stores from CSV, locating columns by header name.
+// The ID column is required; a missing name or area code column is reported.
+// The status and active date columns are optional; stores without them are active.
+// The returned error reflects opts.Strictness, and the report is always returned.
+func ParseStoreMaster(r io.Reader, opts StoreLoadOptions) ([]Store, *StoreLoadReport, error) {
+	report := &StoreLoadReport{}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, report, errors.New("store master is empty")
+	}
+	if err != nil {
+		return nil, report, fmt.Errorf("reading store master header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.TrimPrefix(name, "\ufeff")
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	column := func(name string) int {
+		if i, exists := columns[strings.ToLower(name)]; exists {
+			return i
+		}
+		return -1
+	}
+
+	idCol, nameCol, areaCol := column(opts.Columns.ID), column(opts.Columns.Name), column(opts.Columns.AreaCode)
+	optionalColumn := func(name string) int {
+		if name == "" {
+			return -1
+		}
+		return column(name)
+	}
+	statusCol := optionalColumn(opts.Columns.Status)
+	fromCol, toCol := optionalColumn(opts.Columns.ActiveFrom), optionalColumn(opts.Columns.ActiveTo)
+	if idCol < 0 {
+		return nil, report, fmt.Errorf("store master has no %q column", opts.Columns.ID)
+	}
+	if nameCol < 0 {
+		report.MissingColumns = append(report.MissingColumns, opts.Columns.Name)
+	}
+	if areaCol < 0 {
+		report.MissingColumns = append(report.MissingColumns, opts.Columns.AreaCode)
+	}
+
+	var stores []Store
+	seen := make(map[string]int)
+	duplicates := make(map[string]int)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			report.MalformedLines = append(report.MalformedLines, LineIssue{Line: parseErr.Line, Reason: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, report, fmt.Errorf("reading store master: %w", err)
+		}
+		// FieldPos is only valid after a successful Read
+		line, _ := reader.FieldPos(0)
+
+		if isBlankRecord(record) {
+			report.BlankRows = append(report.BlankRows, line)
+			continue
+		}
+		if idCol >= len(record) {
+			report.MalformedLines = append(report.MalformedLines, LineIssue{
+				Line:   line,
+				Reason: fmt.Sprintf("expected at least %d fields, got %d", idCol+1, len(record)),
+			})
+			continue
+		}
+
+		store := Store{ID: strings.TrimSpace(record[idCol])}
+		if store.ID == "" {
+			report.MalformedLines = append(report.MalformedLines, LineIssue{Line: line, Reason: "missing store ID"})
+			continue
+		}
+		if nameCol >= 0 && nameCol < len(record) {
+			store.Name = strings.TrimSpace(record[nameCol])
+		}
+		if areaCol >= 0 && areaCol < len(record) {
+			store.AreaCode = strings.TrimSpace(record[areaCol])
+		}
+		if statusCol >= 0 && statusCol < len(record) {
+			store.Status = strings.ToLower(strings.TrimSpace(record[statusCol]))
+			if store.Status != "" && store.Status != StoreStatusActive && store.Status != StoreStatusInactive {
+				report.MalformedLines = append(report.MalformedLines, LineIssue{Line: line, Reason: fmt.Sprintf("unknown status %q", store.Status)})
+				continue
+			}
+		}
+		if store.ActiveFrom, err = parseStoreDate(record, fromCol); err != nil {
+			report.MalformedLines = append(report.MalformedLines, LineIssue{Line: line, Reason: "invalid active from date: " + err.Error()})
+			continue
+		}
+		if store.ActiveTo, err = parseStoreDate(record, toCol); err != nil {
+			report.MalformedLines = append(report.MalformedLines, LineIssue{Line: line, Reason: "invalid active to date: " + err.Error()})
+			continue
+		}
+		for i, value := range record {
+			if i == idCol || i == nameCol || i == areaCol || i == statusCol || i == fromCol || i == toCol || i >= len(header) {
+				continue
+			}
+			if store.Extra == nil {
+				store.Extra = make(map[string]string)
+			}
+			store.Extra[strings.TrimSpace(header[i])] = value
+		}
+
+		if first, exists := seen[store.ID]; exists {
+			if _, reported := duplicates[store.ID]; !reported {
+				duplicates[store.ID] = len(report.DuplicateIDs)
+				report.DuplicateIDs = append(report.DuplicateIDs, DuplicateStore{StoreID: store.ID, Lines: []int{first}})
+			}
+			dup := &report.DuplicateIDs[duplicates[store.ID]]
+			dup.Lines = append(dup.Lines, line)
+		} else {
+			seen[store.ID] = line
+		}
+		stores = append(stores, store)
+	}
+
+	report.Loaded = len(seen)
+	switch opts.Strictness {
+	case StrictnessLenient:
+	case StrictnessStrict:
+		if report.HasIssues() {
+			return nil, report, fmt.Errorf("store master has problems: %s", report)
+		}
+	default:
+		if report.Loaded == 0 {
+			return nil, report, errors.New("store master contains no valid stores")
+		}
+	}
+	return stores, report, nil
+}
+
+// parseStoreDate reads an optional date column as either a date (midnight UTC) or an RFC 3339 timestamp
+func parseStoreDate(record []string, col int) (*time.Time, error) {
+	if col < 0 || col >= len(record) || strings.TrimSpace(record[col]) == "" {
+		return nil, nil
+	}
+	value := strings.TrimSpace(record[col])
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		if t, err = time.Parse(time.RFC3339, value); err != nil {
+			return nil, fmt.Errorf("%q is not a date or RFC 3339 timestamp", value)
+		}
+	}
+	return &t, nil
+}
+
+func isBlankRecord(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
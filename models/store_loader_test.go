@@ -0,0 +1,135 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseStoreMaster(t *testing.T) {
+	// Normal case: Columns are located by header name in any order
+	t.Run("HeaderMapping", func(t *testing.T) {
+		csv := "StoreID,Region,StoreName,AreaCode\nS1,East,First,100\nS2,West,Second,200\n"
+		stores, report, err := ParseStoreMaster(strings.NewReader(csv), DefaultStoreLoadOptions)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if report.Loaded != 2 || report.HasIssues() {
+			t.Errorf("Expected 2 clean stores, got %s", report)
+		}
+		if stores[1].ID != "S2" || stores[1].Name != "Second" || stores[1].AreaCode != "200" {
+			t.Errorf("Unexpected store: %+v", stores[1])
+		}
+		if stores[0].Extra["Region"] != "East" {
+			t.Errorf("Expected extra column Region, got %v", stores[0].Extra)
+		}
+	})
+
+	// Normal case: Custom column names
+	t.Run("CustomColumns", func(t *testing.T) {
+		opts := StoreLoadOptions{Columns: StoreColumns{ID: "code", Name: "title", AreaCode: "pin"}}
+		stores, _, err := ParseStoreMaster(strings.NewReader("pin,title,code\n1,Shop,X1\n"), opts)
+		if err != nil || len(stores) != 1 || stores[0].ID != "X1" {
+			t.Errorf("Expected store X1, got %v (err %v)", stores, err)
+		}
+	})
+
+	// Normal case: Status and active window columns
+	t.Run("ActiveWindow", func(t *testing.T) {
+		csv := "AreaCode,StoreName,StoreID,Status,ActiveFrom,ActiveTo\n1,A,S1,active,2023-01-01,\n1,B,S2,inactive,,2023-09-01T12:00:00Z\n1,C,S3,active,yesterday,\n"
+		stores, report, err := ParseStoreMaster(strings.NewReader(csv), DefaultStoreLoadOptions)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(stores) != 2 || len(report.MalformedLines) != 1 {
+			t.Fatalf("Expected 2 stores and 1 malformed line, got %d and %v", len(stores), report.MalformedLines)
+		}
+		if stores[0].ActiveFrom == nil || !stores[0].ActiveFrom.Equal(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("Expected S1 active from 2023-01-01, got %v", stores[0].ActiveFrom)
+		}
+		if stores[1].Status != StoreStatusInactive || stores[1].ActiveTo == nil || stores[1].Extra != nil {
+			t.Errorf("Expected inactive S2 with an end date and no extras, got %+v", stores[1])
+		}
+	})
+
+	// Edge case: Problems are reported with line numbers
+	t.Run("Report", func(t *testing.T) {
+		csv := "AreaCode,StoreName,StoreID\n1,A,S1\n,,\n1,Short\n1,B,S1\n1,C,\n1,\"bad,S3\n"
+		stores, report, err := ParseStoreMaster(strings.NewReader(csv), DefaultStoreLoadOptions)
+		if err != nil {
+			t.Fatalf("Expected no error in standard mode, got %v", err)
+		}
+		if report.Loaded != 1 || len(stores) != 2 {
+			t.Errorf("Expected 1 unique store from 2 rows, got %d from %d", report.Loaded, len(stores))
+		}
+		if len(report.BlankRows) != 1 || report.BlankRows[0] != 3 {
+			t.Errorf("Expected blank row on line 3, got %v", report.BlankRows)
+		}
+		if len(report.DuplicateIDs) != 1 || len(report.DuplicateIDs[0].Lines) != 2 || report.DuplicateIDs[0].Lines[1] != 5 {
+			t.Errorf("Expected S1 duplicated on lines 2 and 5, got %v", report.DuplicateIDs)
+		}
+		if len(report.MalformedLines) != 3 {
+			t.Errorf("Expected 3 malformed lines, got %v", report.MalformedLines)
+		}
+	})
+
+	// Edge case: Bare and unterminated quotes are counted as malformed, not a crash
+	t.Run("QuoteErrors", func(t *testing.T) {
+		for _, csv := range []string{
+			"AreaCode,StoreName,StoreID\n1,\"bad,S1\n",
+			"AreaCode,StoreName,StoreID\n1,B\"ad,S1\n1,C,S2\n",
+			"AreaCode,StoreName,StoreID\n\"\n",
+		} {
+			_, report, _ := ParseStoreMaster(strings.NewReader(csv), DefaultStoreLoadOptions)
+			if len(report.MalformedLines) != 1 || report.MalformedLines[0].Line != 2 {
+				t.Errorf("Expected a malformed line 2 for %q, got %v", csv, report.MalformedLines)
+			}
+		}
+	})
+
+	// Edge case: Header only
+	t.Run("HeaderOnly", func(t *testing.T) {
+		_, _, err := ParseStoreMaster(strings.NewReader("AreaCode,StoreName,StoreID\n"), DefaultStoreLoadOptions)
+		if err == nil {
+			t.Errorf("Expected an error for a file with no stores")
+		}
+		opts := DefaultStoreLoadOptions
+		opts.Strictness = StrictnessLenient
+		if _, _, err := ParseStoreMaster(strings.NewReader("AreaCode,StoreName,StoreID\n"), opts); err != nil {
+			t.Errorf("Expected lenient mode to accept an empty file, got %v", err)
+		}
+	})
+
+	// Edge case: Empty file and missing ID column always fail
+	t.Run("Unusable", func(t *testing.T) {
+		if _, _, err := ParseStoreMaster(strings.NewReader(""), DefaultStoreLoadOptions); err == nil {
+			t.Errorf("Expected an error for an empty file")
+		}
+		if _, _, err := ParseStoreMaster(strings.NewReader("AreaCode,StoreName\n1,A\n"), DefaultStoreLoadOptions); err == nil {
+			t.Errorf("Expected an error for a missing ID column")
+		}
+	})
+
+	// Edge case: Strict mode fails on any problem
+	t.Run("Strict", func(t *testing.T) {
+		opts := DefaultStoreLoadOptions
+		opts.Strictness = StrictnessStrict
+		_, report, err := ParseStoreMaster(strings.NewReader("AreaCode,StoreName,StoreID\n1,A,S1\n1,B,S1\n"), opts)
+		if err == nil {
+			t.Errorf("Expected an error for duplicates in strict mode")
+		}
+		if report == nil || len(report.DuplicateIDs) != 1 {
+			t.Errorf("Expected the report to be returned with the error, got %v", report)
+		}
+	})
+}
+
+func TestLoadStoreMasterMissingFile(t *testing.T) {
+	InitTestStoreMaster()
+	if _, err := LoadStoreMaster("does-not-exist.csv"); err == nil {
+		t.Errorf("Expected an error for a missing file")
+	}
+	if !IsValidStore("RP00001") {
+		t.Errorf("Expected the previous store master to be kept after a failed load")
+	}
+}
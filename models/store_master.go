@@ -1,39 +1,233 @@
 package models
 
 import (
-	"encoding/csv"
-	"os"
+	"sort"
+	"sync/atomic"
+	"time"
 )
 
-var storeMaster = map[string]bool{}
+// Store statuses
+const (
+	StoreStatusActive   = "active"
+	StoreStatusInactive = "inactive"
+)
+
+// Store is a row of the store master
+type Store struct {
+	ID         string            `json:"store_id"`
+	Name       string            `json:"store_name"`
+	AreaCode   string            `json:"area_code"`
+	Status     string            `json:"status"`
+	ActiveFrom *time.Time        `json:"active_from,omitempty"`
+	ActiveTo   *time.Time        `json:"active_to,omitempty"`
+	Extra      map[string]string `json:"extra,omitempty"`
+}
+
+// ActiveAt reports whether the store accepted visits at t. A store is active
+// from ActiveFrom (inclusive) until ActiveTo (exclusive); either bound may be
+// unset. An inactive store without an ActiveTo date never accepts visits.
+func (s Store) ActiveAt(t time.Time) bool {
+	if s.ActiveFrom != nil && t.Before(*s.ActiveFrom) {
+		return false
+	}
+	if s.ActiveTo != nil {
+		return t.Before(*s.ActiveTo)
+	}
+	return s.Status != StoreStatusInactive
+}
+
+// ActiveDuring reports whether the store accepted visits at any time from
+// "from" (inclusive) until "to" (exclusive)
+func (s Store) ActiveDuring(from, to time.Time) bool {
+	if s.ActiveFrom != nil && !s.ActiveFrom.Before(to) {
+		return false
+	}
+	if s.ActiveTo != nil {
+		return s.ActiveTo.After(from)
+	}
+	return s.Status != StoreStatusInactive
+}
+
+// AlwaysActive reports whether the store accepts visits at any time, so
+// visits to it need no visit time to be validated
+func (s Store) AlwaysActive() bool {
+	return s.ActiveFrom == nil && s.ActiveTo == nil && s.Status != StoreStatusInactive
+}
+
+// Active reports whether the store accepts visits now
+func (s Store) Active() bool {
+	return s.ActiveAt(time.Now())
+}
+
+// StoreMasterInfo identifies a loaded copy of the store master. VersionID
+// combines the content checksum and load time, so it is unique per load.
+type StoreMasterInfo struct {
+	VersionID string    `json:"version_id"`
+	Version   int64     `json:"version"`
+	Checksum  string    `json:"checksum"`
+	LoadedAt  time.Time `json:"loaded_at"`
+	Path      string    `json:"path"`
+	Stores    int       `json:"stores"`
+}
+
+// StoreRegistry indexes stores by ID and by area code. A registry is never
+// modified after it is built; reloads swap in a new registry.
+type StoreRegistry struct {
+	byID   map[string]*Store
+	byArea map[string][]*Store
+	sorted []*Store
+	info   StoreMasterInfo
+}
+
+// NewStoreRegistry builds a registry from a list of stores. Later entries
+// replace earlier ones with the same ID.
+func NewStoreRegistry(stores []Store) *StoreRegistry {
+	registry := &StoreRegistry{
+		byID:   make(map[string]*Store, len(stores)),
+		byArea: make(map[string][]*Store),
+	}
+	for i := range stores {
+		store := &stores[i]
+		if store.Status == "" {
+			store.Status = StoreStatusActive
+		}
+		if previous, exists := registry.byID[store.ID]; exists {
+			registry.removeFromArea(previous)
+		}
+		registry.byID[store.ID] = store
+		registry.byArea[store.AreaCode] = append(registry.byArea[store.AreaCode], store)
+	}
+	registry.sorted = make([]*Store, 0, len(registry.byID))
+	for _, store := range registry.byID {
+		registry.sorted = append(registry.sorted, store)
+	}
+	sort.Slice(registry.sorted, func(i, j int) bool {
+		return registry.sorted[i].ID < registry.sorted[j].ID
+	})
+	registry.info.Stores = len(registry.byID)
+	return registry
+}
+
+func (r *StoreRegistry) removeFromArea(store *Store) {
+	stores := r.byArea[store.AreaCode]
+	for i, s := range stores {
+		if s == store {
+			r.byArea[store.AreaCode] = append(stores[:i:i], stores[i+1:]...)
+			return
+		}
+	}
+}
 
-// LoadStoreMaster preloads StoreMaster.csv
-func LoadStoreMaster(filePath string) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		panic(err)
+// Get looks up a store by ID
+func (r *StoreRegistry) Get(storeID string) (Store, bool) {
+	store, exists := r.byID[storeID]
+	if !exists {
+		return Store{}, false
 	}
-	defer file.Close()
+	return *store, true
+}
 
-	reader := csv.NewReader(file)
-	records, err := reader.ReadAll()
-	if err != nil {
-		panic(err)
+// ByAreaCode returns the stores in an area code
+func (r *StoreRegistry) ByAreaCode(areaCode string) []Store {
+	stores := make([]Store, 0, len(r.byArea[areaCode]))
+	for _, store := range r.byArea[areaCode] {
+		stores = append(stores, *store)
 	}
-	for _, record := range records[1:] { // Skip header row
-		storeID := record[2]
-		storeMaster[storeID] = true
+	return stores
+}
+
+// All returns every store ordered by ID
+func (r *StoreRegistry) All() []Store {
+	stores := make([]Store, 0, len(r.sorted))
+	for _, store := range r.sorted {
+		stores = append(stores, *store)
 	}
+	return stores
+}
+
+// Info returns the version details of the registry
+func (r *StoreRegistry) Info() StoreMasterInfo {
+	return r.info
+}
+
+// IsValid checks if a store ID exists in this registry and is active
+func (r *StoreRegistry) IsValid(storeID string) bool {
+	store, exists := r.Get(storeID)
+	return exists && store.Active()
+}
+
+// Len returns the number of stores in the registry
+func (r *StoreRegistry) Len() int {
+	return len(r.byID)
+}
+
+var storeMaster atomic.Pointer[StoreRegistry]
+
+func init() {
+	storeMaster.Store(NewStoreRegistry(nil))
+}
+
+// activeStores returns the current registry. Callers see either the old or the
+// new registry during a reload, never a partially loaded one.
+func activeStores() *StoreRegistry {
+	return storeMaster.Load()
 }
 
-// IsValidStore checks if a store ID exists in the master list
+// CurrentStoreMaster returns the active registry. Holding on to it gives a
+// consistent view of the store master even if it is reloaded meanwhile.
+func CurrentStoreMaster() *StoreRegistry {
+	return activeStores()
+}
+
+// StoreMasterStatus returns the version and checksum of the active store master
+func StoreMasterStatus() StoreMasterInfo {
+	return activeStores().info
+}
+
+// LoadStoreMaster preloads StoreMaster.csv using the default column mapping and strictness
+func LoadStoreMaster(filePath string) (*StoreLoadReport, error) {
+	return LoadStoreMasterWithOptions(filePath, DefaultStoreLoadOptions)
+}
+
+// IsValidStore checks if a store ID exists in the master list and is active
 func IsValidStore(storeID string) bool {
-	return storeMaster[storeID]
+	return activeStores().IsValid(storeID)
+}
+
+// LookupStore returns the store master entry for a store ID
+func LookupStore(storeID string) (Store, bool) {
+	return activeStores().Get(storeID)
+}
+
+// StoresByAreaCode returns the store master entries for an area code
+func StoresByAreaCode(areaCode string) []Store {
+	return activeStores().ByAreaCode(areaCode)
 }
 
 func InitTestStoreMaster() {
-	storeMaster = map[string]bool{
-		"RP00001": true,
-		"RP00002": true,
+	registry := NewStoreRegistry([]Store{
+		{ID: "RP00001", Name: "B P STORE", AreaCode: "7100015"},
+		{ID: "RP00002", Name: "MONAJ STORE", AreaCode: "7100015"},
+	})
+	registry.info.VersionID = "test"
+	storeMaster.Store(registry)
+}
+
+// UpdateTestStore replaces a single store in the active registry without touching any file
+func UpdateTestStore(store Store) {
+	current := activeStores()
+	stores := current.All()
+	replaced := false
+	for i := range stores {
+		if stores[i].ID == store.ID {
+			stores[i], replaced = store, true
+		}
+	}
+	if !replaced {
+		stores = append(stores, store)
 	}
+	registry := NewStoreRegistry(stores)
+	registry.info = current.info
+	registry.info.Stores = registry.Len()
+	storeMaster.Store(registry)
 }
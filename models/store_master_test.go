@@ -2,12 +2,19 @@ package models
 
 import (
 	"testing"
+	"time"
 )
 
 func TestLoadStoreMaster(t *testing.T) {
 	// Assuming StoreMaster.csv is in the correct location
-	LoadStoreMaster("../StoreMaster.csv")
-	if len(storeMaster) == 0 {
+	report, err := LoadStoreMaster("../StoreMaster.csv")
+	if err != nil {
+		t.Fatalf("Expected no error loading StoreMaster.csv, got %v", err)
+	}
+	if report.Loaded != activeStores().Len() {
+		t.Errorf("Expected report to count %d stores, got %d", activeStores().Len(), report.Loaded)
+	}
+	if activeStores().Len() == 0 {
 		t.Errorf("Expected storeMaster to be populated")
 	}
 }
@@ -23,3 +30,118 @@ func TestIsValidStore(t *testing.T) {
 		t.Errorf("Expected 'INVALID_ID' to be invalid")
 	}
 }
+
+func TestLookupStore(t *testing.T) {
+	LoadStoreMaster("../StoreMaster.csv")
+
+	// Normal case: Store details are kept from the CSV
+	t.Run("ByID", func(t *testing.T) {
+		store, exists := LookupStore("RP00001")
+		if !exists {
+			t.Fatalf("Expected 'RP00001' to exist")
+		}
+		if store.Name != "B P STORE" || store.AreaCode != "7100015" {
+			t.Errorf("Unexpected store: %+v", store)
+		}
+	})
+
+	// Normal case: Stores are indexed by area code
+	t.Run("ByAreaCode", func(t *testing.T) {
+		stores := StoresByAreaCode("7100015")
+		if len(stores) == 0 {
+			t.Fatalf("Expected stores in area 7100015")
+		}
+		for _, store := range stores {
+			if store.AreaCode != "7100015" {
+				t.Errorf("Expected area code 7100015, got %s", store.AreaCode)
+			}
+		}
+	})
+
+	// Edge case: Unknown store and area
+	t.Run("Unknown", func(t *testing.T) {
+		if _, exists := LookupStore("INVALID_ID"); exists {
+			t.Errorf("Expected 'INVALID_ID' not to exist")
+		}
+		if stores := StoresByAreaCode("0000000"); len(stores) != 0 {
+			t.Errorf("Expected no stores for an unknown area, got %d", len(stores))
+		}
+	})
+}
+
+func TestNewStoreRegistry(t *testing.T) {
+	// Edge case: Duplicate IDs keep the last entry and move it between areas
+	registry := NewStoreRegistry([]Store{
+		{ID: "S1", Name: "Old", AreaCode: "A"},
+		{ID: "S1", Name: "New", AreaCode: "B"},
+	})
+	if registry.Len() != 1 {
+		t.Errorf("Expected 1 store, got %d", registry.Len())
+	}
+	if store, _ := registry.Get("S1"); store.Name != "New" {
+		t.Errorf("Expected the later entry to win, got %s", store.Name)
+	}
+	if len(registry.ByAreaCode("A")) != 0 || len(registry.ByAreaCode("B")) != 1 {
+		t.Errorf("Expected the store to be indexed only under its latest area")
+	}
+}
+
+func TestStoreActiveAt(t *testing.T) {
+	opened := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	closed := time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	// Normal case: Window bounds are inclusive of the start and exclusive of the end
+	windowed := Store{ID: "S1", Status: StoreStatusInactive, ActiveFrom: &opened, ActiveTo: &closed}
+	cases := []struct {
+		at       time.Time
+		expected bool
+	}{
+		{opened.Add(-time.Second), false},
+		{opened, true},
+		{closed.Add(-time.Second), true},
+		{closed, false},
+	}
+	for _, c := range cases {
+		if got := windowed.ActiveAt(c.at); got != c.expected {
+			t.Errorf("ActiveAt(%s) = %v, expected %v", c.at, got, c.expected)
+		}
+	}
+
+	// Edge case: Status alone decides when there is no end date
+	if !(Store{Status: StoreStatusActive}).ActiveAt(closed) {
+		t.Errorf("Expected an active store without dates to be active")
+	}
+	if (Store{Status: StoreStatusInactive}).ActiveAt(opened) {
+		t.Errorf("Expected an inactive store without dates to be inactive")
+	}
+}
+
+func TestStoreActiveDuring(t *testing.T) {
+	opened := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	closed := time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC)
+	windowed := Store{ID: "S1", Status: StoreStatusInactive, ActiveFrom: &opened, ActiveTo: &closed}
+
+	// Normal case: Periods overlapping the window count, touching ones do not
+	cases := []struct {
+		from, to time.Time
+		expected bool
+	}{
+		{opened.AddDate(0, -1, 0), opened, false},
+		{opened.AddDate(0, -1, 0), opened.Add(time.Second), true},
+		{closed.Add(-time.Second), closed.AddDate(0, 1, 0), true},
+		{closed, closed.AddDate(0, 1, 0), false},
+	}
+	for _, c := range cases {
+		if got := windowed.ActiveDuring(c.from, c.to); got != c.expected {
+			t.Errorf("ActiveDuring(%s, %s) = %v, expected %v", c.from, c.to, got, c.expected)
+		}
+	}
+
+	// Edge case: Status alone decides when there is no end date
+	if !(Store{Status: StoreStatusActive}).ActiveDuring(opened, closed) {
+		t.Errorf("Expected an active store without dates to be active")
+	}
+	if (Store{Status: StoreStatusInactive}).ActiveDuring(opened, closed) {
+		t.Errorf("Expected an inactive store without dates to be inactive")
+	}
+}
@@ -0,0 +1,182 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// MaxStoreMasterVersions is how many past store master versions are kept for
+// diffs. Versions a job was validated against are never dropped, so the
+// history only grows past it when more versions than that are in use.
+var MaxStoreMasterVersions = 20
+
+var (
+	// reloadMutex serializes loads so versions are assigned in order
+	reloadMutex   sync.Mutex
+	storeVersion  int64
+	storeFilePath string
+	storeOptions  = DefaultStoreLoadOptions
+	// storeReport is the report of the load that produced the active registry
+	storeReport *StoreLoadReport
+
+	// storeHistory holds the most recent registries, oldest first
+	storeHistory      []*StoreRegistry
+	storeHistoryMutex sync.RWMutex
+	// storeVersionsInUse holds the version IDs jobs were validated against
+	storeVersionsInUse = make(map[string]bool)
+)
+
+// LoadStoreMasterWithOptions parses a store master file and atomically
+// replaces the active registry. The registry is left unchanged when an error
+// is returned. Later reloads use the same path and options.
+func LoadStoreMasterWithOptions(filePath string, opts StoreLoadOptions) (*StoreLoadReport, error) {
+	reloadMutex.Lock()
+	defer reloadMutex.Unlock()
+
+	storeFilePath, storeOptions = filePath, opts
+	_, report, err := loadStoreMasterLocked(true)
+	return report, err
+}
+
+// ReloadStoreMaster re-reads the store master from the path it was last loaded
+// from. Unless force is set, the registry is only replaced when the file's
+// checksum has changed. It reports whether a new registry was swapped in.
+func ReloadStoreMaster(force bool) (bool, *StoreLoadReport, error) {
+	reloadMutex.Lock()
+	defer reloadMutex.Unlock()
+
+	if storeFilePath == "" {
+		return false, nil, errors.New("store master has not been loaded")
+	}
+	return loadStoreMasterLocked(force)
+}
+
+func loadStoreMasterLocked(force bool) (bool, *StoreLoadReport, error) {
+	data, err := os.ReadFile(storeFilePath)
+	if err != nil {
+		storeMasterReloads.Inc("failure")
+		return false, &StoreLoadReport{}, err
+	}
+
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+	if !force && checksum == StoreMasterStatus().Checksum {
+		return false, nil, nil
+	}
+
+	stores, report, err := parseStoreMasterData(data, storeOptions)
+	if err != nil {
+		storeMasterReloads.Inc("failure")
+		return false, report, err
+	}
+
+	swapStoreRegistry(stores, checksum)
+	storeReport = report
+	storeMasterReloads.Inc("success")
+	return true, report, nil
+}
+
+// parseStoreMasterData parses a store master file, turning a panic in the
+// parser into an error so a bad file cannot take the server down from a
+// background reload
+func parseStoreMasterData(data []byte, opts StoreLoadOptions) (stores []Store, report *StoreLoadReport, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			stores, report, err = nil, &StoreLoadReport{}, fmt.Errorf("parsing store master: %v", r)
+		}
+	}()
+	return ParseStoreMaster(bytes.NewReader(data), opts)
+}
+
+// swapStoreRegistry builds the next version of the registry and makes it
+// active. The caller must hold reloadMutex.
+func swapStoreRegistry(stores []Store, checksum string) {
+	registry := NewStoreRegistry(stores)
+	storeVersion++
+	registry.info.Version = storeVersion
+	registry.info.Checksum = checksum
+	registry.info.LoadedAt = time.Now().UTC().Truncate(time.Millisecond)
+	// Version IDs carry the load time to the millisecond; keep it increasing so
+	// loads of the same file within one millisecond still get distinct IDs
+	if previous := activeStores().info.LoadedAt; !registry.info.LoadedAt.After(previous) {
+		registry.info.LoadedAt = previous.Add(time.Millisecond)
+	}
+	registry.info.Path = storeFilePath
+	registry.info.VersionID = fmt.Sprintf("%s-%s", checksum[:12], registry.info.LoadedAt.Format("20060102T150405.000Z"))
+
+	storeHistoryMutex.Lock()
+	storeHistory = append(storeHistory, registry)
+	trimStoreHistory()
+	storeHistoryMutex.Unlock()
+
+	storeMaster.Store(registry)
+}
+
+// trimStoreHistory drops the oldest versions beyond MaxStoreMasterVersions,
+// skipping versions in use by jobs and the active version. The caller must
+// hold storeHistoryMutex.
+func trimStoreHistory() {
+	excess := len(storeHistory) - MaxStoreMasterVersions
+	kept := storeHistory[:0]
+	for i, registry := range storeHistory {
+		if excess > 0 && i < len(storeHistory)-1 && !storeVersionsInUse[registry.info.VersionID] {
+			excess--
+			continue
+		}
+		kept = append(kept, registry)
+	}
+	clear(storeHistory[len(kept):])
+	storeHistory = kept
+}
+
+// WatchStoreMaster polls the store master file every interval and reloads it
+// when its modification time or size changes, until ctx is cancelled. Failed
+// reloads are logged and the previous registry stays active.
+func WatchStoreMaster(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastModTime time.Time
+	var lastSize int64 = -1
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		reloadMutex.Lock()
+		path := storeFilePath
+		reloadMutex.Unlock()
+		if path == "" {
+			continue
+		}
+
+		stat, err := os.Stat(path)
+		if err != nil {
+			slog.Warn("Store master watch failed", "path", path, "error", err)
+			continue
+		}
+		if stat.ModTime().Equal(lastModTime) && stat.Size() == lastSize {
+			continue
+		}
+		lastModTime, lastSize = stat.ModTime(), stat.Size()
+
+		changed, report, err := ReloadStoreMaster(false)
+		if err != nil {
+			slog.Error("Store master reload failed", "version", StoreMasterStatus().Version, "error", err)
+			continue
+		}
+		if changed {
+			slog.Info("Store master reloaded", "version", StoreMasterStatus().Version, "report", report.String())
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package models
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func writeStoreMaster(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("Failed to write store master: %v", err)
+	}
+}
+
+func TestReloadStoreMaster(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stores.csv")
+	writeStoreMaster(t, path, "AreaCode,StoreName,StoreID\n1,A,S1\n")
+	if _, err := LoadStoreMaster(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	first := StoreMasterStatus()
+	if first.Checksum == "" || first.Stores != 1 {
+		t.Errorf("Expected checksum and store count, got %+v", first)
+	}
+
+	// Normal case: Unchanged file is not reloaded
+	t.Run("Unchanged", func(t *testing.T) {
+		changed, _, err := ReloadStoreMaster(false)
+		if err != nil || changed {
+			t.Errorf("Expected no reload for an unchanged file, got changed=%v err=%v", changed, err)
+		}
+		if StoreMasterStatus().Version != first.Version {
+			t.Errorf("Expected version to stay at %d", first.Version)
+		}
+	})
+
+	// Normal case: Changed file swaps in a new version
+	t.Run("Changed", func(t *testing.T) {
+		writeStoreMaster(t, path, "AreaCode,StoreName,StoreID\n1,A,S1\n2,B,S2\n")
+		changed, report, err := ReloadStoreMaster(false)
+		if err != nil || !changed {
+			t.Fatalf("Expected a reload, got changed=%v err=%v", changed, err)
+		}
+		if report.Loaded != 2 || !IsValidStore("S2") {
+			t.Errorf("Expected S2 to be loaded, got %s", report)
+		}
+		status := StoreMasterStatus()
+		if status.Version <= first.Version || status.Checksum == first.Checksum {
+			t.Errorf("Expected a new version and checksum, got %+v", status)
+		}
+	})
+
+	// Edge case: Broken file keeps the previous registry
+	t.Run("BrokenFile", func(t *testing.T) {
+		before := StoreMasterStatus()
+		writeStoreMaster(t, path, "AreaCode,StoreName\n1,A\n")
+		if _, _, err := ReloadStoreMaster(false); err == nil {
+			t.Errorf("Expected an error for a file without an ID column")
+		}
+		if StoreMasterStatus() != before || !IsValidStore("S2") {
+			t.Errorf("Expected the previous store master to stay active")
+		}
+	})
+
+	// Edge case: A file with only unparseable quoting keeps the previous registry
+	t.Run("MalformedQuotes", func(t *testing.T) {
+		before := StoreMasterStatus()
+		writeStoreMaster(t, path, "AreaCode,StoreName,StoreID\n1,\"bad,S9\n")
+		if _, _, err := ReloadStoreMaster(true); err == nil {
+			t.Errorf("Expected an error for a file without valid rows")
+		}
+		if StoreMasterStatus() != before || !IsValidStore("S2") || IsValidStore("S9") {
+			t.Errorf("Expected the previous store master to stay active")
+		}
+	})
+}
+
+func TestReloadIsAtomic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stores.csv")
+	writeStoreMaster(t, path, "AreaCode,StoreName,StoreID\n1,A,S1\n1,B,S2\n")
+	if _, err := LoadStoreMaster(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				// Both stores are present in every version, so a reader must always see both
+				if !IsValidStore("S1") || !IsValidStore("S2") {
+					t.Error("Observed a partially loaded store master")
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 50; i++ {
+		if _, _, err := ReloadStoreMaster(true); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func TestWatchStoreMaster(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stores.csv")
+	writeStoreMaster(t, path, "AreaCode,StoreName,StoreID\n1,A,S1\n")
+	if _, err := LoadStoreMaster(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go WatchStoreMaster(ctx, 10*time.Millisecond)
+
+	// Edge case: A malformed edit is skipped and the watcher keeps running
+	writeStoreMaster(t, path, "AreaCode,StoreName,StoreID\n\"\n")
+	time.Sleep(50 * time.Millisecond)
+	if !IsValidStore("S1") {
+		t.Errorf("Expected the previous store master to stay active")
+	}
+
+	// Normal case: A later valid edit is picked up
+	writeStoreMaster(t, path, "AreaCode,StoreName,StoreID\n1,A,S1\n1,C,S3\n")
+	deadline := time.Now().Add(2 * time.Second)
+	for !IsValidStore("S3") {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the watcher to pick up S3")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
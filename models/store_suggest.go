@@ -0,0 +1,108 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Suggestion tuning for invalid store IDs
+var (
+	MaxSuggestionDistance = 2
+	MaxSuggestions        = 3
+)
+
+// StoreSuggestion is a store whose ID is close to a mistyped one
+type StoreSuggestion struct {
+	StoreID   string `json:"store_id"`
+	StoreName string `json:"store_name"`
+	Distance  int    `json:"distance"`
+}
+
+// SuggestStores returns the active stores whose IDs most closely match storeID.
+// IDs that only differ in case, punctuation or zero padding ("rp-0001" for
+// "RP00001") rank first with distance 0, followed by IDs within
+// MaxSuggestionDistance edits.
+func SuggestStores(storeID string) []StoreSuggestion {
+	return activeStores().Suggest(storeID, MaxSuggestions)
+}
+
+// Suggest returns up to limit active stores close to storeID, nearest first
+func (r *StoreRegistry) Suggest(storeID string, limit int) []StoreSuggestion {
+	normalized := normalizeStoreID(storeID)
+	canonical := canonicalStoreID(normalized)
+	if normalized == "" {
+		return nil
+	}
+
+	var suggestions []StoreSuggestion
+	for _, store := range r.sorted {
+		if !store.Active() || store.ID == storeID {
+			continue
+		}
+		candidate := normalizeStoreID(store.ID)
+		distance := 0
+		if canonicalStoreID(candidate) != canonical {
+			distance = levenshtein(normalized, candidate)
+			if distance == 0 || distance > MaxSuggestionDistance {
+				continue
+			}
+		}
+		suggestions = append(suggestions, StoreSuggestion{StoreID: store.ID, StoreName: store.Name, Distance: distance})
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].Distance < suggestions[j].Distance
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+// normalizeStoreID upper-cases an ID and drops everything but letters and digits
+func normalizeStoreID(storeID string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(storeID) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// canonicalStoreID strips zero padding from the numeric part of a normalized
+// ID, so RP0001 and RP00001 compare equal
+func canonicalStoreID(normalized string) string {
+	i := strings.IndexFunc(normalized, unicode.IsDigit)
+	if i < 0 {
+		return normalized
+	}
+	digits := strings.TrimLeft(normalized[i:], "0")
+	if digits == "" || !unicode.IsDigit(rune(digits[0])) {
+		digits = "0" + digits
+	}
+	return normalized[:i] + digits
+}
+
+// levenshtein returns the edit distance between two strings
+func levenshtein(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	previous := make([]int, len(br)+1)
+	current := make([]int, len(br)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		current[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(br)]
+}
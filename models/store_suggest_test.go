@@ -0,0 +1,79 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestSuggestStores(t *testing.T) {
+	storeMaster.Store(NewStoreRegistry([]Store{
+		{ID: "RP00001", Name: "B P STORE", AreaCode: "7100015"},
+		{ID: "RP00002", Name: "MONAJ STORE", AreaCode: "7100015"},
+		{ID: "RP00010", Name: "TEN STORE", AreaCode: "7100016"},
+		{ID: "RP00003", Name: "CLOSED STORE", AreaCode: "7100016", Status: StoreStatusInactive},
+		{ID: "XY99999", Name: "FAR STORE", AreaCode: "7100017"},
+	}))
+	defer InitTestStoreMaster()
+
+	// Normal case: Dropped zero padding is an exact canonical match
+	t.Run("ZeroPadding", func(t *testing.T) {
+		suggestions := SuggestStores("RP0001")
+		if len(suggestions) == 0 || suggestions[0].StoreID != "RP00001" || suggestions[0].Distance != 0 {
+			t.Fatalf("Expected RP00001 first with distance 0, got %+v", suggestions)
+		}
+		if suggestions[0].StoreName != "B P STORE" {
+			t.Errorf("Expected store name on suggestion, got %+v", suggestions[0])
+		}
+	})
+
+	// Normal case: Case and punctuation are ignored
+	t.Run("Normalization", func(t *testing.T) {
+		suggestions := SuggestStores("rp-00002")
+		if len(suggestions) == 0 || suggestions[0].StoreID != "RP00002" {
+			t.Errorf("Expected RP00002 first, got %+v", suggestions)
+		}
+	})
+
+	// Normal case: Typos within the edit distance are suggested nearest first
+	t.Run("Typo", func(t *testing.T) {
+		suggestions := SuggestStores("RP00011")
+		if len(suggestions) == 0 || suggestions[0].Distance != 1 {
+			t.Fatalf("Expected a suggestion at distance 1, got %+v", suggestions)
+		}
+		if len(suggestions) > MaxSuggestions {
+			t.Errorf("Expected at most %d suggestions, got %d", MaxSuggestions, len(suggestions))
+		}
+		for _, s := range suggestions {
+			if s.StoreID == "RP00003" {
+				t.Errorf("Expected inactive stores not to be suggested")
+			}
+		}
+	})
+
+	// Edge case: Nothing close enough
+	t.Run("NoMatch", func(t *testing.T) {
+		if suggestions := SuggestStores("ZZZZZZZZZZ"); len(suggestions) != 0 {
+			t.Errorf("Expected no suggestions, got %+v", suggestions)
+		}
+		if suggestions := SuggestStores(""); len(suggestions) != 0 {
+			t.Errorf("Expected no suggestions for an empty ID, got %+v", suggestions)
+		}
+	})
+}
+
+func TestLevenshtein(t *testing.T) {
+	cases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"RP00001", "RP00001", 0},
+		{"RP00001", "RP0001", 1},
+		{"RP00001", "PR00001", 2},
+		{"kitten", "sitting", 3},
+	}
+	for _, c := range cases {
+		if got := levenshtein(c.a, c.b); got != c.expected {
+			t.Errorf("levenshtein(%q, %q) = %d, expected %d", c.a, c.b, got, c.expected)
+		}
+	}
+}
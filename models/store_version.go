@@ -0,0 +1,101 @@
+package models
+
+import "time"
+
+// StoreRename records a store whose name changed between versions
+type StoreRename struct {
+	StoreID string `json:"store_id"`
+	OldName string `json:"old_name"`
+	NewName string `json:"new_name"`
+}
+
+// StoreChange records a store whose area code, status or active window changed between versions
+type StoreChange struct {
+	StoreID string `json:"store_id"`
+	Before  Store  `json:"before"`
+	After   Store  `json:"after"`
+}
+
+// StoreMasterDiff lists the differences between two store master versions
+type StoreMasterDiff struct {
+	From    StoreMasterInfo `json:"from"`
+	To      StoreMasterInfo `json:"to"`
+	Added   []Store         `json:"added"`
+	Removed []Store         `json:"removed"`
+	Renamed []StoreRename   `json:"renamed"`
+	Changed []StoreChange   `json:"changed"`
+}
+
+// StoreMasterVersions returns the retained store master versions, oldest first
+func StoreMasterVersions() []StoreMasterInfo {
+	storeHistoryMutex.RLock()
+	defer storeHistoryMutex.RUnlock()
+
+	versions := make([]StoreMasterInfo, 0, len(storeHistory))
+	for _, registry := range storeHistory {
+		versions = append(versions, registry.info)
+	}
+	return versions
+}
+
+// StoreMasterVersion looks up a retained store master version by its version ID
+func StoreMasterVersion(versionID string) (*StoreRegistry, bool) {
+	storeHistoryMutex.RLock()
+	defer storeHistoryMutex.RUnlock()
+
+	for _, registry := range storeHistory {
+		if registry.info.VersionID == versionID {
+			return registry, true
+		}
+	}
+	return nil, false
+}
+
+// useStoreMasterVersion keeps a version in the history while the server runs,
+// since a job was validated against it
+func useStoreMasterVersion(versionID string) {
+	storeHistoryMutex.Lock()
+	defer storeHistoryMutex.Unlock()
+
+	storeVersionsInUse[versionID] = true
+}
+
+// DiffStoreMasters compares two registries. Stores are listed in ID order.
+func DiffStoreMasters(from, to *StoreRegistry) StoreMasterDiff {
+	diff := StoreMasterDiff{
+		From:    from.info,
+		To:      to.info,
+		Added:   []Store{},
+		Removed: []Store{},
+		Renamed: []StoreRename{},
+		Changed: []StoreChange{},
+	}
+
+	for _, before := range from.sorted {
+		after, exists := to.byID[before.ID]
+		if !exists {
+			diff.Removed = append(diff.Removed, *before)
+			continue
+		}
+		if before.Name != after.Name {
+			diff.Renamed = append(diff.Renamed, StoreRename{StoreID: before.ID, OldName: before.Name, NewName: after.Name})
+		}
+		if before.AreaCode != after.AreaCode || before.Status != after.Status ||
+			!sameTime(before.ActiveFrom, after.ActiveFrom) || !sameTime(before.ActiveTo, after.ActiveTo) {
+			diff.Changed = append(diff.Changed, StoreChange{StoreID: before.ID, Before: *before, After: *after})
+		}
+	}
+	for _, after := range to.sorted {
+		if _, exists := from.byID[after.ID]; !exists {
+			diff.Added = append(diff.Added, *after)
+		}
+	}
+	return diff
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestStoreMasterVersions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stores.csv")
+	writeStoreMaster(t, path, "AreaCode,StoreName,StoreID\n1,Alpha,S1\n1,Beta,S2\n2,Gamma,S3\n")
+	if _, err := LoadStoreMaster(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer InitTestStoreMaster()
+	first := StoreMasterStatus()
+	if first.VersionID == "" || first.VersionID[:12] != first.Checksum[:12] {
+		t.Errorf("Expected version ID derived from the checksum, got %+v", first)
+	}
+
+	writeStoreMaster(t, path, "AreaCode,StoreName,StoreID\n1,Alpha Renamed,S1\n3,Gamma,S3\n1,Delta,S4\n")
+	if _, _, err := ReloadStoreMaster(false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	second := StoreMasterStatus()
+
+	// Normal case: Both versions are retained and can be looked up
+	versions := StoreMasterVersions()
+	if len(versions) < 2 || versions[len(versions)-1].VersionID != second.VersionID {
+		t.Fatalf("Expected the active version last in history, got %+v", versions)
+	}
+	from, exists := StoreMasterVersion(first.VersionID)
+	if !exists {
+		t.Fatalf("Expected version %s to be retained", first.VersionID)
+	}
+
+	// Normal case: Diff lists added, removed, renamed and changed stores
+	diff := DiffStoreMasters(from, CurrentStoreMaster())
+	if len(diff.Added) != 1 || diff.Added[0].ID != "S4" {
+		t.Errorf("Expected S4 added, got %+v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].ID != "S2" {
+		t.Errorf("Expected S2 removed, got %+v", diff.Removed)
+	}
+	if len(diff.Renamed) != 1 || diff.Renamed[0].NewName != "Alpha Renamed" {
+		t.Errorf("Expected S1 renamed, got %+v", diff.Renamed)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0].StoreID != "S3" || diff.Changed[0].After.AreaCode != "3" {
+		t.Errorf("Expected S3 area change, got %+v", diff.Changed)
+	}
+
+	// Edge case: Unknown version
+	if _, exists := StoreMasterVersion("nope"); exists {
+		t.Errorf("Expected unknown version not to exist")
+	}
+}
+
+func TestStoreMasterHistoryIsBounded(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stores.csv")
+	writeStoreMaster(t, path, "AreaCode,StoreName,StoreID\n1,Alpha,S1\n")
+	if _, err := LoadStoreMaster(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer InitTestStoreMaster()
+
+	for i := 0; i < MaxStoreMasterVersions+5; i++ {
+		ReloadStoreMaster(true)
+	}
+	versions := StoreMasterVersions()
+	if len(versions) != MaxStoreMasterVersions {
+		t.Errorf("Expected %d retained versions, got %d", MaxStoreMasterVersions, len(versions))
+	}
+
+	// Edge case: Reloads of the same file within one millisecond get distinct version IDs
+	seen := make(map[string]bool)
+	for _, version := range versions {
+		if seen[version.VersionID] {
+			t.Errorf("Expected unique version IDs, got %s twice", version.VersionID)
+		}
+		seen[version.VersionID] = true
+	}
+}
+
+func TestStoreMasterHistoryKeepsVersionsInUse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stores.csv")
+	writeStoreMaster(t, path, "AreaCode,StoreName,StoreID\n1,Alpha,S1\n")
+	if _, err := LoadStoreMaster(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer InitTestStoreMaster()
+	validated := StoreMasterStatus()
+	jobID := CreateJob(JobRequest{Count: 1, Visits: []Visit{{StoreID: "S1", VisitTime: "2023-10-21T15:04:05Z"}}})
+	SetJobStoreMasterVersion(jobID, validated.VersionID)
+
+	// Edge case: The version a job was validated against outlives the history limit
+	for i := 0; i < MaxStoreMasterVersions+5; i++ {
+		ReloadStoreMaster(true)
+	}
+	if _, exists := StoreMasterVersion(validated.VersionID); !exists {
+		t.Fatalf("Expected version %s of job %s to be retained", validated.VersionID, jobID)
+	}
+	versions := StoreMasterVersions()
+	if len(versions) != MaxStoreMasterVersions {
+		t.Errorf("Expected %d retained versions, got %d", MaxStoreMasterVersions, len(versions))
+	}
+	if versions[len(versions)-1].VersionID != StoreMasterStatus().VersionID {
+		t.Errorf("Expected the active version last in history")
+	}
+}
@@ -0,0 +1,253 @@
+package models
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	ErrStoreNotFound = errors.New("store not found")
+	ErrStoreExists   = errors.New("store already exists")
+	// ErrStoreMasterHasIssues is returned for edits while the store master file
+	// has rows the loader skipped, which rewriting the file would delete
+	ErrStoreMasterHasIssues = errors.New("store master file has malformed or duplicate rows; fix the file before editing stores")
+)
+
+// StoreFilter selects a page of stores
+type StoreFilter struct {
+	AreaCode string
+	Query    string
+	Offset   int
+	Limit    int
+}
+
+// ListStores returns the page of stores, ordered by ID, matching the filter
+// along with the total number of matches. Query matches names case-insensitively.
+func ListStores(filter StoreFilter) ([]Store, int) {
+	var candidates []Store
+	if filter.AreaCode != "" {
+		candidates = StoresByAreaCode(filter.AreaCode)
+		sort.Slice(candidates, func(i, j int) bool { return candidates[i].ID < candidates[j].ID })
+	} else {
+		candidates = activeStores().All()
+	}
+
+	query := strings.ToLower(strings.TrimSpace(filter.Query))
+	matches := candidates[:0]
+	for _, store := range candidates {
+		if query == "" || strings.Contains(strings.ToLower(store.Name), query) {
+			matches = append(matches, store)
+		}
+	}
+
+	total := len(matches)
+	start := min(max(filter.Offset, 0), total)
+	end := total
+	if filter.Limit > 0 {
+		end = min(start+filter.Limit, total)
+	}
+	return matches[start:end], total
+}
+
+// CreateStore adds a store to the store master and persists it
+func CreateStore(store Store) (Store, error) {
+	if err := validateStore(&store); err != nil {
+		return Store{}, err
+	}
+	return store, mutateStoreMaster(func(stores []Store) ([]Store, error) {
+		for _, existing := range stores {
+			if existing.ID == store.ID {
+				return nil, ErrStoreExists
+			}
+		}
+		return append(stores, store), nil
+	})
+}
+
+// UpdateStore replaces the details of an existing store and persists them
+func UpdateStore(storeID string, store Store) (Store, error) {
+	store.ID = storeID
+	if err := validateStore(&store); err != nil {
+		return Store{}, err
+	}
+	return store, mutateStoreMaster(func(stores []Store) ([]Store, error) {
+		for i := range stores {
+			if stores[i].ID == storeID {
+				stores[i] = store
+				return stores, nil
+			}
+		}
+		return nil, ErrStoreNotFound
+	})
+}
+
+// DeactivateStore marks a store inactive from now on so new visits to it are
+// rejected, keeping it in the store master for history
+func DeactivateStore(storeID string) (Store, error) {
+	var updated Store
+	err := mutateStoreMaster(func(stores []Store) ([]Store, error) {
+		for i := range stores {
+			if stores[i].ID == storeID {
+				// Close the active window now so visits made before deactivation stay valid
+				now := time.Now().UTC()
+				if stores[i].ActiveTo == nil || stores[i].ActiveTo.After(now) {
+					stores[i].ActiveTo = &now
+				}
+				stores[i].Status = StoreStatusInactive
+				updated = stores[i]
+				return stores, nil
+			}
+		}
+		return nil, ErrStoreNotFound
+	})
+	return updated, err
+}
+
+func validateStore(store *Store) error {
+	store.ID = strings.TrimSpace(store.ID)
+	store.Name = strings.TrimSpace(store.Name)
+	store.AreaCode = strings.TrimSpace(store.AreaCode)
+	if store.Status == "" {
+		store.Status = StoreStatusActive
+	}
+
+	switch {
+	case store.ID == "":
+		return errors.New("store ID is required")
+	case store.Name == "":
+		return errors.New("store name is required")
+	case store.Status != StoreStatusActive && store.Status != StoreStatusInactive:
+		return fmt.Errorf("unknown status %q", store.Status)
+	case store.ActiveFrom != nil && store.ActiveTo != nil && !store.ActiveTo.After(*store.ActiveFrom):
+		return errors.New("active_to must be after active_from")
+	}
+	return nil
+}
+
+// mutateStoreMaster applies change to a copy of the active stores, writes the
+// result back to the store master file and swaps in the new registry. The file
+// is regenerated from the registry, so edits are refused while the last load
+// skipped malformed or duplicate rows.
+func mutateStoreMaster(change func([]Store) ([]Store, error)) error {
+	reloadMutex.Lock()
+	defer reloadMutex.Unlock()
+
+	if storeFilePath == "" {
+		return errors.New("store master has not been loaded from a file")
+	}
+	if storeReport != nil && (len(storeReport.MalformedLines) > 0 || len(storeReport.DuplicateIDs) > 0) {
+		return ErrStoreMasterHasIssues
+	}
+
+	stores, err := change(activeStores().All())
+	if err != nil {
+		return err
+	}
+
+	data, err := encodeStoreMaster(stores, storeOptions.Columns)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(storeFilePath, data); err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(data)
+	swapStoreRegistry(stores, hex.EncodeToString(sum[:]))
+	storeReport = &StoreLoadReport{Loaded: len(stores)}
+	return nil
+}
+
+// encodeStoreMaster writes stores as CSV using the configured column names,
+// followed by any extra columns in sorted order. An extra column named like a
+// configured column is rejected, since the loader matches header names
+// case-insensitively and would read the store field from it.
+func encodeStoreMaster(stores []Store, columns StoreColumns) ([]byte, error) {
+	header := storeMasterHeader(columns)
+	extraSet := make(map[string]bool)
+	for _, store := range stores {
+		for name := range store.Extra {
+			for _, column := range header {
+				if strings.EqualFold(strings.TrimSpace(name), column) {
+					return nil, fmt.Errorf("extra column %q clashes with the %q column", name, column)
+				}
+			}
+			extraSet[name] = true
+		}
+	}
+	extras := make([]string, 0, len(extraSet))
+	for name := range extraSet {
+		extras = append(extras, name)
+	}
+	sort.Strings(extras)
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write(append(header, extras...))
+	for _, store := range stores {
+		record := []string{store.AreaCode, store.Name, store.ID, store.Status, formatStoreDate(store.ActiveFrom), formatStoreDate(store.ActiveTo)}
+		for _, name := range extras {
+			record = append(record, store.Extra[name])
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+// storeMasterHeader returns the configured store column names in file order,
+// using the default names for unset optional columns
+func storeMasterHeader(columns StoreColumns) []string {
+	orDefault := func(name, fallback string) string {
+		if name == "" {
+			return fallback
+		}
+		return name
+	}
+	defaults := DefaultStoreLoadOptions.Columns
+	return []string{
+		columns.AreaCode,
+		columns.Name,
+		columns.ID,
+		orDefault(columns.Status, defaults.Status),
+		orDefault(columns.ActiveFrom, defaults.ActiveFrom),
+		orDefault(columns.ActiveTo, defaults.ActiveTo),
+	}
+}
+
+func formatStoreDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// writeFileAtomic replaces a file so readers and the watcher never see it half written
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".storemaster-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if stat, err := os.Stat(path); err == nil {
+		os.Chmod(tmp.Name(), stat.Mode().Perm())
+	}
+	return os.Rename(tmp.Name(), path)
+}
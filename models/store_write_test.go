@@ -0,0 +1,154 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestListStores(t *testing.T) {
+	storeMaster.Store(NewStoreRegistry([]Store{
+		{ID: "S3", Name: "Gamma Mart", AreaCode: "200"},
+		{ID: "S1", Name: "Alpha Mart", AreaCode: "100"},
+		{ID: "S2", Name: "Beta Store", AreaCode: "100"},
+	}))
+	defer InitTestStoreMaster()
+
+	// Normal case: All stores ordered by ID
+	stores, total := ListStores(StoreFilter{})
+	if total != 3 || stores[0].ID != "S1" || stores[2].ID != "S3" {
+		t.Errorf("Expected S1..S3 in order, got %v", stores)
+	}
+
+	// Normal case: Area filter combined with case-insensitive name search
+	stores, total = ListStores(StoreFilter{AreaCode: "100", Query: "MART"})
+	if total != 1 || stores[0].ID != "S1" {
+		t.Errorf("Expected only S1, got %v", stores)
+	}
+
+	// Edge case: Offset past the end
+	stores, total = ListStores(StoreFilter{Offset: 10, Limit: 5})
+	if total != 3 || len(stores) != 0 {
+		t.Errorf("Expected an empty page of 3 total, got %d of %d", len(stores), total)
+	}
+}
+
+func TestStoreMutations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stores.csv")
+	os.WriteFile(path, []byte("AreaCode,StoreName,StoreID,Region\n100,Alpha,S1,East\n"), 0o644)
+	if _, err := LoadStoreMaster(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer InitTestStoreMaster()
+
+	// Normal case: Create persists the store and bumps the version
+	before := StoreMasterStatus().Version
+	if _, err := CreateStore(Store{ID: "S2", Name: "Beta", AreaCode: "200"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if StoreMasterStatus().Version != before+1 {
+		t.Errorf("Expected version %d, got %d", before+1, StoreMasterStatus().Version)
+	}
+
+	// Edge case: Validation and conflicts
+	if _, err := CreateStore(Store{ID: "S2", Name: "Again"}); err != ErrStoreExists {
+		t.Errorf("Expected ErrStoreExists, got %v", err)
+	}
+	if _, err := CreateStore(Store{ID: "S5"}); err == nil {
+		t.Errorf("Expected an error for a store without a name")
+	}
+	if _, err := DeactivateStore("NOPE"); err != ErrStoreNotFound {
+		t.Errorf("Expected ErrStoreNotFound, got %v", err)
+	}
+
+	// Normal case: Deactivation keeps the store but rejects visits
+	if _, err := DeactivateStore("S1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Reloading the written file reproduces the registry, including extra columns
+	if _, err := LoadStoreMaster(path); err != nil {
+		t.Fatalf("Expected written file to load, got %v", err)
+	}
+	s1, _ := LookupStore("S1")
+	if s1.Active() || s1.Extra["Region"] != "East" {
+		t.Errorf("Expected inactive S1 with its Region kept, got %+v", s1)
+	}
+	if s1.ActiveTo == nil || !s1.ActiveAt(s1.ActiveTo.Add(-time.Hour)) {
+		t.Errorf("Expected S1 to stay valid for visits before deactivation, got %+v", s1)
+	}
+	if IsValidStore("S1") || !IsValidStore("S2") {
+		t.Errorf("Expected S1 invalid and S2 valid after reload")
+	}
+}
+
+func TestStoreExtraColumns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stores.csv")
+	os.WriteFile(path, []byte("AreaCode,StoreName,StoreID,Region\n100,Alpha,S1,East\n100,Beta,S2,West\n"), 0o644)
+	if _, err := LoadStoreMaster(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer InitTestStoreMaster()
+	original, _ := os.ReadFile(path)
+
+	// Edge case: Extra columns named like a store column, in any case, are rejected
+	for _, name := range []string{"storeid", "StoreName", " AREACODE ", "status", "activeTo"} {
+		_, err := CreateStore(Store{ID: "S3", Name: "Gamma", Extra: map[string]string{name: "X"}})
+		if err == nil {
+			t.Errorf("Expected extra column %q to be rejected", name)
+		}
+	}
+	if data, _ := os.ReadFile(path); string(data) != string(original) {
+		t.Errorf("Expected the file to be left unchanged, got %q", data)
+	}
+	if !IsValidStore("S1") || !IsValidStore("S2") || IsValidStore("S3") {
+		t.Errorf("Expected S1 and S2 to stay valid without S3")
+	}
+
+	// Normal case: Other extra columns are written and read back
+	if _, err := CreateStore(Store{ID: "S3", Name: "Gamma", Extra: map[string]string{"Manager": "Kim"}}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, _, err := ReloadStoreMaster(true); err != nil {
+		t.Fatalf("Expected the written file to reload, got %v", err)
+	}
+	if s3, _ := LookupStore("S3"); s3.Extra["Manager"] != "Kim" || !IsValidStore("S1") {
+		t.Errorf("Expected S3 with its Manager and S1 still valid, got %+v", s3)
+	}
+}
+
+func TestStoreMutationsWithSkippedRows(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stores.csv")
+	contents := "AreaCode,StoreName,StoreID,ActiveFrom\n100,Alpha,S1,\n100,Beta,S2,2023-13-01\n100,Old Gamma,S3,\n100,Gamma,S3,\n"
+	os.WriteFile(path, []byte(contents), 0o644)
+	opts := DefaultStoreLoadOptions
+	opts.Strictness = StrictnessLenient
+	if _, err := LoadStoreMasterWithOptions(path, opts); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer InitTestStoreMaster()
+
+	// Edge case: Edits would drop the malformed and duplicate rows, so they are refused
+	if _, err := CreateStore(Store{ID: "S4", Name: "Delta"}); err != ErrStoreMasterHasIssues {
+		t.Errorf("Expected ErrStoreMasterHasIssues, got %v", err)
+	}
+	if _, err := DeactivateStore("S1"); err != ErrStoreMasterHasIssues {
+		t.Errorf("Expected ErrStoreMasterHasIssues, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != contents {
+		t.Errorf("Expected the file to be left unchanged, got %q", data)
+	}
+
+	// Normal case: Edits are accepted again once the file is fixed
+	os.WriteFile(path, []byte("AreaCode,StoreName,StoreID,ActiveFrom\n100,Alpha,S1,\n100,Beta,S2,2023-12-01\n100,Gamma,S3,\n"), 0o644)
+	if _, _, err := ReloadStoreMaster(false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := CreateStore(Store{ID: "S4", Name: "Delta"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := UpdateStore("S4", Store{Name: "Delta Mart"}); err != nil {
+		t.Errorf("Expected edits after a clean write to be accepted, got %v", err)
+	}
+}
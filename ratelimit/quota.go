@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// DailyQuota caps how much each key may use per UTC day. A DailyQuota with a
+// Limit of 0 is unlimited.
+type DailyQuota struct {
+	Limit int
+
+	// Now returns the current time. It can be replaced during tests.
+	Now func() time.Time
+
+	mu    sync.Mutex
+	usage map[string]dailyUsage
+}
+
+type dailyUsage struct {
+	day  time.Time
+	used int
+}
+
+// NewDailyQuota returns a DailyQuota allowing limit units per key and day
+func NewDailyQuota(limit int) *DailyQuota {
+	return &DailyQuota{Limit: limit, Now: time.Now}
+}
+
+// Take uses n units of key's quota for today if they are all available.
+// Nothing is used when the request would exceed the quota.
+func (q *DailyQuota) Take(key string, n int) Decision {
+	if q.Limit <= 0 {
+		return Decision{Allowed: true}
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.Now().UTC()
+	day := now.Truncate(24 * time.Hour)
+	if q.usage == nil {
+		q.usage = make(map[string]dailyUsage)
+	}
+	usage := q.usage[key]
+	if !usage.day.Equal(day) {
+		for k, u := range q.usage {
+			if !u.day.Equal(day) {
+				delete(q.usage, k)
+			}
+		}
+		usage = dailyUsage{day: day}
+	}
+
+	decision := Decision{Limit: q.Limit, Reset: day.Add(24 * time.Hour).Sub(now)}
+	if usage.used+n <= q.Limit {
+		usage.used += n
+		q.usage[key] = usage
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = decision.Reset
+	}
+	decision.Remaining = q.Limit - usage.used
+	return decision
+}
+
+// Return gives back n units taken today, e.g. for a request that failed later
+func (q *DailyQuota) Return(key string, n int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if usage, exists := q.usage[key]; exists {
+		usage.used = max(0, usage.used-n)
+		q.usage[key] = usage
+	}
+}
@@ -0,0 +1,52 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDailyQuota(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 18, 0, 0, 0, time.UTC)}
+	quota := NewDailyQuota(10)
+	quota.Now = clock.Now
+
+	// Normal case: Units are taken until the quota is used up
+	t.Run("Take", func(t *testing.T) {
+		if d := quota.Take("acme", 6); !d.Allowed || d.Remaining != 4 || d.Reset != 6*time.Hour {
+			t.Errorf("Expected 4 remaining until midnight, got %+v", d)
+		}
+		d := quota.Take("acme", 5)
+		if d.Allowed || d.Remaining != 4 || d.RetryAfter != 6*time.Hour {
+			t.Errorf("Expected the request to be rejected without using the quota, got %+v", d)
+		}
+		if d := quota.Take("acme", 4); !d.Allowed || d.Remaining != 0 {
+			t.Errorf("Expected the rest of the quota to be usable, got %+v", d)
+		}
+	})
+
+	// Normal case: Returned units can be taken again
+	t.Run("Return", func(t *testing.T) {
+		quota.Return("acme", 3)
+		if d := quota.Take("acme", 3); !d.Allowed {
+			t.Errorf("Expected returned units to be available, got %+v", d)
+		}
+	})
+
+	// Edge case: Keys have separate quotas and the quota resets at midnight UTC
+	t.Run("PerKeyAndDay", func(t *testing.T) {
+		if d := quota.Take("globex", 10); !d.Allowed {
+			t.Errorf("Expected another key's quota to be unused, got %+v", d)
+		}
+		clock.now = clock.now.Add(6 * time.Hour)
+		if d := quota.Take("acme", 10); !d.Allowed || d.Remaining != 0 {
+			t.Errorf("Expected a fresh quota on the next day, got %+v", d)
+		}
+	})
+
+	// Edge case: A limit of 0 is unlimited
+	t.Run("Unlimited", func(t *testing.T) {
+		if d := NewDailyQuota(0).Take("acme", 1000000); !d.Allowed {
+			t.Errorf("Expected no limit, got %+v", d)
+		}
+	})
+}
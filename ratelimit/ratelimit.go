@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// MaxIdleBuckets is how many buckets a Limiter keeps before it forgets
+// clients whose buckets have refilled
+var MaxIdleBuckets = 10000
+
+// Limiter is a token bucket per key: each key may make Burst requests at
+// once, refilled at Rate tokens per second. A Limiter with a Rate of 0 allows
+// every request.
+type Limiter struct {
+	Rate  float64
+	Burst int
+
+	// Now returns the current time. It can be replaced during tests.
+	Now func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Decision is the outcome of a request against a limit
+type Decision struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the limit is fully available again
+	Reset time.Duration
+	// RetryAfter is how long a rejected request should wait before retrying
+	RetryAfter time.Duration
+}
+
+// NewLimiter returns a Limiter allowing rate requests per second with bursts of burst
+func NewLimiter(rate float64, burst int) *Limiter {
+	return &Limiter{Rate: rate, Burst: burst, Now: time.Now}
+}
+
+// Allow takes a token from key's bucket if one is available
+func (l *Limiter) Allow(key string) Decision {
+	if l.Rate <= 0 {
+		return Decision{Allowed: true, Limit: l.Burst, Remaining: l.Burst}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.Now()
+	if l.buckets == nil {
+		l.buckets = make(map[string]*bucket)
+	}
+	b, exists := l.buckets[key]
+	if !exists {
+		if len(l.buckets) >= MaxIdleBuckets {
+			l.forgetFull(now)
+		}
+		b = &bucket{tokens: float64(l.Burst), last: now}
+		l.buckets[key] = b
+	}
+	b.refill(now, l.Rate, l.Burst)
+
+	decision := Decision{Limit: l.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = seconds((1 - b.tokens) / l.Rate)
+	}
+	decision.Remaining = int(b.tokens)
+	decision.Reset = seconds((float64(l.Burst) - b.tokens) / l.Rate)
+	return decision
+}
+
+// forgetFull drops the buckets that have refilled, which behave like new ones
+func (l *Limiter) forgetFull(now time.Time) {
+	for key, b := range l.buckets {
+		b.refill(now, l.Rate, l.Burst)
+		if b.tokens >= float64(l.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func (b *bucket) refill(now time.Time, rate float64, burst int) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(burst), b.tokens+elapsed*rate)
+	}
+	b.last = now
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
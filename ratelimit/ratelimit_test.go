@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock that only moves when advanced
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func TestLimiter(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	limiter := NewLimiter(2, 3)
+	limiter.Now = clock.Now
+
+	// Normal case: A burst is allowed, then requests are rejected
+	t.Run("Burst", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			if d := limiter.Allow("a"); !d.Allowed || d.Remaining != 2-i {
+				t.Errorf("Expected request %d to be allowed with %d remaining, got %+v", i, 2-i, d)
+			}
+		}
+		d := limiter.Allow("a")
+		if d.Allowed {
+			t.Fatalf("Expected the fourth request to be rejected")
+		}
+		if d.Limit != 3 || d.Remaining != 0 || d.RetryAfter != 500*time.Millisecond || d.Reset != 1500*time.Millisecond {
+			t.Errorf("Expected limit 3, 0 remaining, retry after 500ms and reset after 1.5s, got %+v", d)
+		}
+	})
+
+	// Normal case: Tokens refill at the rate
+	t.Run("Refill", func(t *testing.T) {
+		clock.now = clock.now.Add(500 * time.Millisecond)
+		if d := limiter.Allow("a"); !d.Allowed {
+			t.Errorf("Expected a refilled token, got %+v", d)
+		}
+		if d := limiter.Allow("a"); d.Allowed {
+			t.Errorf("Expected no token left, got %+v", d)
+		}
+	})
+
+	// Edge case: Keys have separate buckets
+	t.Run("SeparateKeys", func(t *testing.T) {
+		if d := limiter.Allow("b"); !d.Allowed || d.Remaining != 2 {
+			t.Errorf("Expected a full bucket for a new key, got %+v", d)
+		}
+	})
+
+	// Edge case: A rate of 0 disables the limit
+	t.Run("Disabled", func(t *testing.T) {
+		unlimited := NewLimiter(0, 0)
+		for i := 0; i < 100; i++ {
+			if !unlimited.Allow("a").Allowed {
+				t.Fatalf("Expected every request to be allowed")
+			}
+		}
+	})
+
+	// Edge case: Refilled buckets are forgotten once there are too many
+	t.Run("ForgetsIdleBuckets", func(t *testing.T) {
+		defer func(n int) { MaxIdleBuckets = n }(MaxIdleBuckets)
+		MaxIdleBuckets = 2
+		idle := NewLimiter(1, 1)
+		idle.Now = clock.Now
+		idle.Allow("a")
+		idle.Allow("b")
+		clock.now = clock.now.Add(time.Second)
+		idle.Allow("c")
+		if len(idle.buckets) != 1 {
+			t.Errorf("Expected only the new bucket to be kept, got %d buckets", len(idle.buckets))
+		}
+	})
+}
@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound is returned when a key does not exist in the store
+var ErrNotFound = errors.New("blob not found")
+
+// ErrInvalidKey is returned for keys that could escape the store
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// BlobStore persists processed images under opaque keys
+type BlobStore interface {
+	Put(key string, body io.Reader) error
+	Get(key string) (io.ReadCloser, string, error)
+}
+
+// Checker is implemented by stores that can report whether they are usable
+type Checker interface {
+	Check() error
+}
+
+// Default is the store used by the worker and API. Image storage is
+// disabled while it is nil.
+var Default BlobStore
+
+// LocalStore keeps blobs as files in a directory on the local filesystem
+type LocalStore struct {
+	Root string
+}
+
+// NewLocalStore creates the root directory if needed and returns a store backed by it
+func NewLocalStore(root string) (*LocalStore, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalStore{Root: root}, nil
+}
+
+// Put writes the blob atomically so readers never see a partial file
+func (s *LocalStore) Put(key string, body io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(s.Root, ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get opens a blob and returns its content type, derived from the key's extension
+func (s *LocalStore) Get(key string) (io.ReadCloser, string, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, "", ErrNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return file, contentType, nil
+}
+
+// Check verifies that the root directory accepts new files
+func (s *LocalStore) Check() error {
+	tmp, err := os.CreateTemp(s.Root, ".check-*")
+	if err != nil {
+		return err
+	}
+	tmp.Close()
+	return os.Remove(tmp.Name())
+}
+
+// path maps a key to a file inside the root, rejecting anything but a plain file name
+func (s *LocalStore) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, ".") || strings.ContainsAny(key, `/\`) {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.Root, key), nil
+}
+
+// TenantKey prefixes key with a tag derived from tenant, so each tenant's
+// blobs are stored and served separately. Keys of jobs without a tenant are
+// returned unchanged.
+func TenantKey(tenant, key string) string {
+	if tenant == "" {
+		return key
+	}
+	return tenantTag(tenant) + key
+}
+
+// KeyOwnedBy reports whether tenant may read key. Without a tenant, which
+// means authentication is disabled, every key may be read.
+func KeyOwnedBy(key, tenant string) bool {
+	return tenant == "" || strings.HasPrefix(key, tenantTag(tenant))
+}
+
+// tenantTag hashes the tenant, since tenant names may contain characters
+// that are not allowed in keys
+func tenantTag(tenant string) string {
+	sum := sha256.Sum256([]byte(tenant))
+	return "t" + hex.EncodeToString(sum[:8]) + "-"
+}
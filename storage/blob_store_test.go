@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Expected no error creating store, got %v", err)
+	}
+
+	// Normal case: Put and get a blob
+	t.Run("PutAndGet", func(t *testing.T) {
+		if err := store.Put("abc.jpg", strings.NewReader("image-bytes")); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		body, contentType, err := store.Get("abc.jpg")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		defer body.Close()
+		data, _ := io.ReadAll(body)
+		if string(data) != "image-bytes" {
+			t.Errorf("Expected 'image-bytes', got '%s'", data)
+		}
+		if contentType != "image/jpeg" {
+			t.Errorf("Expected content type image/jpeg, got %s", contentType)
+		}
+	})
+
+	// Edge case: Missing key
+	t.Run("MissingKey", func(t *testing.T) {
+		if _, _, err := store.Get("missing.jpg"); err != ErrNotFound {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+
+	// Edge case: Keys that escape the root are rejected
+	t.Run("InvalidKeys", func(t *testing.T) {
+		for _, key := range []string{"", "../etc/passwd", "a/b.jpg", ".hidden"} {
+			if err := store.Put(key, strings.NewReader("x")); err != ErrInvalidKey {
+				t.Errorf("Expected ErrInvalidKey for %q, got %v", key, err)
+			}
+		}
+	})
+
+	// Normal case: A writable root passes the check
+	t.Run("Check", func(t *testing.T) {
+		if err := store.Check(); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+
+	// Edge case: A missing root fails the check
+	t.Run("CheckMissingRoot", func(t *testing.T) {
+		missing := &LocalStore{Root: filepath.Join(t.TempDir(), "missing")}
+		if err := missing.Check(); err == nil {
+			t.Errorf("Expected an error for a missing root")
+		}
+	})
+}
+
+func TestTenantKey(t *testing.T) {
+	acmeKey := TenantKey("acme", "abc.jpg")
+
+	// Normal case: Tenant keys are tagged, readable by their tenant and valid file names
+	if acmeKey == "abc.jpg" || !KeyOwnedBy(acmeKey, "acme") {
+		t.Errorf("Expected a tagged key owned by acme, got %q", acmeKey)
+	}
+	if _, err := (&LocalStore{Root: t.TempDir()}).path(TenantKey("a/../b", "abc.jpg")); err != nil {
+		t.Errorf("Expected a valid key for any tenant name, got %v", err)
+	}
+
+	// Edge case: Other tenants cannot read the key, nor untagged keys
+	if KeyOwnedBy(acmeKey, "globex") || KeyOwnedBy("abc.jpg", "globex") {
+		t.Errorf("Expected globex not to own acme's or untagged keys")
+	}
+
+	// Edge case: Without a tenant keys are unchanged and every key is readable
+	if TenantKey("", "abc.jpg") != "abc.jpg" || !KeyOwnedBy(acmeKey, "") {
+		t.Errorf("Expected untagged keys and full access without a tenant")
+	}
+}
@@ -0,0 +1,399 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"backend-intern-assignment/metrics"
+)
+
+// Exporters
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Exporter sends finished spans to a tracing backend
+type Exporter interface {
+	ExportSpans(ctx context.Context, spans []SpanData) error
+}
+
+// Batching limits. Spans ended while the queue is full are dropped.
+const (
+	MaxQueuedSpans = 2048
+	MaxBatchSize   = 512
+	BatchInterval  = 5 * time.Second
+)
+
+var (
+	providerMutex sync.RWMutex
+	processor     *batchProcessor
+	ratio         = 1.0
+
+	droppedSpans = metrics.NewCounter("trace_spans_dropped_total", "Sampled spans dropped because the export queue was full.")
+)
+
+// Setup exports sampled spans through exporter in batches, replacing any
+// previous exporter without flushing it. A nil exporter disables exporting;
+// spans are still created so trace context propagates. sampleRatio is the
+// fraction of new traces that are sampled.
+func Setup(exporter Exporter, sampleRatio float64) {
+	providerMutex.Lock()
+	defer providerMutex.Unlock()
+
+	ratio = clampRatio(sampleRatio)
+	processor = nil
+	if exporter != nil {
+		processor = newBatchProcessor(exporter)
+	}
+}
+
+// Shutdown stops exporting and flushes queued spans, waiting until ctx ends
+func Shutdown(ctx context.Context) error {
+	providerMutex.Lock()
+	p := processor
+	processor = nil
+	if p != nil {
+		close(p.spans)
+	}
+	providerMutex.Unlock()
+
+	if p == nil {
+		return nil
+	}
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ForceFlush exports the spans queued so far, waiting until ctx ends
+func ForceFlush(ctx context.Context) error {
+	providerMutex.RLock()
+	p := processor
+	providerMutex.RUnlock()
+	if p == nil {
+		return nil
+	}
+
+	flushed := make(chan struct{})
+	select {
+	case p.flush <- flushed:
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func sampleRatio() float64 {
+	providerMutex.RLock()
+	defer providerMutex.RUnlock()
+
+	return ratio
+}
+
+// export queues a finished span without blocking
+func export(span SpanData) {
+	providerMutex.RLock()
+	defer providerMutex.RUnlock()
+
+	if processor == nil {
+		return
+	}
+	select {
+	case processor.spans <- span:
+	default:
+		droppedSpans.Inc()
+	}
+}
+
+// batchProcessor exports spans in batches of up to MaxBatchSize, at least
+// every BatchInterval
+type batchProcessor struct {
+	exporter Exporter
+	spans    chan SpanData
+	flush    chan chan struct{}
+	done     chan struct{}
+}
+
+func newBatchProcessor(exporter Exporter) *batchProcessor {
+	p := &batchProcessor{
+		exporter: exporter,
+		spans:    make(chan SpanData, MaxQueuedSpans),
+		flush:    make(chan chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+func (p *batchProcessor) run() {
+	defer close(p.done)
+	ticker := time.NewTicker(BatchInterval)
+	defer ticker.Stop()
+
+	var batch []SpanData
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), BatchInterval)
+		defer cancel()
+		if err := p.exporter.ExportSpans(ctx, batch); err != nil {
+			droppedSpans.Add(float64(len(batch)))
+		}
+		batch = nil
+	}
+
+	for {
+		select {
+		case span, open := <-p.spans:
+			if !open {
+				flush()
+				return
+			}
+			batch = append(batch, span)
+			if len(batch) >= MaxBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case flushed := <-p.flush:
+			for drained := false; !drained; {
+				select {
+				case span, open := <-p.spans:
+					if !open {
+						drained = true
+						break
+					}
+					batch = append(batch, span)
+				default:
+					drained = true
+				}
+			}
+			flush()
+			close(flushed)
+		}
+	}
+}
+
+// StdoutExporter writes each span as one JSON object per line
+type StdoutExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewStdoutExporter returns an exporter writing to w
+func NewStdoutExporter(w io.Writer) *StdoutExporter {
+	return &StdoutExporter{w: w}
+}
+
+// stdoutSpan is the line format of StdoutExporter
+type stdoutSpan struct {
+	Name          string                 `json:"name"`
+	TraceID       string                 `json:"trace_id"`
+	SpanID        string                 `json:"span_id"`
+	ParentSpanID  string                 `json:"parent_span_id,omitempty"`
+	Kind          string                 `json:"kind"`
+	Start         time.Time              `json:"start"`
+	End           time.Time              `json:"end"`
+	DurationMs    float64                `json:"duration_ms"`
+	Attributes    map[string]interface{} `json:"attributes,omitempty"`
+	Status        string                 `json:"status,omitempty"`
+	StatusMessage string                 `json:"status_message,omitempty"`
+}
+
+var kindNames = map[SpanKind]string{SpanKindInternal: "internal", SpanKindServer: "server", SpanKindClient: "client"}
+
+var statusNames = map[StatusCode]string{StatusOK: "ok", StatusError: "error"}
+
+// ExportSpans writes the spans to the exporter's writer
+func (e *StdoutExporter) ExportSpans(ctx context.Context, spans []SpanData) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, span := range spans {
+		line := stdoutSpan{
+			Name:          span.Name,
+			TraceID:       span.SpanContext.TraceID.String(),
+			SpanID:        span.SpanContext.SpanID.String(),
+			Kind:          kindNames[span.Kind],
+			Start:         span.Start.UTC(),
+			End:           span.End.UTC(),
+			DurationMs:    float64(span.End.Sub(span.Start).Microseconds()) / 1000,
+			Status:        statusNames[span.StatusCode],
+			StatusMessage: span.StatusMessage,
+		}
+		if span.Parent.IsValid() {
+			line.ParentSpanID = span.Parent.String()
+		}
+		if len(span.Attributes) > 0 {
+			line.Attributes = make(map[string]interface{}, len(span.Attributes))
+			for _, attr := range span.Attributes {
+				line.Attributes[attr.Key] = attr.Value
+			}
+		}
+		if err := encoder.Encode(line); err != nil {
+			return err
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err := e.w.Write(buf.Bytes())
+	return err
+}
+
+// OTLPExporter sends spans to an OpenTelemetry collector with the OTLP/HTTP
+// protocol, JSON encoded
+type OTLPExporter struct {
+	// URL is the traces endpoint, e.g. http://localhost:4318/v1/traces
+	URL         string
+	ServiceName string
+	Client      *http.Client
+}
+
+// NewOTLPExporter returns an exporter for the collector at endpoint, the base
+// URL as in OTEL_EXPORTER_OTLP_ENDPOINT; /v1/traces is appended
+func NewOTLPExporter(endpoint, serviceName string) *OTLPExporter {
+	return &OTLPExporter{
+		URL:         strings.TrimRight(endpoint, "/") + "/v1/traces",
+		ServiceName: serviceName,
+		Client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    StatusCode `json:"code,omitempty"`
+	Message string     `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              SpanKind        `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+// otlpRequest is an ExportTraceServiceRequest
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+// ExportSpans posts the spans to the collector
+func (e *OTLPExporter) ExportSpans(ctx context.Context, spans []SpanData) error {
+	scope := otlpScopeSpans{Scope: otlpScope{Name: "backend-intern-assignment/tracing"}}
+	for _, span := range spans {
+		s := otlpSpan{
+			TraceID:           span.SpanContext.TraceID.String(),
+			SpanID:            span.SpanContext.SpanID.String(),
+			Name:              span.Name,
+			Kind:              span.Kind,
+			StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+			Status:            otlpStatus{Code: span.StatusCode, Message: span.StatusMessage},
+		}
+		if span.Parent.IsValid() {
+			s.ParentSpanID = span.Parent.String()
+		}
+		for _, attr := range span.Attributes {
+			s.Attributes = append(s.Attributes, otlpAttr(attr))
+		}
+		scope.Spans = append(scope.Spans, s)
+	}
+	request := otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: []otlpAttribute{otlpAttr(String("service.name", e.ServiceName))}},
+		ScopeSpans: []otlpScopeSpans{scope},
+	}}}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("OTLP export to %s: unexpected status %s", e.URL, resp.Status)
+	}
+	return nil
+}
+
+// otlpAttr encodes an attribute as an OTLP AnyValue; 64-bit integers are
+// strings in OTLP JSON
+func otlpAttr(attr Attribute) otlpAttribute {
+	var value otlpValue
+	switch v := attr.Value.(type) {
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		value.IntValue = &s
+	case float64:
+		value.DoubleValue = &v
+	case bool:
+		value.BoolValue = &v
+	default:
+		s := fmt.Sprint(v)
+		value.StringValue = &s
+	}
+	return otlpAttribute{Key: attr.Key, Value: value}
+}
@@ -0,0 +1,106 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStdoutExporter(t *testing.T) {
+	// Normal case: Ended spans are flushed on Shutdown as JSON lines
+	var out bytes.Buffer
+	Setup(NewStdoutExporter(&out), 1)
+	defer Setup(nil, 1)
+
+	ctx, parent := Start(context.Background(), "parent", WithKind(SpanKindServer))
+	_, child := Start(ctx, "child", WithAttributes(String("store.id", "RP00001"), Int("images", 2)))
+	child.End()
+	parent.End()
+	if err := Shutdown(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 spans, got %q", out.String())
+	}
+	var span stdoutSpan
+	json.Unmarshal([]byte(lines[0]), &span)
+	if span.Name != "child" || span.ParentSpanID != parent.SpanContext().SpanID.String() || span.Kind != "internal" {
+		t.Errorf("Expected the child span first, got %+v", span)
+	}
+	if span.Attributes["store.id"] != "RP00001" || span.Attributes["images"] != float64(2) {
+		t.Errorf("Expected the child's attributes, got %v", span.Attributes)
+	}
+}
+
+func TestForceFlush(t *testing.T) {
+	// Normal case: Queued spans are exported without waiting for the batch interval
+	var out bytes.Buffer
+	exporter := NewStdoutExporter(&out)
+	Setup(exporter, 1)
+	defer Setup(nil, 1)
+
+	_, span := Start(context.Background(), "flushed")
+	span.End()
+	if err := ForceFlush(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	exporter.mu.Lock()
+	defer exporter.mu.Unlock()
+	if !strings.Contains(out.String(), `"name":"flushed"`) {
+		t.Errorf("Expected the span to be exported, got %q", out.String())
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var received otlpRequest
+	var path, contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, contentType = r.URL.Path, r.Header.Get("Content-Type")
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	// Normal case: Spans are posted as an OTLP/HTTP JSON request
+	t.Run("Export", func(t *testing.T) {
+		_, span := Start(context.Background(), "ProcessJob", WithAttributes(Int("job.id", 7), Bool("ok", false)))
+		span.RecordError(context.Canceled)
+		span.End()
+
+		exporter := NewOTLPExporter(server.URL+"/", "test-service")
+		if err := exporter.ExportSpans(context.Background(), []SpanData{span.data}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if path != "/v1/traces" || contentType != "application/json" {
+			t.Errorf("Expected a JSON post to /v1/traces, got %s %s", contentType, path)
+		}
+		resource := received.ResourceSpans[0]
+		if *resource.Resource.Attributes[0].Value.StringValue != "test-service" {
+			t.Errorf("Expected the service name resource attribute")
+		}
+		got := resource.ScopeSpans[0].Spans[0]
+		if got.Name != "ProcessJob" || got.TraceID != span.SpanContext().TraceID.String() || got.Status.Code != StatusError {
+			t.Errorf("Expected the failed ProcessJob span, got %+v", got)
+		}
+		if *got.Attributes[0].Value.IntValue != "7" || *got.Attributes[1].Value.BoolValue {
+			t.Errorf("Expected typed attributes, got %+v", got.Attributes)
+		}
+	})
+
+	// Edge case: Collector errors are returned
+	t.Run("CollectorError", func(t *testing.T) {
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}))
+		defer failing.Close()
+
+		if err := NewOTLPExporter(failing.URL, "svc").ExportSpans(context.Background(), nil); err == nil {
+			t.Errorf("Expected an error for a 503 response")
+		}
+	})
+}
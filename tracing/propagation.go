@@ -0,0 +1,65 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// TraceParentHeader is the W3C Trace Context header
+const TraceParentHeader = "traceparent"
+
+// FormatTraceParent encodes sc as a version 00 traceparent value, or "" if sc is invalid
+func FormatTraceParent(sc SpanContext) string {
+	if !sc.IsValid() {
+		return ""
+	}
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ParseTraceParent decodes a traceparent value. Versions other than 00 are
+// read by their first four fields, as the specification requires.
+func ParseTraceParent(value string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return SpanContext{}, false
+	}
+
+	var sc SpanContext
+	var flags [1]byte
+	if !decodeHex(sc.TraceID[:], parts[1]) || !decodeHex(sc.SpanID[:], parts[2]) || !decodeHex(flags[:], parts[3]) {
+		return SpanContext{}, false
+	}
+	sc.Sampled = flags[0]&1 == 1
+	return sc, sc.IsValid()
+}
+
+// decodeHex decodes lowercase hex of exactly len(dst) bytes
+func decodeHex(dst []byte, s string) bool {
+	if len(s) != 2*len(dst) || strings.ToLower(s) != s {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err == nil
+}
+
+// Extract returns a context carrying the span context of a traceparent
+// header, if the header is present and valid
+func Extract(ctx context.Context, header http.Header) context.Context {
+	if sc, ok := ParseTraceParent(header.Get(TraceParentHeader)); ok {
+		return ContextWithRemoteSpanContext(ctx, sc)
+	}
+	return ctx
+}
+
+// Inject sets the traceparent header from the span context in ctx
+func Inject(ctx context.Context, header http.Header) {
+	if value := FormatTraceParent(SpanContextFromContext(ctx)); value != "" {
+		header.Set(TraceParentHeader, value)
+	}
+}
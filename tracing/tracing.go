@@ -0,0 +1,273 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// TraceID identifies a trace across processes
+type TraceID [16]byte
+
+// String returns the ID in lowercase hex
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+
+// IsValid reports whether the ID is not all zeros
+func (t TraceID) IsValid() bool { return t != TraceID{} }
+
+// SpanID identifies a span within a trace
+type SpanID [8]byte
+
+// String returns the ID in lowercase hex
+func (s SpanID) String() string { return hex.EncodeToString(s[:]) }
+
+// IsValid reports whether the ID is not all zeros
+func (s SpanID) IsValid() bool { return s != SpanID{} }
+
+// SpanContext is the part of a span that is propagated to its children,
+// including those in other goroutines and processes
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// IsValid reports whether both IDs are set
+func (sc SpanContext) IsValid() bool { return sc.TraceID.IsValid() && sc.SpanID.IsValid() }
+
+// SpanKind describes the relationship of a span to its callers, with OTLP's numbering
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+// StatusCode is the outcome of a span, with OTLP's numbering
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// Attribute is a key and a string, int64, float64 or bool value
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// String returns a string attribute
+func String(key, value string) Attribute { return Attribute{key, value} }
+
+// Int returns an integer attribute
+func Int(key string, value int) Attribute { return Attribute{key, int64(value)} }
+
+// Int64 returns an integer attribute
+func Int64(key string, value int64) Attribute { return Attribute{key, value} }
+
+// Float64 returns a floating-point attribute
+func Float64(key string, value float64) Attribute { return Attribute{key, value} }
+
+// Bool returns a boolean attribute
+func Bool(key string, value bool) Attribute { return Attribute{key, value} }
+
+// SpanData is a finished span as handed to exporters
+type SpanData struct {
+	Name          string
+	SpanContext   SpanContext
+	Parent        SpanID
+	Kind          SpanKind
+	Start         time.Time
+	End           time.Time
+	Attributes    []Attribute
+	StatusCode    StatusCode
+	StatusMessage string
+}
+
+// Span is an operation being timed. A nil *Span is a valid no-op span.
+type Span struct {
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// SpanContext returns the IDs that children of the span inherit
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.data.SpanContext
+}
+
+// SetAttributes adds attributes to the span
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Attributes = append(s.data.Attributes, attrs...)
+}
+
+// SetStatus sets the outcome of the span
+func (s *Span) SetStatus(code StatusCode, message string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.StatusCode, s.data.StatusMessage = code, message
+}
+
+// RecordError marks the span as failed with err's message. A nil err is ignored.
+func (s *Span) RecordError(err error) {
+	if err != nil {
+		s.SetStatus(StatusError, err.Error())
+	}
+}
+
+// End finishes the span and hands it to the exporter if it is sampled. Only
+// the first call has an effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	data.Attributes = append([]Attribute(nil), s.data.Attributes...)
+	s.mu.Unlock()
+
+	if data.SpanContext.Sampled {
+		export(data)
+	}
+}
+
+// Option configures a span started with Start
+type Option func(*SpanData)
+
+// WithKind sets the kind of the span; spans are internal by default
+func WithKind(kind SpanKind) Option {
+	return func(d *SpanData) { d.Kind = kind }
+}
+
+// WithAttributes sets initial attributes of the span
+func WithAttributes(attrs ...Attribute) Option {
+	return func(d *SpanData) { d.Attributes = append(d.Attributes, attrs...) }
+}
+
+// WithStartTime starts the span at t instead of now
+func WithStartTime(t time.Time) Option {
+	return func(d *SpanData) { d.Start = t }
+}
+
+type spanKey struct{}
+
+type remoteKey struct{}
+
+// Start begins a span as a child of the span or remote span context in ctx,
+// or as the root of a new trace, and returns a context carrying it
+func Start(ctx context.Context, name string, opts ...Option) (context.Context, *Span) {
+	parent := SpanContextFromContext(ctx)
+	data := SpanData{Name: name, Kind: SpanKindInternal, Start: time.Now()}
+	data.SpanContext.SpanID = newSpanID()
+	if parent.IsValid() {
+		data.SpanContext.TraceID, data.SpanContext.Sampled = parent.TraceID, parent.Sampled
+		data.Parent = parent.SpanID
+	} else {
+		data.SpanContext.TraceID = newTraceID()
+		data.SpanContext.Sampled = sampled(data.SpanContext.TraceID)
+	}
+	for _, opt := range opts {
+		opt(&data)
+	}
+
+	span := &Span{data: data}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// SpanFromContext returns the span carried by ctx, or nil
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// SpanContextFromContext returns the context of the span carried by ctx, or
+// the remote span context it carries
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.SpanContext()
+	}
+	sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+	return sc
+}
+
+// ContextWithRemoteSpanContext returns a context whose spans are children of
+// sc, which was started elsewhere, e.g. by another process or an HTTP request
+// that has since finished
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	ctx = context.WithValue(ctx, spanKey{}, (*Span)(nil))
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		putUint64(id[:8], rand.Uint64())
+		putUint64(id[8:], rand.Uint64())
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		putUint64(id[:], rand.Uint64())
+	}
+	return id
+}
+
+func putUint64(b []byte, v uint64) {
+	for i := 7; i >= 0; i-- {
+		b[i] = byte(v)
+		v >>= 8
+	}
+}
+
+// sampled decides from the trace ID whether a new trace is recorded, so the
+// decision is the same wherever the trace ID is seen
+func sampled(id TraceID) bool {
+	ratio := sampleRatio()
+	if ratio >= 1 {
+		return true
+	}
+	if ratio <= 0 {
+		return false
+	}
+	var v uint64
+	for _, b := range id[8:] {
+		v = v<<8 | uint64(b)
+	}
+	return v>>11 < uint64(ratio*(1<<53))
+}
+
+// clampRatio keeps a sample ratio within [0, 1]
+func clampRatio(ratio float64) float64 {
+	if math.IsNaN(ratio) {
+		return 0
+	}
+	return math.Max(0, math.Min(1, ratio))
+}
@@ -0,0 +1,117 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestStart(t *testing.T) {
+	Setup(nil, 1)
+
+	// Normal case: Children share the trace and point at their parent
+	t.Run("ParentAndChild", func(t *testing.T) {
+		ctx, parent := Start(context.Background(), "parent")
+		_, child := Start(ctx, "child")
+
+		if !parent.SpanContext().IsValid() || !parent.SpanContext().Sampled {
+			t.Fatalf("Expected a valid sampled root span, got %+v", parent.SpanContext())
+		}
+		if child.SpanContext().TraceID != parent.SpanContext().TraceID {
+			t.Errorf("Expected the child to share the parent's trace")
+		}
+		if child.data.Parent != parent.SpanContext().SpanID || child.SpanContext().SpanID == parent.SpanContext().SpanID {
+			t.Errorf("Expected the child to have its own ID and point at the parent")
+		}
+	})
+
+	// Normal case: A remote span context parents spans in another goroutine
+	t.Run("RemoteParent", func(t *testing.T) {
+		_, origin := Start(context.Background(), "origin")
+		origin.End()
+
+		ctx := ContextWithRemoteSpanContext(context.Background(), origin.SpanContext())
+		_, span := Start(ctx, "async")
+		if span.SpanContext().TraceID != origin.SpanContext().TraceID || span.data.Parent != origin.SpanContext().SpanID {
+			t.Errorf("Expected the span to continue the origin's trace")
+		}
+	})
+
+	// Edge case: A zero sample ratio records no new traces
+	t.Run("Unsampled", func(t *testing.T) {
+		Setup(nil, 0)
+		defer Setup(nil, 1)
+
+		ctx, root := Start(context.Background(), "root")
+		_, child := Start(ctx, "child")
+		if root.SpanContext().Sampled || child.SpanContext().Sampled {
+			t.Errorf("Expected unsampled spans")
+		}
+	})
+
+	// Edge case: Nil spans are no-ops
+	t.Run("NilSpan", func(t *testing.T) {
+		var span *Span
+		span.SetAttributes(String("key", "value"))
+		span.RecordError(errors.New("failed"))
+		span.End()
+		if span.SpanContext().IsValid() {
+			t.Errorf("Expected an invalid span context for a nil span")
+		}
+	})
+
+	// Edge case: Errors set the status, and only the first End counts
+	t.Run("StatusAndEnd", func(t *testing.T) {
+		_, span := Start(context.Background(), "work")
+		span.RecordError(errors.New("boom"))
+		span.End()
+		end := span.data.End
+		span.End()
+
+		if span.data.StatusCode != StatusError || span.data.StatusMessage != "boom" {
+			t.Errorf("Expected error status boom, got %d %q", span.data.StatusCode, span.data.StatusMessage)
+		}
+		if !span.data.End.Equal(end) {
+			t.Errorf("Expected a second End to be ignored")
+		}
+	})
+}
+
+func TestTraceParent(t *testing.T) {
+	// Normal case: Values round-trip through the header
+	t.Run("RoundTrip", func(t *testing.T) {
+		_, span := Start(context.Background(), "client")
+		header := http.Header{}
+		Inject(ContextWithRemoteSpanContext(context.Background(), span.SpanContext()), header)
+
+		sc := SpanContextFromContext(Extract(context.Background(), header))
+		if sc != span.SpanContext() {
+			t.Errorf("Expected %+v, got %+v", span.SpanContext(), sc)
+		}
+	})
+
+	// Normal case: A known value is parsed
+	t.Run("Parse", func(t *testing.T) {
+		sc, ok := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		if !ok || sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID.String() != "00f067aa0ba902b7" || !sc.Sampled {
+			t.Errorf("Expected the parsed span context, got %+v %v", sc, ok)
+		}
+	})
+
+	// Edge case: Malformed values are rejected
+	t.Run("Invalid", func(t *testing.T) {
+		for _, value := range []string{
+			"",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+			"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+			"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+			"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		} {
+			if _, ok := ParseTraceParent(value); ok {
+				t.Errorf("Expected %q to be rejected", value)
+			}
+		}
+	})
+}
@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"sort"
+	"sync"
+)
+
+// Metrics holds the named numeric measurements produced by analyzers
+type Metrics map[string]float64
+
+// Analyzer computes a set of metrics for a decoded image
+type Analyzer interface {
+	Name() string
+	Analyze(img image.Image) (Metrics, error)
+}
+
+// DefaultAnalyzers are run when a job does not request any analyzers
+var DefaultAnalyzers = []string{"perimeter"}
+
+var (
+	analyzers      = make(map[string]Analyzer)
+	analyzersMutex sync.RWMutex
+)
+
+func init() {
+	RegisterAnalyzer(perimeterAnalyzer{})
+	RegisterAnalyzer(areaAnalyzer{})
+	RegisterAnalyzer(aspectRatioAnalyzer{})
+	RegisterAnalyzer(dimensionsAnalyzer{})
+	RegisterAnalyzer(meanBrightnessAnalyzer{})
+}
+
+// RegisterAnalyzer adds an analyzer to the registry, replacing any analyzer with the same name
+func RegisterAnalyzer(a Analyzer) {
+	analyzersMutex.Lock()
+	defer analyzersMutex.Unlock()
+
+	analyzers[a.Name()] = a
+}
+
+// GetAnalyzer looks up a registered analyzer by name
+func GetAnalyzer(name string) (Analyzer, bool) {
+	analyzersMutex.RLock()
+	defer analyzersMutex.RUnlock()
+
+	a, exists := analyzers[name]
+	return a, exists
+}
+
+// AnalyzerNames returns the names of all registered analyzers in sorted order
+func AnalyzerNames() []string {
+	analyzersMutex.RLock()
+	defer analyzersMutex.RUnlock()
+
+	names := make([]string, 0, len(analyzers))
+	for name := range analyzers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateAnalyzers returns an error naming the first analyzer that is not registered
+func ValidateAnalyzers(names []string) error {
+	for _, name := range names {
+		if _, exists := GetAnalyzer(name); !exists {
+			return fmt.Errorf("unknown analyzer: %s", name)
+		}
+	}
+	return nil
+}
+
+// DecodeImage decodes an image in any of the registered formats
+func DecodeImage(body io.Reader) (image.Image, string, error) {
+	return image.Decode(body)
+}
+
+// RunAnalyzers runs the named analyzers against an image and merges their metrics.
+// When names is empty, DefaultAnalyzers are used.
+func RunAnalyzers(img image.Image, names []string) (Metrics, error) {
+	if len(names) == 0 {
+		names = DefaultAnalyzers
+	}
+
+	metrics := make(Metrics)
+	for _, name := range names {
+		a, exists := GetAnalyzer(name)
+		if !exists {
+			return nil, fmt.Errorf("unknown analyzer: %s", name)
+		}
+		result, err := a.Analyze(img)
+		if err != nil {
+			return nil, fmt.Errorf("analyzer %s: %w", name, err)
+		}
+		for key, value := range result {
+			metrics[key] = value
+		}
+	}
+	return metrics, nil
+}
+
+func imageSize(img image.Image) (int, int) {
+	bounds := img.Bounds()
+	return bounds.Dx(), bounds.Dy()
+}
+
+type perimeterAnalyzer struct{}
+
+func (perimeterAnalyzer) Name() string { return "perimeter" }
+
+func (perimeterAnalyzer) Analyze(img image.Image) (Metrics, error) {
+	width, height := imageSize(img)
+	return Metrics{"perimeter": float64(2 * (width + height))}, nil
+}
+
+type areaAnalyzer struct{}
+
+func (areaAnalyzer) Name() string { return "area" }
+
+func (areaAnalyzer) Analyze(img image.Image) (Metrics, error) {
+	width, height := imageSize(img)
+	return Metrics{"area": float64(width * height)}, nil
+}
+
+type aspectRatioAnalyzer struct{}
+
+func (aspectRatioAnalyzer) Name() string { return "aspect_ratio" }
+
+func (aspectRatioAnalyzer) Analyze(img image.Image) (Metrics, error) {
+	width, height := imageSize(img)
+	if height == 0 {
+		return nil, fmt.Errorf("image has zero height")
+	}
+	return Metrics{"aspect_ratio": float64(width) / float64(height)}, nil
+}
+
+type dimensionsAnalyzer struct{}
+
+func (dimensionsAnalyzer) Name() string { return "dimensions" }
+
+func (dimensionsAnalyzer) Analyze(img image.Image) (Metrics, error) {
+	width, height := imageSize(img)
+	return Metrics{"width": float64(width), "height": float64(height)}, nil
+}
+
+type meanBrightnessAnalyzer struct{}
+
+func (meanBrightnessAnalyzer) Name() string { return "mean_brightness" }
+
+// Analyze returns the mean Rec. 601 luma of the image on a 0-255 scale
+func (meanBrightnessAnalyzer) Analyze(img image.Image) (Metrics, error) {
+	bounds := img.Bounds()
+	pixels := bounds.Dx() * bounds.Dy()
+	if pixels == 0 {
+		return nil, fmt.Errorf("image has no pixels")
+	}
+
+	var sum float64
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			sum += luminance(img.At(x, y).RGBA())
+		}
+	}
+	return Metrics{"mean_brightness": sum / float64(pixels)}, nil
+}
+
+// luminance converts 16-bit RGBA channels to an 8-bit luma value
+func luminance(r, g, b, _ uint32) float64 {
+	return (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 257
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestRunAnalyzers(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 200))
+
+	// Normal case: Default analyzers compute the perimeter
+	t.Run("DefaultAnalyzers", func(t *testing.T) {
+		metrics, err := RunAnalyzers(img, nil)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if metrics["perimeter"] != 600 {
+			t.Errorf("Expected perimeter 600, got %v", metrics["perimeter"])
+		}
+	})
+
+	// Normal case: Multiple analyzers merge their metrics
+	t.Run("MultipleAnalyzers", func(t *testing.T) {
+		metrics, err := RunAnalyzers(img, []string{"area", "aspect_ratio", "dimensions"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		expected := Metrics{"area": 20000, "aspect_ratio": 0.5, "width": 100, "height": 200}
+		for key, value := range expected {
+			if metrics[key] != value {
+				t.Errorf("Expected %s %v, got %v", key, value, metrics[key])
+			}
+		}
+		if _, exists := metrics["perimeter"]; exists {
+			t.Errorf("Expected perimeter not to be computed when not requested")
+		}
+	})
+
+	// Edge case: Unknown analyzer
+	t.Run("UnknownAnalyzer", func(t *testing.T) {
+		if _, err := RunAnalyzers(img, []string{"does_not_exist"}); err == nil {
+			t.Errorf("Expected an error for an unknown analyzer")
+		}
+	})
+}
+
+func TestMeanBrightness(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.White)
+	img.Set(1, 0, color.Black)
+
+	metrics, err := RunAnalyzers(img, []string{"mean_brightness"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := metrics["mean_brightness"]; got < 127 || got > 128 {
+		t.Errorf("Expected mean brightness of about 127.5, got %v", got)
+	}
+}
+
+func TestValidateAnalyzers(t *testing.T) {
+	if err := ValidateAnalyzers(AnalyzerNames()); err != nil {
+		t.Errorf("Expected all registered analyzers to be valid, got %v", err)
+	}
+	if err := ValidateAnalyzers([]string{"perimeter", "bogus"}); err == nil {
+		t.Errorf("Expected an error for an unregistered analyzer")
+	}
+}
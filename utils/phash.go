@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// Perceptual hash algorithms
+const (
+	HashAverage    = "ahash"
+	HashDifference = "dhash"
+	HashDCT        = "phash"
+)
+
+// DefaultHashAlgorithm is the perceptual hash stored with each image result
+var DefaultHashAlgorithm = HashDCT
+
+// PerceptualHash computes a 64-bit perceptual hash of an image with the named algorithm
+func PerceptualHash(img image.Image, algorithm string) (uint64, error) {
+	switch algorithm {
+	case HashAverage:
+		return AverageHash(img), nil
+	case HashDifference:
+		return DifferenceHash(img), nil
+	case HashDCT:
+		return DCTHash(img), nil
+	default:
+		return 0, fmt.Errorf("unknown hash algorithm: %s", algorithm)
+	}
+}
+
+// HammingDistance returns the number of differing bits between two hashes
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// AverageHash sets a bit for every pixel of an 8x8 thumbnail brighter than the mean
+func AverageHash(img image.Image) uint64 {
+	pixels := shrink(img, 8, 8)
+
+	var sum float64
+	for _, v := range pixels {
+		sum += v
+	}
+	mean := sum / float64(len(pixels))
+
+	var hash uint64
+	for i, v := range pixels {
+		if v > mean {
+			hash |= 1 << uint(i)
+		}
+	}
+	return hash
+}
+
+// DifferenceHash sets a bit wherever a pixel of a 9x8 thumbnail is brighter than its right neighbour
+func DifferenceHash(img image.Image) uint64 {
+	pixels := shrink(img, 9, 8)
+
+	var hash uint64
+	bit := 0
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if pixels[y*9+x] > pixels[y*9+x+1] {
+				hash |= 1 << uint(bit)
+			}
+			bit++
+		}
+	}
+	return hash
+}
+
+// DCTHash sets a bit for every low-frequency DCT coefficient of a 32x32
+// thumbnail above the median, which survives recompression and rescaling
+func DCTHash(img image.Image) uint64 {
+	const size, keep = 32, 8
+	pixels := shrink(img, size, size)
+
+	coefficients := make([]float64, 0, keep*keep)
+	for v := 0; v < keep; v++ {
+		for u := 0; u < keep; u++ {
+			var sum float64
+			for y := 0; y < size; y++ {
+				for x := 0; x < size; x++ {
+					sum += pixels[y*size+x] *
+						math.Cos(float64(2*x+1)*float64(u)*math.Pi/(2*size)) *
+						math.Cos(float64(2*y+1)*float64(v)*math.Pi/(2*size))
+				}
+			}
+			coefficients = append(coefficients, sum)
+		}
+	}
+
+	// The DC term only reflects overall brightness, so leave it out of the median
+	sorted := append([]float64(nil), coefficients[1:]...)
+	sort.Float64s(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+
+	var hash uint64
+	for i, c := range coefficients {
+		if c > median {
+			hash |= 1 << uint(i)
+		}
+	}
+	return hash
+}
+
+// shrink box-averages the luma of an image down to a width x height grid
+func shrink(img image.Image, width, height int) []float64 {
+	bounds := img.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	pixels := make([]float64, width*height)
+	if srcWidth == 0 || srcHeight == 0 {
+		return pixels
+	}
+
+	for ty := 0; ty < height; ty++ {
+		y0 := ty * srcHeight / height
+		y1 := max((ty+1)*srcHeight/height, y0+1)
+		for tx := 0; tx < width; tx++ {
+			x0 := tx * srcWidth / width
+			x1 := max((tx+1)*srcWidth/width, x0+1)
+
+			var sum float64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					sum += luminance(img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA())
+				}
+			}
+			pixels[ty*width+tx] = sum / float64((y1-y0)*(x1-x0))
+		}
+	}
+	return pixels
+}
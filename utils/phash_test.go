@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// gradient returns an image with a diagonal brightness gradient and a bright square
+func gradient(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := uint8((x + y) * 255 / (width + height))
+			if x > width/4 && x < width/2 && y > height/4 && y < height/2 {
+				v = 255
+			}
+			img.Set(x, y, color.RGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+	return img
+}
+
+// mirror flips an image horizontally
+func mirror(src *image.RGBA) *image.RGBA {
+	bounds := src.Bounds()
+	img := image.NewRGBA(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			img.Set(bounds.Max.X-1-x+bounds.Min.X, y, src.At(x, y))
+		}
+	}
+	return img
+}
+
+func TestPerceptualHash(t *testing.T) {
+	original := gradient(256, 256)
+
+	// Recompress at low quality to simulate a re-uploaded photo
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, original, &jpeg.Options{Quality: 30})
+	recompressed, _, err := DecodeImage(&buf)
+	if err != nil {
+		t.Fatalf("Expected no error decoding recompressed image, got %v", err)
+	}
+	different := mirror(original)
+
+	for _, algorithm := range []string{HashAverage, HashDifference, HashDCT} {
+		t.Run(algorithm, func(t *testing.T) {
+			a, err := PerceptualHash(original, algorithm)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			b, _ := PerceptualHash(recompressed, algorithm)
+			c, _ := PerceptualHash(different, algorithm)
+
+			if d := HammingDistance(a, b); d > 6 {
+				t.Errorf("Expected recompressed image to be within distance 6, got %d", d)
+			}
+			if d := HammingDistance(a, c); d <= 6 {
+				t.Errorf("Expected different image to be further than distance 6, got %d", d)
+			}
+		})
+	}
+
+	// Edge case: Unknown algorithm
+	if _, err := PerceptualHash(original, "md5"); err == nil {
+		t.Errorf("Expected an error for an unknown hash algorithm")
+	}
+}
+
+func TestHammingDistance(t *testing.T) {
+	if d := HammingDistance(0, 0); d != 0 {
+		t.Errorf("Expected distance 0, got %d", d)
+	}
+	if d := HammingDistance(0b1011, 0b0001); d != 2 {
+		t.Errorf("Expected distance 2, got %d", d)
+	}
+}
@@ -0,0 +1,251 @@
+package utils
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"slices"
+	"sync"
+)
+
+// QualityThresholds configures when an image is rejected by the quality checks.
+// A zero value disables the corresponding check.
+type QualityThresholds struct {
+	MinSharpness       float64 `json:"min_sharpness"`
+	MinMeanLuminance   float64 `json:"min_mean_luminance"`
+	MaxMeanLuminance   float64 `json:"max_mean_luminance"`
+	MaxClippedFraction float64 `json:"max_clipped_fraction"`
+	MinMegapixels      float64 `json:"min_megapixels"`
+	MinShortSide       float64 `json:"min_short_side"`
+}
+
+// DefaultQualityThresholds are used when a job does not provide its own thresholds
+var DefaultQualityThresholds = QualityThresholds{
+	MinSharpness:       100,
+	MinMeanLuminance:   40,
+	MaxMeanLuminance:   220,
+	MaxClippedFraction: 0.5,
+	MinMegapixels:      0.3,
+	MinShortSide:       480,
+}
+
+// minSharpnessSide is the smallest width and height the Laplacian kernel of
+// the sharpness analyzer can measure
+const minSharpnessSide = 3
+
+// Luma levels at or beyond which a pixel counts as crushed or blown out
+const (
+	underexposedLuma = 16
+	overexposedLuma  = 239
+)
+
+func init() {
+	RegisterAnalyzer(sharpnessAnalyzer{})
+	RegisterAnalyzer(exposureAnalyzer{})
+	RegisterAnalyzer(resolutionAnalyzer{})
+}
+
+// AnalyzeImage runs the named analyzers, or DefaultAnalyzers when names is
+// empty, together with the quality analyzers the non-zero thresholds need. It
+// returns the metrics and a reason for every failed quality check; an image
+// too small to measure its sharpness fails with a resolution reason. The
+// analyzers share one luma plane of the image.
+func AnalyzeImage(img image.Image, names []string, t QualityThresholds) (Metrics, []string, error) {
+	if len(names) == 0 {
+		names = DefaultAnalyzers
+	}
+	names = append([]string(nil), names...)
+	for _, name := range t.analyzers() {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	img = &lumaImage{Image: img}
+	var reasons []string
+	if width, height := imageSize(img); width < minSharpnessSide || height < minSharpnessSide {
+		measured := names[:0]
+		for _, name := range names {
+			if name != "sharpness" {
+				measured = append(measured, name)
+			}
+		}
+		if len(measured) < len(names) {
+			reasons = append(reasons, fmt.Sprintf("image too small to measure sharpness (%dx%d)", width, height))
+		}
+		names = measured
+	}
+
+	metrics, err := RunAnalyzers(img, names)
+	if err != nil {
+		return nil, nil, err
+	}
+	return metrics, append(reasons, CheckQuality(metrics, t)...), nil
+}
+
+// analyzers returns the quality analyzers computing the metrics checked by
+// the non-zero thresholds
+func (t QualityThresholds) analyzers() []string {
+	var names []string
+	if t.MinSharpness > 0 {
+		names = append(names, "sharpness")
+	}
+	if t.MinMeanLuminance > 0 || t.MaxMeanLuminance > 0 || t.MaxClippedFraction > 0 {
+		names = append(names, "exposure")
+	}
+	if t.MinMegapixels > 0 || t.MinShortSide > 0 {
+		names = append(names, "resolution")
+	}
+	return names
+}
+
+// CheckQuality compares the quality metrics present in metrics against the
+// thresholds and returns a reason for every failed check. Metrics that were
+// not computed are skipped; AnalyzeImage computes those the thresholds need.
+func CheckQuality(metrics Metrics, t QualityThresholds) []string {
+	var reasons []string
+
+	if v, ok := metrics["sharpness"]; ok && t.MinSharpness > 0 && v < t.MinSharpness {
+		reasons = append(reasons, fmt.Sprintf("image is blurry (sharpness %.1f < %.1f)", v, t.MinSharpness))
+	}
+	if v, ok := metrics["mean_luminance"]; ok {
+		if t.MinMeanLuminance > 0 && v < t.MinMeanLuminance {
+			reasons = append(reasons, fmt.Sprintf("image is underexposed (mean luminance %.1f < %.1f)", v, t.MinMeanLuminance))
+		}
+		if t.MaxMeanLuminance > 0 && v > t.MaxMeanLuminance {
+			reasons = append(reasons, fmt.Sprintf("image is overexposed (mean luminance %.1f > %.1f)", v, t.MaxMeanLuminance))
+		}
+	}
+	if v, ok := metrics["underexposed_fraction"]; ok && t.MaxClippedFraction > 0 && v > t.MaxClippedFraction {
+		reasons = append(reasons, fmt.Sprintf("too many dark pixels (%.0f%% > %.0f%%)", v*100, t.MaxClippedFraction*100))
+	}
+	if v, ok := metrics["overexposed_fraction"]; ok && t.MaxClippedFraction > 0 && v > t.MaxClippedFraction {
+		reasons = append(reasons, fmt.Sprintf("too many blown-out pixels (%.0f%% > %.0f%%)", v*100, t.MaxClippedFraction*100))
+	}
+	if v, ok := metrics["megapixels"]; ok && t.MinMegapixels > 0 && v < t.MinMegapixels {
+		reasons = append(reasons, fmt.Sprintf("resolution too low (%.2f MP < %.2f MP)", v, t.MinMegapixels))
+	}
+	if v, ok := metrics["short_side"]; ok && t.MinShortSide > 0 && v < t.MinShortSide {
+		reasons = append(reasons, fmt.Sprintf("image too small (short side %.0fpx < %.0fpx)", v, t.MinShortSide))
+	}
+
+	return reasons
+}
+
+// lumaImage is an image whose luma plane is computed at most once, so the
+// quality analyzers run by AnalyzeImage share it
+type lumaImage struct {
+	image.Image
+	once sync.Once
+	luma []float32
+}
+
+// grayscale returns the row-major luma plane of an image
+func grayscale(img image.Image) ([]float32, int, int) {
+	width, height := imageSize(img)
+	if l, ok := img.(*lumaImage); ok {
+		l.once.Do(func() { l.luma = lumaPlane(l.Image) })
+		return l.luma, width, height
+	}
+	return lumaPlane(img), width, height
+}
+
+// lumaPlane computes the luma of every pixel, reading the pixel buffers of
+// the types image.Decode returns directly instead of boxing each pixel
+func lumaPlane(img image.Image) []float32 {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	luma := make([]float32, width*height)
+	for y := 0; y < height; y++ {
+		row := luma[y*width : (y+1)*width]
+		switch src := img.(type) {
+		case *image.Gray:
+			for x, v := range src.Pix[src.PixOffset(bounds.Min.X, bounds.Min.Y+y):][:width] {
+				row[x] = float32(v)
+			}
+		case *image.YCbCr:
+			for x := range row {
+				px, py := bounds.Min.X+x, bounds.Min.Y+y
+				c := color.YCbCr{Y: src.Y[src.YOffset(px, py)], Cb: src.Cb[src.COffset(px, py)], Cr: src.Cr[src.COffset(px, py)]}
+				row[x] = float32(luminance(c.RGBA()))
+			}
+		case *image.RGBA:
+			for x := range row {
+				i := src.PixOffset(bounds.Min.X+x, bounds.Min.Y+y)
+				r, g, b := uint32(src.Pix[i]), uint32(src.Pix[i+1]), uint32(src.Pix[i+2])
+				row[x] = float32(luminance(r*257, g*257, b*257, 0))
+			}
+		default:
+			for x := range row {
+				row[x] = float32(luminance(img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()))
+			}
+		}
+	}
+	return luma
+}
+
+type sharpnessAnalyzer struct{}
+
+func (sharpnessAnalyzer) Name() string { return "sharpness" }
+
+// Analyze returns the variance of the Laplacian of the luma channel; low values indicate blur
+func (sharpnessAnalyzer) Analyze(img image.Image) (Metrics, error) {
+	gray, width, height := grayscale(img)
+	if width < minSharpnessSide || height < minSharpnessSide {
+		return nil, fmt.Errorf("image too small to measure sharpness")
+	}
+
+	var sum, sumSquares float64
+	n := float64((width - 2) * (height - 2))
+	for y := 1; y < height-1; y++ {
+		for x := 1; x < width-1; x++ {
+			i := y*width + x
+			laplacian := float64(gray[i-width] + gray[i+width] + gray[i-1] + gray[i+1] - 4*gray[i])
+			sum += laplacian
+			sumSquares += laplacian * laplacian
+		}
+	}
+	mean := sum / n
+	return Metrics{"sharpness": sumSquares/n - mean*mean}, nil
+}
+
+type exposureAnalyzer struct{}
+
+func (exposureAnalyzer) Name() string { return "exposure" }
+
+func (exposureAnalyzer) Analyze(img image.Image) (Metrics, error) {
+	gray, width, height := grayscale(img)
+	if width == 0 || height == 0 {
+		return nil, fmt.Errorf("image has no pixels")
+	}
+
+	var sum float64
+	var under, over int
+	for _, v := range gray {
+		sum += float64(v)
+		if v <= underexposedLuma {
+			under++
+		}
+		if v >= overexposedLuma {
+			over++
+		}
+	}
+	n := float64(len(gray))
+	return Metrics{
+		"mean_luminance":        sum / n,
+		"underexposed_fraction": float64(under) / n,
+		"overexposed_fraction":  float64(over) / n,
+	}, nil
+}
+
+type resolutionAnalyzer struct{}
+
+func (resolutionAnalyzer) Name() string { return "resolution" }
+
+func (resolutionAnalyzer) Analyze(img image.Image) (Metrics, error) {
+	width, height := imageSize(img)
+	return Metrics{
+		"megapixels": float64(width*height) / 1e6,
+		"short_side": float64(min(width, height)),
+	}, nil
+}
@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// checkerboard returns a high-contrast image that is maximally sharp
+func checkerboard(width, height int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if (x+y)%2 == 0 {
+				img.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+	return img
+}
+
+func TestSharpnessAnalyzer(t *testing.T) {
+	sharp, err := RunAnalyzers(checkerboard(10, 10), []string{"sharpness"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	flat, err := RunAnalyzers(image.NewGray(image.Rect(0, 0, 10, 10)), []string{"sharpness"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if flat["sharpness"] != 0 {
+		t.Errorf("Expected a flat image to have zero sharpness, got %v", flat["sharpness"])
+	}
+	if sharp["sharpness"] <= flat["sharpness"] {
+		t.Errorf("Expected checkerboard to be sharper than a flat image")
+	}
+
+	// Edge case: Image too small for the Laplacian kernel
+	if _, err := RunAnalyzers(image.NewGray(image.Rect(0, 0, 2, 2)), []string{"sharpness"}); err == nil {
+		t.Errorf("Expected an error for an image smaller than the kernel")
+	}
+}
+
+func TestCheckQuality(t *testing.T) {
+	// Normal case: A dark, blurry, tiny image fails every check
+	t.Run("RejectsPoorImage", func(t *testing.T) {
+		img := image.NewRGBA(image.Rect(0, 0, 100, 200))
+		metrics, err := RunAnalyzers(img, []string{"sharpness", "exposure", "resolution"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		reasons := CheckQuality(metrics, DefaultQualityThresholds)
+		// blurry, underexposed, too many dark pixels, low megapixels, short side
+		if len(reasons) != 5 {
+			t.Errorf("Expected 5 reasons, got %d: %v", len(reasons), reasons)
+		}
+	})
+
+	// Normal case: Metrics within thresholds pass
+	t.Run("AcceptsGoodImage", func(t *testing.T) {
+		metrics := Metrics{
+			"sharpness":             500,
+			"mean_luminance":        120,
+			"underexposed_fraction": 0.05,
+			"overexposed_fraction":  0.05,
+			"megapixels":            2,
+			"short_side":            1080,
+		}
+		if reasons := CheckQuality(metrics, DefaultQualityThresholds); len(reasons) != 0 {
+			t.Errorf("Expected no reasons, got %v", reasons)
+		}
+	})
+
+	// Edge case: Checks are skipped when quality analyzers were not requested
+	t.Run("SkipsMissingMetrics", func(t *testing.T) {
+		if reasons := CheckQuality(Metrics{"perimeter": 600}, DefaultQualityThresholds); len(reasons) != 0 {
+			t.Errorf("Expected no reasons, got %v", reasons)
+		}
+	})
+
+	// Edge case: Zero thresholds disable the checks
+	t.Run("ZeroThresholdsDisableChecks", func(t *testing.T) {
+		metrics := Metrics{"sharpness": 0, "mean_luminance": 0, "megapixels": 0}
+		if reasons := CheckQuality(metrics, QualityThresholds{}); len(reasons) != 0 {
+			t.Errorf("Expected no reasons, got %v", reasons)
+		}
+	})
+}
+
+func TestAnalyzeImage(t *testing.T) {
+	// Normal case: The quality analyzers run by default and reject a dark, blurry, tiny image
+	t.Run("DefaultThresholds", func(t *testing.T) {
+		metrics, reasons, err := AnalyzeImage(image.NewRGBA(image.Rect(0, 0, 100, 200)), nil, DefaultQualityThresholds)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if metrics["perimeter"] != 600 {
+			t.Errorf("Expected the default analyzers to run, got %v", metrics)
+		}
+		for _, metric := range []string{"sharpness", "mean_luminance", "short_side"} {
+			if _, exists := metrics[metric]; !exists {
+				t.Errorf("Expected %s to be measured for the thresholds, got %v", metric, metrics)
+			}
+		}
+		if len(reasons) != 5 {
+			t.Errorf("Expected 5 reasons, got %d: %v", len(reasons), reasons)
+		}
+	})
+
+	// Normal case: Zero thresholds add no quality analyzers
+	t.Run("ZeroThresholds", func(t *testing.T) {
+		metrics, reasons, err := AnalyzeImage(image.NewRGBA(image.Rect(0, 0, 100, 200)), []string{"area"}, QualityThresholds{})
+		if err != nil || len(reasons) != 0 || len(metrics) != 1 {
+			t.Errorf("Expected only the area metric and no reasons, got %v, %v, %v", metrics, reasons, err)
+		}
+	})
+
+	// Edge case: An image too small for the sharpness kernel is rejected, not failed
+	t.Run("TooSmallForSharpness", func(t *testing.T) {
+		metrics, reasons, err := AnalyzeImage(checkerboard(2, 2), nil, QualityThresholds{MinSharpness: 100})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(reasons) != 1 || reasons[0] != "image too small to measure sharpness (2x2)" {
+			t.Errorf("Expected a resolution reason, got %v", reasons)
+		}
+		if metrics["perimeter"] != 8 {
+			t.Errorf("Expected the other metrics to be measured, got %v", metrics)
+		}
+	})
+}
+
+// opaqueImage hides the concrete type of an image, forcing the generic At path
+type opaqueImage struct{ image.Image }
+
+func TestLumaPlane(t *testing.T) {
+	rgba := image.NewRGBA(image.Rect(0, 0, 8, 6))
+	for y := 0; y < 6; y++ {
+		for x := 0; x < 8; x++ {
+			rgba.Set(x, y, color.RGBA{R: uint8(x * 30), G: uint8(y * 40), B: uint8(x * y * 5), A: 255})
+		}
+	}
+	ycbcr := image.NewYCbCr(image.Rect(0, 0, 8, 6), image.YCbCrSubsampleRatio420)
+	for i := range ycbcr.Y {
+		ycbcr.Y[i] = uint8(i * 5)
+	}
+	for i := range ycbcr.Cb {
+		ycbcr.Cb[i], ycbcr.Cr[i] = uint8(100+i*10), uint8(200-i*10)
+	}
+
+	// Normal case: The pixel buffer fast paths match the generic path, including sub-images
+	for name, img := range map[string]image.Image{
+		"Gray":     checkerboard(8, 6),
+		"RGBA":     rgba,
+		"SubRGBA":  rgba.SubImage(image.Rect(2, 1, 7, 5)),
+		"YCbCr":    ycbcr,
+		"SubYCbCr": ycbcr.SubImage(image.Rect(1, 1, 6, 6)),
+	} {
+		fast, want := lumaPlane(img), lumaPlane(opaqueImage{img})
+		if len(fast) != len(want) {
+			t.Fatalf("%s: Expected %d values, got %d", name, len(want), len(fast))
+		}
+		for i := range want {
+			if diff := fast[i] - want[i]; diff > 1e-3 || diff < -1e-3 {
+				t.Errorf("%s: Expected luma %v at %d, got %v", name, want[i], i, fast[i])
+				break
+			}
+		}
+	}
+
+	// Normal case: Analyzers run by AnalyzeImage share one luma plane
+	img := &lumaImage{Image: checkerboard(8, 6)}
+	first, _, _ := grayscale(img)
+	second, _, _ := grayscale(img)
+	if &first[0] != &second[0] {
+		t.Errorf("Expected the luma plane to be computed once")
+	}
+}
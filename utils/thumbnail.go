@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+)
+
+// Thumbnail box-downsamples an image so its longest side is at most maxSide
+// pixels, preserving the aspect ratio. Smaller images are returned unchanged.
+func Thumbnail(img image.Image, maxSide int) image.Image {
+	bounds := img.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	if srcWidth <= maxSide && srcHeight <= maxSide {
+		return img
+	}
+
+	width, height := maxSide, maxSide
+	if srcWidth > srcHeight {
+		height = max(srcHeight*maxSide/srcWidth, 1)
+	} else {
+		width = max(srcWidth*maxSide/srcHeight, 1)
+	}
+
+	thumb := image.NewRGBA(image.Rect(0, 0, width, height))
+	for ty := 0; ty < height; ty++ {
+		y0 := ty * srcHeight / height
+		y1 := max((ty+1)*srcHeight/height, y0+1)
+		for tx := 0; tx < width; tx++ {
+			x0 := tx * srcWidth / width
+			x1 := max((tx+1)*srcWidth/width, x0+1)
+
+			var r, g, b, a uint64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					pr, pg, pb, pa := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+				}
+			}
+			n := uint64((y1 - y0) * (x1 - x0))
+			thumb.SetRGBA64(tx, ty, color.RGBA64{
+				R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n),
+			})
+		}
+	}
+	return thumb
+}
@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"image"
+	"testing"
+)
+
+func TestThumbnail(t *testing.T) {
+	// Normal case: Landscape image is scaled to the max side
+	thumb := Thumbnail(image.NewRGBA(image.Rect(0, 0, 1000, 500)), 200)
+	if w, h := thumb.Bounds().Dx(), thumb.Bounds().Dy(); w != 200 || h != 100 {
+		t.Errorf("Expected 200x100 thumbnail, got %dx%d", w, h)
+	}
+
+	// Normal case: Portrait image is scaled to the max side
+	thumb = Thumbnail(image.NewRGBA(image.Rect(0, 0, 300, 1200)), 200)
+	if w, h := thumb.Bounds().Dx(), thumb.Bounds().Dy(); w != 50 || h != 200 {
+		t.Errorf("Expected 50x200 thumbnail, got %dx%d", w, h)
+	}
+
+	// Edge case: Small images are not upscaled
+	small := image.NewRGBA(image.Rect(0, 0, 64, 32))
+	if thumb := Thumbnail(small, 200); thumb != image.Image(small) {
+		t.Errorf("Expected small image to be returned unchanged")
+	}
+}
@@ -0,0 +1,150 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// EgressPolicy decides which image URLs the worker may download. Hosts match
+// themselves and their subdomains; a denied host is blocked even when it is
+// also allowed. Private, loopback and link-local addresses are blocked after
+// DNS resolution unless AllowPrivateIPs is set.
+type EgressPolicy struct {
+	Schemes         []string
+	AllowHosts      []string // empty allows every host that is not denied
+	DenyHosts       []string
+	AllowPrivateIPs bool
+}
+
+// Egress is the policy image downloads and their redirects are checked against
+var Egress = EgressPolicy{Schemes: []string{"http", "https"}}
+
+// URLNotAllowedError is returned for URLs and addresses blocked by Egress
+type URLNotAllowedError struct {
+	Reason string
+}
+
+func (e *URLNotAllowedError) Error() string {
+	return "url not allowed: " + e.Reason
+}
+
+// maxRedirects is how many redirects a download follows
+const maxRedirects = 10
+
+// blockedPrefixes are special-purpose ranges not covered by the netip.Addr
+// predicates. The NAT64 prefixes are blocked because they can embed private
+// IPv4 addresses.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),          // "this network"
+	netip.MustParsePrefix("100.64.0.0/10"),      // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),       // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),      // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),        // reserved
+	netip.MustParsePrefix("255.255.255.255/32"), // limited broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),       // NAT64
+	netip.MustParsePrefix("64:ff9b:1::/48"),     // local-use NAT64
+}
+
+// newHTTPClient returns a client that applies Egress to every request,
+// redirect and dialed address. It does not use a proxy, since the policy
+// could only check the proxy's address.
+func newHTTPClient() *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: checkDialedAddress}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Transport: transport, CheckRedirect: checkRedirect}
+}
+
+// CheckURL reports whether the scheme and host of u are allowed
+func (p EgressPolicy) CheckURL(u *url.URL) error {
+	if !containsFold(p.Schemes, u.Scheme) {
+		return &URLNotAllowedError{Reason: fmt.Sprintf("scheme %q is not allowed", u.Scheme)}
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return &URLNotAllowedError{Reason: "missing host"}
+	}
+	if matchesHost(p.DenyHosts, host) {
+		return &URLNotAllowedError{Reason: fmt.Sprintf("host %q is denied", host)}
+	}
+	if len(p.AllowHosts) > 0 && !matchesHost(p.AllowHosts, host) {
+		return &URLNotAllowedError{Reason: fmt.Sprintf("host %q is not in the allow list", host)}
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return p.CheckIP(addr)
+	}
+	return nil
+}
+
+// CheckIP reports whether the worker may connect to addr
+func (p EgressPolicy) CheckIP(addr netip.Addr) error {
+	if p.AllowPrivateIPs {
+		return nil
+	}
+	addr = addr.Unmap()
+	blocked := addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified()
+	for _, prefix := range blockedPrefixes {
+		blocked = blocked || prefix.Contains(addr)
+	}
+	if blocked {
+		return &URLNotAllowedError{Reason: fmt.Sprintf("address %s is not public", addr)}
+	}
+	return nil
+}
+
+// checkRedirect applies Egress to each redirect target
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	return Egress.CheckURL(req.URL)
+}
+
+// checkDialedAddress applies Egress to the resolved address a connection is
+// about to be made to, so hostnames resolving to private addresses are caught
+func checkDialedAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return &URLNotAllowedError{Reason: fmt.Sprintf("unresolved address %q", host)}
+	}
+	return Egress.CheckIP(addr)
+}
+
+// urlNotAllowed returns the policy error wrapped in err, if any
+func urlNotAllowed(err error) (*URLNotAllowedError, bool) {
+	var notAllowed *URLNotAllowedError
+	return notAllowed, errors.As(err, &notAllowed)
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesHost reports whether host is one of hosts or a subdomain of one
+func matchesHost(hosts []string, host string) bool {
+	for _, h := range hosts {
+		h = strings.TrimPrefix(strings.TrimSuffix(strings.ToLower(h), "."), "*.")
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+package worker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"testing"
+
+	"backend-intern-assignment/models"
+)
+
+func TestEgressPolicy(t *testing.T) {
+	policy := EgressPolicy{
+		Schemes:    []string{"http", "https"},
+		AllowHosts: []string{"images.example.com", "cdn.example.net"},
+		DenyHosts:  []string{"private.cdn.example.net"},
+	}
+	tests := []struct {
+		url     string
+		allowed bool
+	}{
+		// Normal case: Allowed hosts and their subdomains
+		{"https://images.example.com/a.jpg", true},
+		{"http://eu.cdn.example.net/a.jpg", true},
+		{"https://IMAGES.example.com./a.jpg", true},
+		// Edge case: Other schemes, hosts outside the allow list and denied subdomains
+		{"ftp://images.example.com/a.jpg", false},
+		{"file:///etc/passwd", false},
+		{"https://example.com/a.jpg", false},
+		{"https://images.example.com.evil.io/a.jpg", false},
+		{"https://private.cdn.example.net/a.jpg", false},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		if err := policy.CheckURL(u); (err == nil) != tt.allowed {
+			t.Errorf("Expected %s allowed=%v, got %v", tt.url, tt.allowed, err)
+		}
+	}
+
+	// Edge case: Private, loopback and link-local address literals are blocked
+	open := EgressPolicy{Schemes: []string{"http", "https"}}
+	for _, raw := range []string{
+		"http://169.254.169.254/latest/meta-data/",
+		"http://127.0.0.1:8080/",
+		"http://10.0.0.5/",
+		"http://192.168.1.1/",
+		"http://100.64.0.1/",
+		"http://0.0.0.0/",
+		"http://[::1]/",
+		"http://[fe80::1]/",
+		"http://[fd00::1]/",
+		"http://[::ffff:127.0.0.1]/",
+	} {
+		u, _ := url.Parse(raw)
+		if err := open.CheckURL(u); err == nil {
+			t.Errorf("Expected %s to be blocked", raw)
+		}
+	}
+
+	// Edge case: Reserved, broadcast and NAT64 addresses are blocked
+	for _, raw := range []string{
+		"192.0.0.1",
+		"192.0.0.170",
+		"240.0.0.1",
+		"250.1.2.3",
+		"255.255.255.255",
+		"64:ff9b::a00:1",
+		"64:ff9b::7f00:1",
+		"64:ff9b:1::a9fe:a9fe",
+		"::ffff:255.255.255.255",
+	} {
+		if err := open.CheckIP(netip.MustParseAddr(raw)); err == nil {
+			t.Errorf("Expected %s to be blocked", raw)
+		}
+	}
+
+	// Normal case: Public addresses next to the blocked ranges are allowed
+	for _, raw := range []string{"192.0.1.1", "223.255.255.254", "64:ff9c::a00:1", "2001:4860:4860::8888"} {
+		if err := open.CheckIP(netip.MustParseAddr(raw)); err != nil {
+			t.Errorf("Expected %s to be allowed, got %v", raw, err)
+		}
+	}
+
+	// Normal case: Public addresses, and private ones once allowed
+	u, _ := url.Parse("http://93.184.216.34/a.jpg")
+	if err := open.CheckURL(u); err != nil {
+		t.Errorf("Expected a public address to be allowed, got %v", err)
+	}
+	open.AllowPrivateIPs = true
+	u, _ = url.Parse("http://10.0.0.5/a.jpg")
+	if err := open.CheckURL(u); err != nil {
+		t.Errorf("Expected a private address to be allowed, got %v", err)
+	}
+}
+
+func TestEgressClient(t *testing.T) {
+	originalEgress := Egress
+	defer func() { Egress = originalEgress }()
+	client := newHTTPClient()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, r.URL.Query().Get("to"), http.StatusFound)
+	}))
+	defer server.Close()
+	// localhost is not an address literal, so only the resolved address reveals loopback
+	localURL := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+
+	// Edge case: Hostnames are checked after DNS resolution
+	t.Run("ResolvedLoopback", func(t *testing.T) {
+		Egress = EgressPolicy{Schemes: []string{"http"}}
+		_, err := client.Get(localURL + "/?to=/")
+		if _, blocked := urlNotAllowed(err); !blocked {
+			t.Errorf("Expected the loopback address to be blocked, got %v", err)
+		}
+	})
+
+	// Edge case: Redirects are checked against the policy
+	t.Run("Redirect", func(t *testing.T) {
+		Egress = EgressPolicy{Schemes: []string{"http"}, DenyHosts: []string{"metadata.internal"}, AllowPrivateIPs: true}
+		for _, target := range []string{"http://metadata.internal/latest", "ftp://localhost/a.jpg"} {
+			_, err := client.Get(localURL + "/?to=" + url.QueryEscape(target))
+			if _, blocked := urlNotAllowed(err); !blocked {
+				t.Errorf("Expected the redirect to %s to be blocked, got %v", target, err)
+			}
+		}
+	})
+
+	// Normal case: Allowed redirects are followed
+	t.Run("AllowedRedirect", func(t *testing.T) {
+		Egress = EgressPolicy{Schemes: []string{"http"}, AllowPrivateIPs: true}
+		resp, err := client.Get(localURL + "/?to=" + url.QueryEscape(server.URL+"/?to=/"))
+		if err == nil {
+			resp.Body.Close()
+		}
+		if _, blocked := urlNotAllowed(err); blocked {
+			t.Errorf("Expected the redirect to be followed, got %v", err)
+		}
+	})
+}
+
+func TestProcessJobURLNotAllowed(t *testing.T) {
+	initTestStoreMaster()
+	originalTransport := HTTPClient.Transport
+	requests := 0
+	HTTPClient.Transport = &MockTransport{RoundTripFunc: func(req *http.Request) (*http.Response, error) {
+		requests++
+		return nil, http.ErrHandlerTimeout
+	}}
+	defer func() { HTTPClient.Transport = originalTransport }()
+
+	// Edge case: Blocked image URLs are recorded as url_not_allowed without a request
+	jobID := models.CreateJob(models.JobRequest{
+		Count: 1,
+		Visits: []models.Visit{{
+			StoreID:   "RP00001",
+			ImageURLs: []string{"http://169.254.169.254/latest/meta-data/"},
+			VisitTime: "2023-10-21T15:04:05Z",
+		}},
+	})
+	ProcessJob(jobID)
+
+	job, _ := models.SnapshotJob(jobID)
+	if requests != 0 {
+		t.Errorf("Expected no request to be made, got %d", requests)
+	}
+	if len(job.Errors) != 1 || job.Errors[0].Error != models.ErrURLNotAllowed || job.Errors[0].Reason == "" {
+		t.Errorf("Expected one url_not_allowed error with a reason, got %+v", job.Errors)
+	}
+}
@@ -0,0 +1,146 @@
+package worker
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Clock abstracts time so tests can run the worker without real delays
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time        { return time.Now() }
+func (systemClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// SystemClock is the wall clock
+var SystemClock Clock = systemClock{}
+
+// GPUStage decides how long the simulated GPU processing of an image takes
+type GPUStage interface {
+	Delay() time.Duration
+}
+
+// GPU simulation modes
+const (
+	GPUModeNone      = "none"
+	GPUModeFixed     = "fixed"
+	GPUModeUniform   = "uniform"
+	GPUModeRealistic = "realistic"
+)
+
+// GPUConfig selects and parameterizes a GPU stage
+type GPUConfig struct {
+	Mode   string
+	Fixed  time.Duration
+	Min    time.Duration
+	Max    time.Duration
+	Median time.Duration
+	Sigma  float64
+	Seed   int64
+}
+
+// DefaultGPUConfig matches the original 100-400ms uniform delay
+var DefaultGPUConfig = GPUConfig{
+	Mode:   GPUModeUniform,
+	Fixed:  250 * time.Millisecond,
+	Min:    100 * time.Millisecond,
+	Max:    400 * time.Millisecond,
+	Median: 200 * time.Millisecond,
+	Sigma:  0.5,
+}
+
+// NewGPUStage builds the GPU stage described by cfg. A zero seed uses the current time.
+func NewGPUStage(cfg GPUConfig) (GPUStage, error) {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	switch cfg.Mode {
+	case GPUModeNone:
+		return NoGPUDelay{}, nil
+	case GPUModeFixed:
+		if cfg.Fixed < 0 {
+			return nil, fmt.Errorf("fixed GPU delay must not be negative")
+		}
+		return FixedGPUDelay{Duration: cfg.Fixed}, nil
+	case GPUModeUniform:
+		if cfg.Min < 0 || cfg.Max < cfg.Min {
+			return nil, fmt.Errorf("uniform GPU delay needs 0 <= min <= max")
+		}
+		return NewUniformGPUDelay(cfg.Min, cfg.Max, seed), nil
+	case GPUModeRealistic:
+		if cfg.Median <= 0 || cfg.Sigma < 0 || cfg.Max < 0 {
+			return nil, fmt.Errorf("realistic GPU delay needs a positive median and non-negative sigma and max")
+		}
+		return NewRealisticGPUDelay(cfg.Median, cfg.Sigma, cfg.Max, seed), nil
+	default:
+		return nil, fmt.Errorf("unknown GPU simulation mode: %s", cfg.Mode)
+	}
+}
+
+// NoGPUDelay skips the GPU simulation entirely
+type NoGPUDelay struct{}
+
+func (NoGPUDelay) Delay() time.Duration { return 0 }
+
+// FixedGPUDelay always takes the same time
+type FixedGPUDelay struct {
+	Duration time.Duration
+}
+
+func (f FixedGPUDelay) Delay() time.Duration { return f.Duration }
+
+// UniformGPUDelay draws delays uniformly from [Min, Max] with a seeded generator
+type UniformGPUDelay struct {
+	Min, Max time.Duration
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// NewUniformGPUDelay returns a uniform GPU stage whose sequence of delays is fixed by seed
+func NewUniformGPUDelay(minDelay, maxDelay time.Duration, seed int64) *UniformGPUDelay {
+	return &UniformGPUDelay{Min: minDelay, Max: maxDelay, rng: rand.New(rand.NewSource(seed))}
+}
+
+func (u *UniformGPUDelay) Delay() time.Duration {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return u.Min + time.Duration(u.rng.Int63n(int64(u.Max-u.Min)+1))
+}
+
+// RealisticGPUDelay draws log-normally distributed delays around Median, which
+// gives the long tail of a shared GPU. Delays are capped at Max when it is set.
+type RealisticGPUDelay struct {
+	Median time.Duration
+	Sigma  float64
+	Max    time.Duration
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// NewRealisticGPUDelay returns a log-normal GPU stage whose sequence of delays is fixed by seed
+func NewRealisticGPUDelay(median time.Duration, sigma float64, maxDelay time.Duration, seed int64) *RealisticGPUDelay {
+	return &RealisticGPUDelay{Median: median, Sigma: sigma, Max: maxDelay, rng: rand.New(rand.NewSource(seed))}
+}
+
+func (r *RealisticGPUDelay) Delay() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delay := time.Duration(float64(r.Median) * math.Exp(r.Sigma*r.rng.NormFloat64()))
+	if r.Max > 0 && delay > r.Max {
+		delay = r.Max
+	}
+	return delay
+}
@@ -0,0 +1,84 @@
+package worker
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock advances instantly instead of sleeping
+type fakeClock struct {
+	now    time.Time
+	slept  time.Duration
+	sleeps int
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.now = c.now.Add(d)
+	c.slept += d
+	c.sleeps++
+}
+
+func TestNewGPUStage(t *testing.T) {
+	// Normal case: Every mode builds a stage
+	for _, mode := range []string{GPUModeNone, GPUModeFixed, GPUModeUniform, GPUModeRealistic} {
+		cfg := DefaultGPUConfig
+		cfg.Mode = mode
+		if _, err := NewGPUStage(cfg); err != nil {
+			t.Errorf("Expected no error for mode %s, got %v", mode, err)
+		}
+	}
+
+	// Edge case: Unknown mode
+	if _, err := NewGPUStage(GPUConfig{Mode: "quantum"}); err == nil {
+		t.Errorf("Expected an error for an unknown mode")
+	}
+
+	// Edge case: Inverted uniform range
+	if _, err := NewGPUStage(GPUConfig{Mode: GPUModeUniform, Min: time.Second, Max: time.Millisecond}); err == nil {
+		t.Errorf("Expected an error for min greater than max")
+	}
+}
+
+func TestGPUDelays(t *testing.T) {
+	// Normal case: Fixed and none stages are constant
+	t.Run("Constant", func(t *testing.T) {
+		if d := (NoGPUDelay{}).Delay(); d != 0 {
+			t.Errorf("Expected no delay, got %v", d)
+		}
+		if d := (FixedGPUDelay{Duration: 150 * time.Millisecond}).Delay(); d != 150*time.Millisecond {
+			t.Errorf("Expected 150ms delay, got %v", d)
+		}
+	})
+
+	// Normal case: Uniform delays stay in range and repeat for the same seed
+	t.Run("UniformSeeded", func(t *testing.T) {
+		a := NewUniformGPUDelay(100*time.Millisecond, 400*time.Millisecond, 42)
+		b := NewUniformGPUDelay(100*time.Millisecond, 400*time.Millisecond, 42)
+		for i := 0; i < 100; i++ {
+			da, db := a.Delay(), b.Delay()
+			if da != db {
+				t.Fatalf("Expected identical sequences for the same seed, got %v and %v", da, db)
+			}
+			if da < 100*time.Millisecond || da > 400*time.Millisecond {
+				t.Fatalf("Expected delay within [100ms, 400ms], got %v", da)
+			}
+		}
+	})
+
+	// Normal case: Realistic delays are capped and repeat for the same seed
+	t.Run("RealisticSeeded", func(t *testing.T) {
+		a := NewRealisticGPUDelay(200*time.Millisecond, 1, time.Second, 7)
+		b := NewRealisticGPUDelay(200*time.Millisecond, 1, time.Second, 7)
+		for i := 0; i < 100; i++ {
+			da, db := a.Delay(), b.Delay()
+			if da != db {
+				t.Fatalf("Expected identical sequences for the same seed, got %v and %v", da, db)
+			}
+			if da <= 0 || da > time.Second {
+				t.Fatalf("Expected delay within (0, 1s], got %v", da)
+			}
+		}
+	})
+}
@@ -1,19 +1,42 @@
 package worker
 
 import (
-	"log"
-	"math/rand"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
 	"net/http"
+	"strings"
 	"time"
 
+	"backend-intern-assignment/logging"
 	"backend-intern-assignment/models"
+	"backend-intern-assignment/storage"
+	"backend-intern-assignment/tracing"
 	"backend-intern-assignment/utils"
 )
 
-// HTTPClient is the client used for HTTP requests. It can be overridden during tests.
-var HTTPClient = &http.Client{}
+// HTTPClient is the client used for HTTP requests. It applies Egress and can
+// be overridden during tests.
+var HTTPClient = newHTTPClient()
 
-var randomGenerator = rand.New(rand.NewSource(time.Now().UnixNano()))
+// ThumbnailSize is the longest side, in pixels, of stored thumbnails
+var ThumbnailSize = 256
+
+// GPU simulates the GPU processing stage. It can be replaced during tests.
+var GPU GPUStage = NewUniformGPUDelay(DefaultGPUConfig.Min, DefaultGPUConfig.Max, time.Now().UnixNano())
+
+// PropagateTraceContext sends the traceparent header with image downloads.
+// It is off by default, since image hosts are supplied by clients and are
+// usually outside the system.
+var PropagateTraceContext = false
+
+// WorkerClock measures and sleeps for the worker. It can be replaced during tests.
+var WorkerClock = SystemClock
 
 // ProcessJob processes images for a job
 // func ProcessJob(jobID int) {
@@ -76,76 +99,351 @@ var randomGenerator = rand.New(rand.NewSource(time.Now().UnixNano()))
 //		totalTime := time.Since(startTime)
 //		log.Printf("Job ID %d: Total processing time %v", jobID, totalTime)
 //	}
-func ProcessJob(jobID int) {
-	startTime := time.Now()
+func ProcessJob(jobID string) {
+	ProcessJobContext(context.Background(), jobID)
+}
+
+// ProcessJobContext processes a job until it finishes or ctx is cancelled. A
+// cancelled job stops before its next image and is marked interrupted.
+func ProcessJobContext(ctx context.Context, jobID string) {
+	startTime := WorkerClock.Now()
+	jobsInFlight.Inc()
+	defer jobsInFlight.Dec()
 
 	job, err := models.FetchJob(jobID)
 	if err != nil {
-		log.Printf("Failed to fetch job: %v", err)
+		logging.FromContext(ctx).Error("Failed to fetch job", "job_id", jobID, "error", err)
 		return
 	}
+	logger := logging.FromContext(ctx).With("job_id", jobID)
+	if job.RequestID != "" {
+		logger = logger.With("request_id", job.RequestID)
+	}
+	ctx = logging.WithLogger(ctx, logger)
+	logger.Info("Job started", "visits", len(job.Request.Visits))
+
+	// Continue the trace of the request that submitted the job
+	if parent, ok := tracing.ParseTraceParent(job.TraceParent); ok {
+		ctx = tracing.ContextWithRemoteSpanContext(ctx, parent)
+	}
+	ctx, span := tracing.Start(ctx, "ProcessJob", tracing.WithAttributes(
+		tracing.String("job.id", jobID),
+		tracing.Int("job.visits", len(job.Request.Visits))))
+	defer span.End()
+
+	// Validate every visit against the same store master, even if it is reloaded mid-job
+	stores := models.CurrentStoreMaster()
+	models.SetJobStoreMasterVersion(jobID, stores.Info().VersionID)
 
 	var hasErrors bool
 
 	for _, visit := range job.Request.Visits {
-		log.Printf("Processing visit for Store ID: %s", visit.StoreID)
-
-		// Check if StoreID is valid
-		if !models.IsValidStore(visit.StoreID) {
-			log.Printf("Invalid Store ID: %s", visit.StoreID)
-			models.AddJobError(jobID, visit.StoreID, "Invalid Store ID")
-			hasErrors = true
-			continue
+		if ctx.Err() != nil {
+			break
 		}
-
-		// Check if ImageURLs is empty
-		if len(visit.ImageURLs) == 0 {
-			log.Printf("Empty ImageURLs for Store ID: %s", visit.StoreID)
-			models.AddJobError(jobID, visit.StoreID, "No images provided for processing")
+		if !processVisit(ctx, job, stores, visit) {
 			hasErrors = true
-			continue
 		}
+	}
 
-		for _, imageURL := range visit.ImageURLs {
-			log.Printf("Downloading image: %s", imageURL)
-
-			resp, err := http.Get(imageURL)
-			if err != nil || resp.StatusCode != http.StatusOK {
-				log.Printf("Failed to download image: %s", imageURL)
-				models.AddJobError(jobID, visit.StoreID, "Failed to download image")
-				hasErrors = true
-				continue
-			}
-
-			log.Printf("Processing image: %s", imageURL)
-			perimeter, err := utils.CalculatePerimeter(resp.Body)
-			resp.Body.Close()
-			if err != nil {
-				log.Printf("Failed to process image: %s", imageURL)
-				models.AddJobError(jobID, visit.StoreID, "Failed to process image")
-				hasErrors = true
-				continue
-			}
-
-			// Simulate GPU processing delay
-			delay := time.Duration(randomGenerator.Intn(301)+100) * time.Millisecond
-			log.Printf("Simulating GPU processing with delay: %v", delay)
-			time.Sleep(delay)
-
-			models.StoreImageResult(jobID, visit.StoreID, imageURL, perimeter)
-			log.Printf("Successfully processed image: %s with perimeter: %d", imageURL, perimeter)
-		}
+	if ctx.Err() != nil {
+		logger.Warn("Job interrupted by shutdown")
+		span.SetStatus(tracing.StatusError, "interrupted by shutdown")
+		models.InterruptJob(jobID, "server shut down before the job finished")
+		return
 	}
 
 	// Mark the job status based on whether there were errors
+	status := "completed"
 	if hasErrors {
-		log.Printf("Job ID %d: Marking job as failed", jobID)
+		status = "failed"
+		span.SetStatus(tracing.StatusError, "job failed")
 		models.FailJob(jobID)
 	} else {
-		log.Printf("Job ID %d: Marking job as completed", jobID)
 		models.CompleteJob(jobID)
 	}
 
-	totalTime := time.Since(startTime)
-	log.Printf("Job ID %d: Total processing time %v", jobID, totalTime)
+	span.SetAttributes(tracing.String("job.status", status))
+
+	totalTime := WorkerClock.Now().Sub(startTime)
+	logger.Info("Job finished", "status", status, logging.Duration("duration", totalTime))
+}
+
+// processVisit validates a visit and processes its images. It returns false
+// if any error was recorded for the visit.
+func processVisit(ctx context.Context, job *models.Job, stores *models.StoreRegistry, visit models.Visit) (ok bool) {
+	ctx, span := tracing.Start(ctx, "visit", tracing.WithAttributes(
+		tracing.String("store.id", visit.StoreID),
+		tracing.String("visit.time", visit.VisitTime),
+		tracing.Int("visit.images", len(visit.ImageURLs))))
+	defer func() { endSpan(span, ok, "visit recorded errors") }()
+
+	logger := logging.FromContext(ctx).With("store_id", visit.StoreID, "visit_time", visit.VisitTime)
+	ctx = logging.WithLogger(ctx, logger)
+	logger.Debug("Processing visit")
+
+	// Check if StoreID is valid
+	store, exists := stores.Get(visit.StoreID)
+	if !exists {
+		logger.Warn("Invalid store ID")
+		recordVisitError(job.ID, visit, models.JobError{
+			Error:      "Invalid Store ID",
+			DidYouMean: stores.Suggest(visit.StoreID, models.MaxSuggestions),
+		})
+		return false
+	}
+
+	// Check the store was open when the visit happened. Only stores with an
+	// active window or an inactive status need the visit time.
+	if !store.AlwaysActive() {
+		visitTime, err := time.Parse(time.RFC3339, visit.VisitTime)
+		if err != nil {
+			logger.Warn("Invalid visit time")
+			recordVisitError(job.ID, visit, models.JobError{Error: "Invalid visit time"})
+			return false
+		}
+		if !store.ActiveAt(visitTime) {
+			logger.Warn("Store inactive at visit time")
+			recordVisitError(job.ID, visit, models.JobError{
+				Error:  models.ErrStoreInactiveAtVisitTime,
+				Reason: describeActiveWindow(store),
+			})
+			return false
+		}
+	}
+
+	// Check if ImageURLs is empty
+	if len(visit.ImageURLs) == 0 {
+		logger.Warn("No images provided for visit")
+		recordVisitError(job.ID, visit, models.JobError{Error: "No images provided for processing"})
+		return false
+	}
+
+	ok = true
+	for _, imageURL := range visit.ImageURLs {
+		if ctx.Err() != nil {
+			break
+		}
+		if !processImage(ctx, job, visit, imageURL) {
+			ok = false
+		}
+	}
+	return ok
+}
+
+// processImage downloads, analyzes and stores a single image of a visit. It
+// returns false if any error was recorded for the image. A download cut short
+// by ctx is not recorded as an error.
+func processImage(ctx context.Context, job *models.Job, visit models.Visit, imageURL string) (ok bool) {
+	ctx, span := tracing.Start(ctx, "image", tracing.WithAttributes(tracing.String("image.url", imageURL)))
+	defer func() { endSpan(span, ok, "image recorded errors") }()
+
+	logger := logging.FromContext(ctx).With("image_url", imageURL)
+	logger.Debug("Downloading image")
+
+	downloadStart := WorkerClock.Now()
+	data, err := fetchImage(ctx, imageURL)
+	if ctx.Err() != nil {
+		return true
+	}
+	if notAllowed, blocked := urlNotAllowed(err); blocked {
+		logger.Warn("Image URL not allowed", "reason", notAllowed.Reason)
+		recordVisitError(job.ID, visit, models.JobError{Error: models.ErrURLNotAllowed, ImageURL: imageURL, Reason: notAllowed.Reason})
+		return false
+	}
+	if err != nil {
+		logger.Warn("Failed to download image", "error", err)
+		recordVisitError(job.ID, visit, models.JobError{Error: "Failed to download image", ImageURL: imageURL})
+		return false
+	}
+	downloadTime := WorkerClock.Now().Sub(downloadStart)
+	observeDownload(downloadTime, len(data))
+	logger.Debug("Downloaded image", "bytes", len(data), logging.Duration("download", downloadTime))
+
+	_, decodeSpan := tracing.Start(ctx, "image.decode")
+	img, format, err := utils.DecodeImage(bytes.NewReader(data))
+	decodeSpan.SetAttributes(tracing.String("image.format", format))
+	decodeSpan.RecordError(err)
+	decodeSpan.End()
+	if err != nil {
+		logger.Warn("Failed to decode image", "error", err)
+		observeDecodeError(data)
+		recordVisitError(job.ID, visit, models.JobError{Error: "Failed to process image", ImageURL: imageURL})
+		return false
+	}
+
+	thresholds := utils.DefaultQualityThresholds
+	if job.Request.QualityThresholds != nil {
+		thresholds = *job.Request.QualityThresholds
+	}
+	metrics, reasons, err := utils.AnalyzeImage(img, job.Request.Analyzers, thresholds)
+	if err != nil {
+		logger.Warn("Failed to analyze image", "error", err)
+		recordVisitError(job.ID, visit, models.JobError{Error: "Failed to analyze image", ImageURL: imageURL})
+		return false
+	}
+
+	hash, err := utils.PerceptualHash(img, utils.DefaultHashAlgorithm)
+	if err != nil {
+		logger.Warn("Failed to hash image", "error", err)
+	}
+	result := models.ImageResult{
+		StoreID:        visit.StoreID,
+		VisitTime:      visit.VisitTime,
+		ImageURL:       imageURL,
+		Metrics:        metrics,
+		PerceptualHash: hash,
+	}
+	if err == nil {
+		result.Duplicates = models.IndexImageHash(models.HashRecord{
+			Tenant:    job.Tenant,
+			JobID:     job.ID,
+			StoreID:   visit.StoreID,
+			VisitTime: visit.VisitTime,
+			ImageURL:  imageURL,
+			Hash:      hash,
+		})
+	}
+
+	if storage.Default != nil {
+		result.OriginalKey, result.ThumbnailKey, err = storeImage(job.Tenant, data, format, img)
+		if err != nil {
+			logger.Warn("Failed to store image", "error", err)
+		}
+	}
+
+	if len(reasons) > 0 {
+		reason := strings.Join(reasons, "; ")
+		logger.Info("Image rejected", "reason", reason)
+		models.RejectImageResult(job.ID, result, reason)
+		return false
+	}
+
+	ok = true
+	if len(result.Duplicates) > 0 {
+		match := result.Duplicates[0]
+		logger.Info("Duplicate image", "match_image_url", match.ImageURL, "match_store_id", match.StoreID, "match_job_id", match.JobID)
+		recordVisitError(job.ID, visit, models.JobError{
+			Error:    models.ErrDuplicateImage,
+			ImageURL: imageURL,
+			Reason: fmt.Sprintf("matches %s from store %s visited at %s (job %s, distance %d)",
+				match.ImageURL, match.StoreID, match.VisitTime, match.JobID, match.Distance),
+		})
+		ok = false
+	}
+
+	// Simulate GPU processing delay
+	delay := GPU.Delay()
+	_, gpuSpan := tracing.Start(ctx, "image.gpu", tracing.WithAttributes(tracing.Float64("gpu.delay_ms", float64(delay.Microseconds())/1000)))
+	WorkerClock.Sleep(delay)
+	gpuSpan.End()
+	gpuDuration.Observe(delay.Seconds())
+
+	models.StoreImageResult(job.ID, result)
+	logger.Info("Image processed",
+		"format", format,
+		"metrics", metrics,
+		logging.Duration("download", downloadTime),
+		logging.Duration("gpu", delay),
+		logging.Duration("duration", WorkerClock.Now().Sub(downloadStart)))
+	return ok
+}
+
+// fetchImage downloads an image and reads its body in an image.download span.
+// Responses other than 200 OK are errors.
+func fetchImage(ctx context.Context, imageURL string) ([]byte, error) {
+	ctx, span := tracing.Start(ctx, "image.download",
+		tracing.WithKind(tracing.SpanKindClient),
+		tracing.WithAttributes(tracing.String("url.full", imageURL)))
+	defer span.End()
+
+	resp, err := download(ctx, imageURL)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+	span.SetAttributes(tracing.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("unexpected status %s", resp.Status)
+		span.RecordError(err)
+		return nil, err
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttributes(tracing.Int("image.bytes", len(data)))
+	return data, nil
+}
+
+// download fetches an image with HTTPClient, aborting when ctx is cancelled.
+// URLs blocked by Egress are not requested. The trace context is only passed
+// on when PropagateTraceContext is set.
+func download(ctx context.Context, imageURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := Egress.CheckURL(req.URL); err != nil {
+		return nil, err
+	}
+	if PropagateTraceContext {
+		tracing.Inject(ctx, req.Header)
+	}
+	return HTTPClient.Do(req)
+}
+
+// endSpan ends span, marking it failed with message when ok is false
+func endSpan(span *tracing.Span, ok bool, message string) {
+	if !ok {
+		span.SetStatus(tracing.StatusError, message)
+	}
+	span.End()
+}
+
+// recordVisitError adds an error for a visit to the job
+func recordVisitError(jobID string, visit models.Visit, jobErr models.JobError) {
+	jobErr.StoreID, jobErr.VisitTime = visit.StoreID, visit.VisitTime
+	models.AppendJobError(jobID, jobErr)
+}
+
+// describeActiveWindow explains when a store accepts visits
+func describeActiveWindow(store models.Store) string {
+	switch {
+	case store.ActiveFrom != nil && store.ActiveTo != nil:
+		return fmt.Sprintf("store active from %s until %s", store.ActiveFrom.Format(time.RFC3339), store.ActiveTo.Format(time.RFC3339))
+	case store.ActiveFrom != nil:
+		return fmt.Sprintf("store active from %s", store.ActiveFrom.Format(time.RFC3339))
+	case store.ActiveTo != nil:
+		return fmt.Sprintf("store active until %s", store.ActiveTo.Format(time.RFC3339))
+	default:
+		return "store is inactive"
+	}
+}
+
+// storeImage writes the original image and a JPEG thumbnail to the blob store
+// under content-addressed keys of the tenant, so re-uploads of the same bytes
+// by a tenant share storage
+func storeImage(tenant string, data []byte, format string, img image.Image) (string, string, error) {
+	sum := sha256.Sum256(data)
+	digest := storage.TenantKey(tenant, hex.EncodeToString(sum[:]))
+
+	originalKey := digest + "." + format
+	if err := storage.Default.Put(originalKey, bytes.NewReader(data)); err != nil {
+		return "", "", err
+	}
+
+	var thumb bytes.Buffer
+	if err := jpeg.Encode(&thumb, utils.Thumbnail(img, ThumbnailSize), nil); err != nil {
+		return originalKey, "", err
+	}
+	thumbnailKey := digest + "_thumb.jpg"
+	if err := storage.Default.Put(thumbnailKey, &thumb); err != nil {
+		return originalKey, "", err
+	}
+
+	return originalKey, thumbnailKey, nil
 }
@@ -2,15 +2,54 @@ package worker
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"image"
 	"image/jpeg"
 	"io"
+	"log/slog"
 	"net/http"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"backend-intern-assignment/logging"
 	"backend-intern-assignment/models"
+	"backend-intern-assignment/storage"
+	"backend-intern-assignment/tracing"
+	"backend-intern-assignment/utils"
 )
 
+// noQualityChecks turns off the quality checks for jobs of the small black
+// mock images, which the default thresholds reject
+var noQualityChecks = &utils.QualityThresholds{}
+
+// recordingExporter keeps exported spans in memory
+type recordingExporter struct {
+	mu    sync.Mutex
+	spans []tracing.SpanData
+}
+
+func (e *recordingExporter) ExportSpans(ctx context.Context, spans []tracing.SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+// byName returns the exported spans keyed by name
+func (e *recordingExporter) byName() map[string]tracing.SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	spans := make(map[string]tracing.SpanData)
+	for _, span := range e.spans {
+		spans[span.Name] = span
+	}
+	return spans
+}
+
 // MockTransport is a custom implementation of http.RoundTripper
 type MockTransport struct {
 	RoundTripFunc func(req *http.Request) (*http.Response, error)
@@ -28,18 +67,42 @@ func createMockImage() []byte {
 	return buf.Bytes()
 }
 
+// Helper to create a sharp, well exposed 640x480 photo that passes the default
+// quality thresholds
+func createSharpImage() []byte {
+	img := image.NewGray(image.Rect(0, 0, 640, 480))
+	for y := 0; y < 480; y++ {
+		for x := 0; x < 640; x++ {
+			img.Pix[y*img.Stride+x] = 60
+			if (x/8+y/8)%2 == 0 {
+				img.Pix[y*img.Stride+x] = 200
+			}
+		}
+	}
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90})
+	return buf.Bytes()
+}
+
 // Helper to initialize StoreMaster
 func initTestStoreMaster() {
 	models.InitTestStoreMaster()
+	models.ResetImageHashes()
 }
 
 // Test cases for ProcessJob
 func TestProcessJob(t *testing.T) {
 	// Replace the HTTP client transport with a mock
 	mockTransport := &MockTransport{}
-	originalTransport := http.DefaultClient.Transport
-	http.DefaultClient.Transport = mockTransport
-	defer func() { http.DefaultClient.Transport = originalTransport }()
+	originalTransport := HTTPClient.Transport
+	HTTPClient.Transport = mockTransport
+	defer func() { HTTPClient.Transport = originalTransport }()
+
+	// Run the GPU stage on a fake clock so the tests do not sleep
+	clock := &fakeClock{now: time.Date(2023, 10, 21, 15, 4, 5, 0, time.UTC)}
+	originalGPU, originalClock := GPU, WorkerClock
+	GPU, WorkerClock = FixedGPUDelay{Duration: 250 * time.Millisecond}, clock
+	defer func() { GPU, WorkerClock = originalGPU, originalClock }()
 
 	// Normal case: Valid StoreID and ImageURL
 	t.Run("ValidStoreIDAndImageURL", func(t *testing.T) {
@@ -61,7 +124,9 @@ func TestProcessJob(t *testing.T) {
 					VisitTime: "2023-10-21T15:04:05Z",
 				},
 			},
+			QualityThresholds: noQualityChecks,
 		}
+		downloads, gpuRuns := downloadDuration.Count(), gpuDuration.Count()
 		jobID := models.CreateJob(jobRequest)
 		ProcessJob(jobID)
 
@@ -72,9 +137,451 @@ func TestProcessJob(t *testing.T) {
 		if len(job.Errors) != 0 {
 			t.Errorf("Expected no errors, got %d", len(job.Errors))
 		}
+		if downloadDuration.Count() != downloads+1 || gpuDuration.Count() != gpuRuns+1 {
+			t.Errorf("Expected one download and one GPU run to be measured")
+		}
+		if jobsInFlight.Value() != 0 {
+			t.Errorf("Expected no jobs in flight, got %v", jobsInFlight.Value())
+		}
 		if len(job.Results) != 1 {
 			t.Errorf("Expected 1 result, got %d", len(job.Results))
 		}
+		if job.StoreMasterVersion != models.StoreMasterStatus().VersionID {
+			t.Errorf("Expected job to record store master version %q, got %q", models.StoreMasterStatus().VersionID, job.StoreMasterVersion)
+		}
+		if job.Results[0].StoreName != "B P STORE" || job.Results[0].AreaCode != "7100015" {
+			t.Errorf("Expected result enriched with store details, got %+v", job.Results[0])
+		}
+		if job.Results[0].Metrics["perimeter"] != 600 {
+			t.Errorf("Expected perimeter 600, got %v", job.Results[0].Metrics["perimeter"])
+		}
+		if clock.sleeps == 0 || clock.slept%(250*time.Millisecond) != 0 {
+			t.Errorf("Expected the GPU stage to sleep on the injected clock, slept %v", clock.slept)
+		}
+	})
+
+	// Normal case: Log records carry the job, request, store and image as fields
+	t.Run("StructuredLogs", func(t *testing.T) {
+		initTestStoreMaster()
+
+		mockTransport.RoundTripFunc = func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(createMockImage())),
+			}, nil
+		}
+		var out bytes.Buffer
+		logger, _ := logging.New(&out, "debug", logging.FormatJSON)
+		original := slog.Default()
+		slog.SetDefault(logger)
+		defer slog.SetDefault(original)
+
+		jobID := models.CreateJob(models.JobRequest{
+			Count: 1,
+			Visits: []models.Visit{
+				{StoreID: "RP00001", ImageURLs: []string{"https://mock-url.com/image.jpg"}, VisitTime: "2023-10-21T15:04:05Z"},
+			},
+			QualityThresholds: noQualityChecks,
+		})
+		models.SetJobRequestID(jobID, "req-42")
+		ProcessJob(jobID)
+
+		var processed map[string]interface{}
+		for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+			var record map[string]interface{}
+			json.Unmarshal([]byte(line), &record)
+			if record["job_id"] != jobID || record["request_id"] != "req-42" {
+				t.Errorf("Expected every record to carry the job and request IDs, got %s", line)
+			}
+			if record["msg"] == "Image processed" {
+				processed = record
+			}
+		}
+		if processed == nil {
+			t.Fatalf("Expected an \"Image processed\" record, got %s", out.String())
+		}
+		if processed["store_id"] != "RP00001" || processed["image_url"] != "https://mock-url.com/image.jpg" {
+			t.Errorf("Expected store and image fields, got %v", processed)
+		}
+		if processed["gpu_ms"] != float64(250) {
+			t.Errorf("Expected gpu_ms of 250, got %v", processed["gpu_ms"])
+		}
+	})
+
+	// Normal case: Each image step is traced, without sending the trace context to image hosts
+	t.Run("Spans", func(t *testing.T) {
+		initTestStoreMaster()
+
+		var traceParent string
+		mockTransport.RoundTripFunc = func(req *http.Request) (*http.Response, error) {
+			traceParent = req.Header.Get(tracing.TraceParentHeader)
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(createMockImage())),
+			}, nil
+		}
+		exporter := &recordingExporter{}
+		tracing.Setup(exporter, 1)
+		defer tracing.Setup(nil, 1)
+
+		jobID := models.CreateJob(models.JobRequest{
+			Count: 1,
+			Visits: []models.Visit{
+				{StoreID: "RP00001", ImageURLs: []string{"https://mock-url.com/image.jpg"}, VisitTime: "2023-10-21T15:04:05Z"},
+			},
+			QualityThresholds: noQualityChecks,
+		})
+		models.SetJobTraceParent(jobID, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		ProcessJob(jobID)
+		tracing.ForceFlush(context.Background())
+
+		spans := exporter.byName()
+		for _, name := range []string{"ProcessJob", "visit", "image", "image.download", "image.decode", "image.gpu"} {
+			span, exists := spans[name]
+			if !exists {
+				t.Fatalf("Expected a %s span, got %v", name, spans)
+			}
+			if span.SpanContext.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+				t.Errorf("Expected %s to continue the submitting trace", name)
+			}
+		}
+		if spans["ProcessJob"].Parent.String() != "00f067aa0ba902b7" || spans["image.gpu"].Parent != spans["image"].SpanContext.SpanID {
+			t.Errorf("Expected ProcessJob under the submitting span and image.gpu under image")
+		}
+		download := spans["image.download"]
+		if traceParent != "" {
+			t.Errorf("Expected no traceparent on the download request, got %q", traceParent)
+		}
+		if download.Kind != tracing.SpanKindClient || spans["image.decode"].Attributes[0].Value != "jpeg" {
+			t.Errorf("Expected a client download span and the decoded format")
+		}
+	})
+
+	// Normal case: The trace context is sent with downloads once propagation is enabled
+	t.Run("PropagateTraceContext", func(t *testing.T) {
+		initTestStoreMaster()
+		PropagateTraceContext = true
+		defer func() { PropagateTraceContext = false }()
+
+		var traceParent string
+		mockTransport.RoundTripFunc = func(req *http.Request) (*http.Response, error) {
+			traceParent = req.Header.Get(tracing.TraceParentHeader)
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(createMockImage())),
+			}, nil
+		}
+		exporter := &recordingExporter{}
+		tracing.Setup(exporter, 1)
+		defer tracing.Setup(nil, 1)
+
+		jobID := models.CreateJob(models.JobRequest{
+			Count: 1,
+			Visits: []models.Visit{
+				{StoreID: "RP00001", ImageURLs: []string{"https://mock-url.com/image.jpg"}, VisitTime: "2023-10-21T15:04:05Z"},
+			},
+			QualityThresholds: noQualityChecks,
+		})
+		ProcessJob(jobID)
+		tracing.ForceFlush(context.Background())
+
+		download := exporter.byName()["image.download"]
+		if !strings.Contains(traceParent, download.SpanContext.SpanID.String()) {
+			t.Errorf("Expected the download request to carry the download span, got %q", traceParent)
+		}
+	})
+
+	// Normal case: Requested analyzers are recorded in the result metrics
+	t.Run("RequestedAnalyzers", func(t *testing.T) {
+		initTestStoreMaster()
+
+		mockTransport.RoundTripFunc = func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(createMockImage())),
+			}, nil
+		}
+
+		jobRequest := models.JobRequest{
+			Count: 1,
+			Visits: []models.Visit{
+				{
+					StoreID:   "RP00001",
+					ImageURLs: []string{"https://mock-url.com/image.jpg"},
+					VisitTime: "2023-10-21T15:04:05Z",
+				},
+			},
+			Analyzers:         []string{"area", "dimensions"},
+			QualityThresholds: noQualityChecks,
+		}
+		jobID := models.CreateJob(jobRequest)
+		ProcessJob(jobID)
+
+		job, _ := models.FetchJob(jobID)
+		if len(job.Results) != 1 {
+			t.Fatalf("Expected 1 result, got %d", len(job.Results))
+		}
+		metrics := job.Results[0].Metrics
+		if metrics["area"] != 20000 || metrics["width"] != 100 || metrics["height"] != 200 {
+			t.Errorf("Unexpected metrics: %v", metrics)
+		}
+	})
+
+	// Normal case: Original and thumbnail are written to the blob store
+	t.Run("StoresImages", func(t *testing.T) {
+		initTestStoreMaster()
+
+		store, err := storage.NewLocalStore(t.TempDir())
+		if err != nil {
+			t.Fatalf("Expected no error creating store, got %v", err)
+		}
+		storage.Default = store
+		defer func() { storage.Default = nil }()
+
+		mockTransport.RoundTripFunc = func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(createMockImage())),
+			}, nil
+		}
+
+		jobID := models.CreateJob(models.JobRequest{
+			Count: 1,
+			Visits: []models.Visit{
+				{StoreID: "RP00001", ImageURLs: []string{"https://mock-url.com/image.jpg"}, VisitTime: "2023-10-21T15:04:05Z"},
+			},
+			QualityThresholds: noQualityChecks,
+		})
+		ProcessJob(jobID)
+
+		job, _ := models.FetchJob(jobID)
+		if len(job.Results) != 1 {
+			t.Fatalf("Expected 1 result, got %d", len(job.Results))
+		}
+		result := job.Results[0]
+		if result.OriginalKey == "" || result.ThumbnailKey == "" {
+			t.Fatalf("Expected stored keys on the result, got %+v", result)
+		}
+		for _, key := range []string{result.OriginalKey, result.ThumbnailKey} {
+			body, _, err := store.Get(key)
+			if err != nil {
+				t.Errorf("Expected %s to be stored, got %v", key, err)
+				continue
+			}
+			body.Close()
+		}
+
+		// Edge case: The same image of a tenant is stored under the tenant's own keys
+		tenantJobID := models.CreateTenantJob("acme", models.JobRequest{
+			Count: 1,
+			Visits: []models.Visit{
+				{StoreID: "RP00001", ImageURLs: []string{"https://mock-url.com/image.jpg"}, VisitTime: "2023-10-22T15:04:05Z"},
+			},
+			QualityThresholds: noQualityChecks,
+		})
+		ProcessJob(tenantJobID)
+		tenantJob, _ := models.FetchJob(tenantJobID)
+		if len(tenantJob.Results) != 1 || tenantJob.Results[0].OriginalKey == result.OriginalKey || !storage.KeyOwnedBy(tenantJob.Results[0].OriginalKey, "acme") {
+			t.Errorf("Expected a separate key owned by acme, got %+v", tenantJob.Results)
+		}
+	})
+
+	// Edge case: Image fails the quality checks
+	t.Run("QualityRejected", func(t *testing.T) {
+		initTestStoreMaster()
+
+		mockTransport.RoundTripFunc = func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(createMockImage())),
+			}, nil
+		}
+
+		jobRequest := models.JobRequest{
+			Count: 1,
+			Visits: []models.Visit{
+				{
+					StoreID:   "RP00001",
+					ImageURLs: []string{"https://mock-url.com/dark.jpg"},
+					VisitTime: "2023-10-21T15:04:05Z",
+				},
+			},
+			Analyzers:         []string{"perimeter", "exposure"},
+			QualityThresholds: &utils.QualityThresholds{MinMeanLuminance: 40},
+		}
+		jobID := models.CreateJob(jobRequest)
+		ProcessJob(jobID)
+
+		job, _ := models.FetchJob(jobID)
+		if job.Status != "failed" {
+			t.Errorf("Expected job status 'failed' for rejected image, got '%s'", job.Status)
+		}
+		if len(job.Errors) != 1 || job.Errors[0].Error != models.ImageStatusQualityRejected {
+			t.Fatalf("Expected 1 quality_rejected error, got %v", job.Errors)
+		}
+		if job.Errors[0].Reason == "" || job.Errors[0].ImageURL != "https://mock-url.com/dark.jpg" {
+			t.Errorf("Expected reason and image URL on the error, got %+v", job.Errors[0])
+		}
+		if len(job.Results) != 1 || job.Results[0].Status != models.ImageStatusQualityRejected {
+			t.Errorf("Expected rejected image result, got %+v", job.Results)
+		}
+	})
+
+	// Edge case: The shipped default thresholds reject the small black image
+	t.Run("DefaultQualityThresholds", func(t *testing.T) {
+		initTestStoreMaster()
+
+		mockTransport.RoundTripFunc = func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(createMockImage())),
+			}, nil
+		}
+		jobID := models.CreateJob(models.JobRequest{
+			Count: 1,
+			Visits: []models.Visit{
+				{StoreID: "RP00001", ImageURLs: []string{"https://mock-url.com/blurry.jpg"}, VisitTime: "2023-10-21T15:04:05Z"},
+			},
+		})
+		ProcessJob(jobID)
+
+		job, _ := models.FetchJob(jobID)
+		if len(job.Errors) != 1 || job.Errors[0].Error != models.ImageStatusQualityRejected {
+			t.Fatalf("Expected 1 quality_rejected error, got %v", job.Errors)
+		}
+		for _, reason := range []string{"blurry", "underexposed", "short side"} {
+			if !strings.Contains(job.Errors[0].Reason, reason) {
+				t.Errorf("Expected a %q reason, got %q", reason, job.Errors[0].Reason)
+			}
+		}
+	})
+
+	// Normal case: A sharp, well exposed photo passes the shipped default thresholds
+	t.Run("PassesDefaultQualityThresholds", func(t *testing.T) {
+		initTestStoreMaster()
+
+		mockTransport.RoundTripFunc = func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(createSharpImage())),
+			}, nil
+		}
+		jobID := models.CreateJob(models.JobRequest{
+			Count: 1,
+			Visits: []models.Visit{
+				{StoreID: "RP00001", ImageURLs: []string{"https://mock-url.com/shelf.jpg"}, VisitTime: "2023-10-21T15:04:05Z"},
+			},
+		})
+		ProcessJob(jobID)
+
+		job, _ := models.FetchJob(jobID)
+		if job.Status != "completed" || len(job.Results) != 1 {
+			t.Fatalf("Expected a completed job with 1 result, got '%s' %v", job.Status, job.Errors)
+		}
+		if _, measured := job.Results[0].Metrics["sharpness"]; !measured {
+			t.Errorf("Expected the quality metrics to be measured, got %v", job.Results[0].Metrics)
+		}
+	})
+
+	// Edge case: An image too small to measure sharpness is rejected, not failed
+	t.Run("TooSmallForSharpness", func(t *testing.T) {
+		initTestStoreMaster()
+
+		mockTransport.RoundTripFunc = func(req *http.Request) (*http.Response, error) {
+			var buf bytes.Buffer
+			jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2)), nil)
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(&buf)}, nil
+		}
+		jobID := models.CreateJob(models.JobRequest{
+			Count: 1,
+			Visits: []models.Visit{
+				{StoreID: "RP00001", ImageURLs: []string{"https://mock-url.com/tiny.jpg"}, VisitTime: "2023-10-21T15:04:05Z"},
+			},
+			QualityThresholds: &utils.QualityThresholds{MinSharpness: 100},
+		})
+		ProcessJob(jobID)
+
+		job, _ := models.FetchJob(jobID)
+		if len(job.Errors) != 1 || job.Errors[0].Error != models.ImageStatusQualityRejected || !strings.Contains(job.Errors[0].Reason, "too small") {
+			t.Errorf("Expected a quality_rejected error with a resolution reason, got %v", job.Errors)
+		}
+	})
+
+	// Edge case: Same photo reused for a different store
+	t.Run("DuplicateImage", func(t *testing.T) {
+		initTestStoreMaster()
+
+		mockTransport.RoundTripFunc = func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(createMockImage())),
+			}, nil
+		}
+
+		first := models.CreateJob(models.JobRequest{
+			Count: 1,
+			Visits: []models.Visit{
+				{StoreID: "RP00001", ImageURLs: []string{"https://mock-url.com/a.jpg"}, VisitTime: "2023-10-21T15:04:05Z"},
+			},
+			QualityThresholds: noQualityChecks,
+		})
+		ProcessJob(first)
+
+		second := models.CreateJob(models.JobRequest{
+			Count: 1,
+			Visits: []models.Visit{
+				{StoreID: "RP00002", ImageURLs: []string{"https://mock-url.com/b.jpg"}, VisitTime: "2023-10-22T09:00:00Z"},
+			},
+			QualityThresholds: noQualityChecks,
+		})
+		ProcessJob(second)
+
+		job, _ := models.FetchJob(second)
+		if job.Status != "failed" {
+			t.Errorf("Expected job status 'failed' for duplicate image, got '%s'", job.Status)
+		}
+		if len(job.Errors) != 1 || job.Errors[0].Error != models.ErrDuplicateImage {
+			t.Fatalf("Expected 1 duplicate_image error, got %v", job.Errors)
+		}
+		if len(job.Results) != 1 || len(job.Results[0].Duplicates) != 1 {
+			t.Fatalf("Expected result flagged with 1 duplicate, got %+v", job.Results)
+		}
+		if job.Results[0].Duplicates[0].JobID != first {
+			t.Errorf("Expected duplicate of job %s, got %s", first, job.Results[0].Duplicates[0].JobID)
+		}
+	})
+
+	// Edge case: Another tenant's copy of a photo is not flagged and does not leak its details
+	t.Run("DuplicateImageOtherTenant", func(t *testing.T) {
+		initTestStoreMaster()
+
+		mockTransport.RoundTripFunc = func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(createMockImage())),
+			}, nil
+		}
+		tenantJob := func(tenant, storeID, imageURL, visitTime string) *models.Job {
+			jobID := models.CreateTenantJob(tenant, models.JobRequest{
+				Count:             1,
+				Visits:            []models.Visit{{StoreID: storeID, ImageURLs: []string{imageURL}, VisitTime: visitTime}},
+				QualityThresholds: noQualityChecks,
+			})
+			ProcessJob(jobID)
+			job, _ := models.FetchJob(jobID)
+			return job
+		}
+
+		acme := tenantJob("acme", "RP00001", "https://acme.example.com/private.jpg", "2023-10-21T15:04:05Z")
+		globex := tenantJob("globex", "RP00002", "https://globex.example.com/b.jpg", "2023-10-22T09:00:00Z")
+		if globex.Status != "completed" || len(globex.Errors) != 0 || len(globex.Results[0].Duplicates) != 0 {
+			t.Errorf("Expected globex's image not to match acme's, got '%s' %v %+v", globex.Status, globex.Errors, globex.Results)
+		}
+
+		again := tenantJob("acme", "RP00002", "https://acme.example.com/c.jpg", "2023-10-23T09:00:00Z")
+		if len(again.Errors) != 1 || again.Errors[0].Error != models.ErrDuplicateImage || !strings.Contains(again.Errors[0].Reason, acme.ID) {
+			t.Errorf("Expected acme's reuse of its own photo to be flagged, got %v", again.Errors)
+		}
 	})
 
 	// Edge case: Invalid StoreID
@@ -103,6 +610,115 @@ func TestProcessJob(t *testing.T) {
 		}
 	})
 
+	// Edge case: Visit outside the store's active window
+	t.Run("StoreInactiveAtVisitTime", func(t *testing.T) {
+		initTestStoreMaster()
+		closed := time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC)
+		models.UpdateTestStore(models.Store{ID: "RP00001", Name: "B P STORE", AreaCode: "7100015", Status: models.StoreStatusInactive, ActiveTo: &closed})
+		defer initTestStoreMaster()
+
+		mockTransport.RoundTripFunc = func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(createMockImage())),
+			}, nil
+		}
+
+		// A visit before closing is still valid
+		before := models.CreateJob(models.JobRequest{
+			Count: 1,
+			Visits: []models.Visit{
+				{StoreID: "RP00001", ImageURLs: []string{"https://mock-url.com/image.jpg"}, VisitTime: "2023-08-15T10:00:00Z"},
+			},
+			QualityThresholds: noQualityChecks,
+		})
+		ProcessJob(before)
+		if job, _ := models.FetchJob(before); job.Status != "completed" {
+			t.Errorf("Expected visit before closing to complete, got '%s' %v", job.Status, job.Errors)
+		}
+
+		after := models.CreateJob(models.JobRequest{
+			Count: 1,
+			Visits: []models.Visit{
+				{StoreID: "RP00001", ImageURLs: []string{"https://mock-url.com/image.jpg"}, VisitTime: "2023-10-21T15:04:05Z"},
+			},
+			QualityThresholds: noQualityChecks,
+		})
+		ProcessJob(after)
+		job, _ := models.FetchJob(after)
+		if len(job.Errors) != 1 || job.Errors[0].Error != models.ErrStoreInactiveAtVisitTime {
+			t.Errorf("Expected store_inactive_at_visit_time error, got %v", job.Errors)
+		}
+	})
+
+	// Edge case: Unparseable visit time for a store with an active window
+	t.Run("InvalidVisitTime", func(t *testing.T) {
+		initTestStoreMaster()
+		opened := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+		models.UpdateTestStore(models.Store{ID: "RP00001", Name: "B P STORE", AreaCode: "7100015", Status: models.StoreStatusActive, ActiveFrom: &opened})
+		defer initTestStoreMaster()
+
+		jobID := models.CreateJob(models.JobRequest{
+			Count: 1,
+			Visits: []models.Visit{
+				{StoreID: "RP00001", ImageURLs: []string{"https://mock-url.com/image.jpg"}, VisitTime: "last tuesday"},
+			},
+		})
+		ProcessJob(jobID)
+
+		job, _ := models.FetchJob(jobID)
+		if job.Status != "failed" || len(job.Errors) != 1 || job.Errors[0].Error != "Invalid visit time" {
+			t.Errorf("Expected failed job with 'Invalid visit time', got '%s' %v", job.Status, job.Errors)
+		}
+	})
+
+	// Normal case: Stores that are always active do not need a parseable visit time
+	t.Run("FreeFormVisitTime", func(t *testing.T) {
+		initTestStoreMaster()
+
+		mockTransport.RoundTripFunc = func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(createMockImage())),
+			}, nil
+		}
+		jobID := models.CreateJob(models.JobRequest{
+			Count: 1,
+			Visits: []models.Visit{
+				{StoreID: "RP00001", ImageURLs: []string{"https://mock-url.com/image.jpg"}, VisitTime: "2023-10-21 15:04"},
+			},
+			QualityThresholds: noQualityChecks,
+		})
+		ProcessJob(jobID)
+
+		job, _ := models.FetchJob(jobID)
+		if job.Status != "completed" || len(job.Results) != 1 {
+			t.Errorf("Expected the visit to complete, got '%s' %v", job.Status, job.Errors)
+		}
+	})
+
+	// Edge case: Mistyped StoreID gets suggestions
+	t.Run("MistypedStoreID", func(t *testing.T) {
+		initTestStoreMaster()
+
+		jobID := models.CreateJob(models.JobRequest{
+			Count: 1,
+			Visits: []models.Visit{
+				{StoreID: "RP0001", ImageURLs: []string{"https://mock-url.com/image.jpg"}, VisitTime: "2023-10-21T15:04:05Z"},
+			},
+		})
+		ProcessJob(jobID)
+
+		job, _ := models.FetchJob(jobID)
+		if len(job.Errors) != 1 {
+			t.Fatalf("Expected 1 error for mistyped StoreID, got %d", len(job.Errors))
+		}
+		suggestions := job.Errors[0].DidYouMean
+		if len(suggestions) == 0 || suggestions[0].StoreID != "RP00001" || suggestions[0].StoreName != "B P STORE" {
+			t.Errorf("Expected RP00001 suggested first, got %+v", suggestions)
+		}
+	})
+
 	// Edge case: Empty ImageURLs
 	t.Run("EmptyImageURLs", func(t *testing.T) {
 		initTestStoreMaster()
@@ -150,6 +766,7 @@ func TestProcessJob(t *testing.T) {
 				},
 			},
 		}
+		decodeFailures := decodeErrors.Value("unknown")
 		jobID := models.CreateJob(jobRequest)
 		ProcessJob(jobID)
 
@@ -157,6 +774,9 @@ func TestProcessJob(t *testing.T) {
 		if job.Status != "failed" {
 			t.Errorf("Expected job status 'failed' for image processing failure, got '%s'", job.Status)
 		}
+		if decodeErrors.Value("unknown") != decodeFailures+1 {
+			t.Errorf("Expected a decode error for an unknown format to be counted")
+		}
 		if len(job.Errors) != 1 {
 			t.Errorf("Expected 1 error for image processing failure, got %d", len(job.Errors))
 		}
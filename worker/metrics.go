@@ -0,0 +1,45 @@
+package worker
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"backend-intern-assignment/metrics"
+)
+
+var (
+	_ = metrics.NewGaugeFunc("job_queue_depth", "Jobs waiting for a worker, including those set aside for their tenant's cap.", func() float64 {
+		depth, _ := QueueStats()
+		return float64(depth)
+	})
+	_ = metrics.NewGaugeFunc("jobs_waiting_for_tenant", "Jobs set aside because their tenant is processing its maximum number of jobs.", func() float64 {
+		return float64(waitingForTenant())
+	})
+	jobsInFlight = metrics.NewGauge("jobs_in_flight", "Jobs currently being processed.")
+
+	downloadDuration = metrics.NewHistogram("image_download_duration_seconds",
+		"Time taken to download an image.", metrics.DefaultBuckets)
+	downloadSize = metrics.NewHistogram("image_download_size_bytes",
+		"Size of downloaded images.", metrics.ExponentialBuckets(1024, 4, 8))
+	decodeErrors = metrics.NewCounter("image_decode_errors_total",
+		"Images that could not be decoded, by detected format.", "format")
+	gpuDuration = metrics.NewHistogram("gpu_simulated_duration_seconds",
+		"Simulated GPU processing time per image.", metrics.DefaultBuckets)
+)
+
+// observeDownload records the latency and size of a completed download
+func observeDownload(elapsed time.Duration, size int) {
+	downloadDuration.Observe(elapsed.Seconds())
+	downloadSize.Observe(float64(size))
+}
+
+// observeDecodeError records an image that failed to decode. The format is
+// sniffed from the content, since the decoder did not recognize it.
+func observeDecodeError(data []byte) {
+	format := "unknown"
+	if contentType := http.DetectContentType(data); strings.HasPrefix(contentType, "image/") {
+		format = strings.TrimPrefix(contentType, "image/")
+	}
+	decodeErrors.Inc(format)
+}
@@ -0,0 +1,23 @@
+package worker
+
+import "testing"
+
+func TestObserveDecodeError(t *testing.T) {
+	// Normal case: A truncated PNG is counted under png
+	t.Run("SniffedFormat", func(t *testing.T) {
+		before := decodeErrors.Value("png")
+		observeDecodeError([]byte("\x89PNG\r\n\x1a\ntruncated"))
+		if decodeErrors.Value("png") != before+1 {
+			t.Errorf("Expected a png decode error to be counted")
+		}
+	})
+
+	// Edge case: Content that is not an image is counted as unknown
+	t.Run("UnknownFormat", func(t *testing.T) {
+		before := decodeErrors.Value("unknown")
+		observeDecodeError([]byte("<html></html>"))
+		if decodeErrors.Value("unknown") != before+1 {
+			t.Errorf("Expected an unknown decode error to be counted")
+		}
+	})
+}
@@ -0,0 +1,274 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"backend-intern-assignment/models"
+	"backend-intern-assignment/tracing"
+)
+
+// ErrShuttingDown is returned for jobs submitted after Shutdown has started
+var ErrShuttingDown = errors.New("worker pool is shutting down")
+
+// queuedJob is a job waiting for a worker, with the span timing its wait
+type queuedJob struct {
+	id     string
+	tenant string
+	span   *tracing.Span
+}
+
+// MaxJobsPerTenant caps how many jobs of one tenant the pool processes at
+// once, so one tenant cannot occupy every worker. The API also refuses
+// submissions from a tenant with this many jobs in progress, which keeps the
+// jobs set aside for the cap bounded. 0 means no cap.
+var MaxJobsPerTenant int
+
+var (
+	poolMutex sync.RWMutex
+	jobQueue  chan queuedJob
+	// drainStarted is closed when Shutdown starts
+	drainStarted chan struct{}
+	draining     bool
+	cancelJobs   context.CancelFunc
+	workers      sync.WaitGroup
+
+	// tenantJobs counts each tenant's jobs from Enqueue until they finish,
+	// tenantRunning counts the jobs being processed per tenant, and
+	// tenantWaiting holds jobs set aside because their tenant is at its cap
+	tenantMutex   sync.Mutex
+	tenantJobs    = make(map[string]int)
+	tenantRunning = make(map[string]int)
+	tenantWaiting = make(map[string][]queuedJob)
+)
+
+// StartPool starts size workers that process queued jobs in submission order,
+// except that jobs of a tenant at MaxJobsPerTenant wait for its running jobs.
+// Up to queueSize jobs wait in the queue; further submissions block until a
+// worker frees a slot.
+func StartPool(size, queueSize int) {
+	queue, drain := make(chan queuedJob, queueSize), make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	poolMutex.Lock()
+	jobQueue, drainStarted, draining, cancelJobs = queue, drain, false, cancel
+	poolMutex.Unlock()
+
+	for i := 0; i < size; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for {
+				select {
+				case <-drain:
+					return
+				case job := <-queue:
+					// Once a job finishes, a job of the same tenant that was set aside takes its place
+					for ok := claimTenantSlot(job); ok; job, ok = releaseTenantSlot(job.tenant) {
+						if Draining() {
+							interruptQueuedJob(job)
+							continue
+						}
+						job.span.End()
+						ProcessJobContext(ctx, job.id)
+						countTenantJob(job.tenant, -1)
+					}
+				}
+			}
+		}()
+	}
+}
+
+// Enqueue schedules a job for processing. Without a running pool the job is
+// processed in its own goroutine. Once Shutdown starts, jobs are refused with
+// ErrShuttingDown, including those waiting for room in a full queue.
+func Enqueue(jobID string) error {
+	return EnqueueContext(context.Background(), jobID)
+}
+
+// EnqueueContext is Enqueue with the time the job waits for a worker traced
+// as a child of the span in ctx
+func EnqueueContext(ctx context.Context, jobID string) error {
+	_, span := tracing.Start(ctx, "job.queue", tracing.WithAttributes(tracing.String("job.id", jobID)))
+	snapshot, _ := models.SnapshotJob(jobID)
+	job := queuedJob{id: jobID, tenant: snapshot.Tenant, span: span}
+	countTenantJob(job.tenant, 1)
+	for {
+		poolMutex.RLock()
+		queue, drain, stopping := jobQueue, drainStarted, draining
+		if queue == nil {
+			poolMutex.RUnlock()
+			span.End()
+			go func() {
+				ProcessJob(jobID)
+				countTenantJob(job.tenant, -1)
+			}()
+			return nil
+		}
+		if stopping {
+			poolMutex.RUnlock()
+			endRefused(job)
+			return ErrShuttingDown
+		}
+		// Sending under the lock guarantees Shutdown sees every queued job
+		select {
+		case queue <- job:
+			poolMutex.RUnlock()
+			return nil
+		default:
+			poolMutex.RUnlock()
+		}
+
+		select {
+		case <-drain:
+			endRefused(job)
+			return ErrShuttingDown
+		case <-time.After(queueRetryInterval):
+		}
+	}
+}
+
+// claimTenantSlot reports whether a dequeued job may start. Jobs of a tenant
+// at MaxJobsPerTenant are set aside until one of its jobs finishes.
+func claimTenantSlot(job queuedJob) bool {
+	tenantMutex.Lock()
+	defer tenantMutex.Unlock()
+
+	if MaxJobsPerTenant > 0 && tenantRunning[job.tenant] >= MaxJobsPerTenant {
+		tenantWaiting[job.tenant] = append(tenantWaiting[job.tenant], job)
+		return false
+	}
+	tenantRunning[job.tenant]++
+	return true
+}
+
+// releaseTenantSlot is called when a job of tenant finishes. It returns the
+// tenant's oldest job that was set aside, which takes over the slot.
+func releaseTenantSlot(tenant string) (queuedJob, bool) {
+	tenantMutex.Lock()
+	defer tenantMutex.Unlock()
+
+	if waiting := tenantWaiting[tenant]; len(waiting) > 0 {
+		next := waiting[0]
+		if len(waiting) == 1 {
+			delete(tenantWaiting, tenant)
+		} else {
+			tenantWaiting[tenant] = waiting[1:]
+		}
+		return next, true
+	}
+	if tenantRunning[tenant]--; tenantRunning[tenant] <= 0 {
+		delete(tenantRunning, tenant)
+	}
+	return queuedJob{}, false
+}
+
+// TenantJobs returns the number of tenant's jobs that are queued, set aside
+// for its cap or being processed
+func TenantJobs(tenant string) int {
+	tenantMutex.Lock()
+	defer tenantMutex.Unlock()
+
+	return tenantJobs[tenant]
+}
+
+// countTenantJob adds delta to the number of tenant's jobs in progress
+func countTenantJob(tenant string, delta int) {
+	tenantMutex.Lock()
+	defer tenantMutex.Unlock()
+
+	if tenantJobs[tenant] += delta; tenantJobs[tenant] <= 0 {
+		delete(tenantJobs, tenant)
+	}
+}
+
+// waitingForTenant returns the number of jobs set aside for their tenant's cap
+func waitingForTenant() int {
+	tenantMutex.Lock()
+	defer tenantMutex.Unlock()
+
+	waiting := 0
+	for _, jobs := range tenantWaiting {
+		waiting += len(jobs)
+	}
+	return waiting
+}
+
+// queueRetryInterval is how often Enqueue retries while the queue is full
+const queueRetryInterval = 10 * time.Millisecond
+
+// QueueStats returns the number of jobs waiting for a worker, including those
+// set aside for their tenant's cap, and the queue's capacity. Both are 0 when
+// no pool is running.
+func QueueStats() (depth, capacity int) {
+	poolMutex.RLock()
+	depth, capacity = len(jobQueue), cap(jobQueue)
+	poolMutex.RUnlock()
+
+	return depth + waitingForTenant(), capacity
+}
+
+// Draining reports whether the pool has stopped accepting jobs
+func Draining() bool {
+	poolMutex.RLock()
+	defer poolMutex.RUnlock()
+
+	return draining
+}
+
+// Shutdown stops accepting jobs and waits for running jobs to finish. Jobs
+// still queued are marked interrupted without starting. If ctx ends first,
+// running jobs are cancelled, marked interrupted, and ctx's error is returned.
+func Shutdown(ctx context.Context) error {
+	poolMutex.Lock()
+	if jobQueue == nil || draining {
+		poolMutex.Unlock()
+		return nil
+	}
+	draining = true
+	close(drainStarted)
+	queue, cancel := jobQueue, cancelJobs
+	poolMutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		cancel()
+		<-done
+		err = ctx.Err()
+	}
+	cancel()
+
+	for {
+		select {
+		case job := <-queue:
+			interruptQueuedJob(job)
+		default:
+			return err
+		}
+	}
+}
+
+func interruptQueuedJob(job queuedJob) {
+	slog.Warn("Job interrupted by shutdown before starting", "job_id", job.id)
+	job.span.SetStatus(tracing.StatusError, "interrupted by shutdown")
+	job.span.End()
+	models.InterruptJob(job.id, "server shut down before the job started")
+	countTenantJob(job.tenant, -1)
+}
+
+func endRefused(job queuedJob) {
+	job.span.RecordError(ErrShuttingDown)
+	job.span.End()
+	countTenantJob(job.tenant, -1)
+}
@@ -0,0 +1,190 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"backend-intern-assignment/models"
+)
+
+// blockingTransport serves mock images once release is closed, or fails when the request is cancelled
+func blockingTransport(started chan<- struct{}, release <-chan struct{}) *MockTransport {
+	return &MockTransport{RoundTripFunc: func(req *http.Request) (*http.Response, error) {
+		started <- struct{}{}
+		select {
+		case <-release:
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(createMockImage()))}, nil
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}}
+}
+
+func createPoolJob() string {
+	return models.CreateJob(models.JobRequest{
+		Count: 1,
+		Visits: []models.Visit{{
+			StoreID:   "RP00001",
+			ImageURLs: []string{"https://mock-url.com/image.jpg"},
+			VisitTime: "2023-10-21T15:04:05Z",
+		}},
+		QualityThresholds: noQualityChecks,
+	})
+}
+
+func waitForJob(t *testing.T, jobID string) string {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, _ := models.SnapshotJob(jobID)
+		if job.Status != "ongoing" {
+			return job.Status
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Job %s was not processed in time", jobID)
+	return ""
+}
+
+func TestPool(t *testing.T) {
+	initTestStoreMaster()
+	originalTransport, originalGPU := HTTPClient.Transport, GPU
+	GPU = NoGPUDelay{}
+	defer func() {
+		HTTPClient.Transport, GPU = originalTransport, originalGPU
+		jobQueue, draining = nil, false
+	}()
+
+	// Normal case: Enqueued jobs are processed by the pool
+	t.Run("ProcessesQueuedJobs", func(t *testing.T) {
+		StartPool(2, 10)
+		defer Shutdown(context.Background())
+
+		var ids []string
+		for i := 0; i < 5; i++ {
+			id := models.CreateJob(models.JobRequest{
+				Count:  1,
+				Visits: []models.Visit{{StoreID: "RX99999", VisitTime: "2023-10-21T15:04:05Z"}},
+			})
+			if err := Enqueue(id); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			ids = append(ids, id)
+		}
+		for _, id := range ids {
+			if status := waitForJob(t, id); status != "failed" {
+				t.Errorf("Expected job %s to fail on its invalid store, got %q", id, status)
+			}
+		}
+	})
+
+	// Normal case: Jobs of a tenant at its cap wait while other tenants' jobs run
+	t.Run("TenantCap", func(t *testing.T) {
+		MaxJobsPerTenant = 1
+		defer func() { MaxJobsPerTenant = 0 }()
+		started, release := make(chan struct{}, 3), make(chan struct{})
+		HTTPClient.Transport = blockingTransport(started, release)
+		StartPool(2, 10)
+		defer Shutdown(context.Background())
+
+		tenantJob := func(tenant string) string {
+			return models.CreateTenantJob(tenant, models.JobRequest{
+				Count: 1,
+				Visits: []models.Visit{{
+					StoreID:   "RP00001",
+					ImageURLs: []string{"https://mock-url.com/image.jpg"},
+					VisitTime: "2023-10-21T15:04:05Z",
+				}},
+				QualityThresholds: noQualityChecks,
+			})
+		}
+		ids := []string{tenantJob("acme"), tenantJob("acme"), tenantJob("globex")}
+		for _, id := range ids {
+			Enqueue(id)
+		}
+		if jobs := TenantJobs("acme"); jobs != 2 {
+			t.Errorf("Expected 2 acme jobs in progress, got %d", jobs)
+		}
+		<-started
+		<-started
+
+		if waiting := waitingForTenant(); waiting != 1 {
+			t.Errorf("Expected 1 job waiting for its tenant, got %d", waiting)
+		}
+		if depth, _ := QueueStats(); depth != 1 {
+			t.Errorf("Expected the waiting job in the queue depth, got %d", depth)
+		}
+		close(release)
+		for _, id := range ids {
+			if status := waitForJob(t, id); status != "completed" {
+				t.Errorf("Expected job %s to complete, got %q", id, status)
+			}
+		}
+		if waiting := waitingForTenant(); waiting != 0 {
+			t.Errorf("Expected no job left waiting, got %d", waiting)
+		}
+	})
+
+	// Normal case: Shutdown waits for running jobs and interrupts queued ones
+	t.Run("DrainsRunningJobs", func(t *testing.T) {
+		started, release := make(chan struct{}, 1), make(chan struct{})
+		HTTPClient.Transport = blockingTransport(started, release)
+		StartPool(1, 10)
+
+		running, queued := createPoolJob(), createPoolJob()
+		Enqueue(running)
+		Enqueue(queued)
+		<-started
+
+		done := make(chan error)
+		go func() { done <- Shutdown(context.Background()) }()
+		for !Draining() {
+			time.Sleep(time.Millisecond)
+		}
+		if err := Enqueue(createPoolJob()); !errors.Is(err, ErrShuttingDown) {
+			t.Errorf("Expected ErrShuttingDown while draining, got %v", err)
+		}
+		close(release)
+
+		if err := <-done; err != nil {
+			t.Errorf("Expected clean shutdown, got %v", err)
+		}
+		if status := waitForJob(t, running); status != "completed" {
+			t.Errorf("Expected running job to complete, got %q", status)
+		}
+		job, _ := models.SnapshotJob(queued)
+		if job.Status != models.JobStatusInterrupted || len(job.Errors) != 1 || job.Errors[0].Error != models.ErrJobInterrupted {
+			t.Errorf("Expected queued job to be interrupted, got %+v", job)
+		}
+	})
+
+	// Edge case: Jobs still running at the deadline are cancelled and marked interrupted
+	t.Run("DeadlineInterruptsJobs", func(t *testing.T) {
+		started := make(chan struct{}, 1)
+		HTTPClient.Transport = blockingTransport(started, make(chan struct{}))
+		StartPool(1, 10)
+
+		jobID := createPoolJob()
+		Enqueue(jobID)
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if err := Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected deadline exceeded, got %v", err)
+		}
+		job, _ := models.SnapshotJob(jobID)
+		if job.Status != models.JobStatusInterrupted {
+			t.Errorf("Expected job to be interrupted, got %q", job.Status)
+		}
+		for _, jobErr := range job.Errors {
+			if jobErr.Error == "Failed to download image" {
+				t.Errorf("Expected the cancelled download not to be recorded as a failure")
+			}
+		}
+	})
+}